@@ -6,9 +6,17 @@ import (
 	"slices"
 	"syscall"
 
-	magcli "github.com/openshift-pipelines/manual-approval-gate/pkg/cli"
 	magcmd "github.com/openshift-pipelines/manual-approval-gate/pkg/cli/cmd"
 	opccli "github.com/openshift-pipelines/opc/pkg"
+	"github.com/openshift-pipelines/opc/pkg/approvaltask"
+	opcbundle "github.com/openshift-pipelines/opc/pkg/bundle"
+	"github.com/openshift-pipelines/opc/pkg/lint"
+	opcpipeline "github.com/openshift-pipelines/opc/pkg/pipeline"
+	opcpipelinerun "github.com/openshift-pipelines/opc/pkg/pipelinerun"
+	opcresults "github.com/openshift-pipelines/opc/pkg/results"
+	"github.com/openshift-pipelines/opc/pkg/stepaction"
+	opctask "github.com/openshift-pipelines/opc/pkg/task"
+	"github.com/openshift-pipelines/opc/pkg/trustedresources"
 	paccli "github.com/openshift-pipelines/pipelines-as-code/pkg/cli"
 	"github.com/openshift-pipelines/pipelines-as-code/pkg/cmd/tknpac"
 	pacversion "github.com/openshift-pipelines/pipelines-as-code/pkg/cmd/tknpac/versioncmd"
@@ -39,6 +47,48 @@ func main() {
 	tkn := cmd.Root(tp)
 	tkn.Use = binaryName
 	tkn.Short = tknShortDesc
+	if prCmd, _, err := tkn.Find([]string{"pipelinerun"}); err == nil {
+		opcresults.AddHistory(prCmd, tp, resultscommon.ResourceTypePipelineRun)
+		prCmd.AddCommand(
+			opcresults.PipelineRunDiffCommand(tp),
+			opcpipelinerun.WatchCommand(tp),
+			opcpipelinerun.GraphCommand(tp),
+			opcpipelinerun.RerunCommand(tp),
+		)
+	}
+	if pCmd, _, err := tkn.Find([]string{"pipeline"}); err == nil {
+		pCmd.AddCommand(opcpipeline.GraphCommand(tp))
+		if verifyCmd, _, err := pCmd.Find([]string{"verify"}); err == nil {
+			trustedresources.AddClusterPolicies(verifyCmd, tp, "Pipeline")
+		}
+	}
+	if tCmd, _, err := tkn.Find([]string{"task"}); err == nil {
+		tCmd.AddCommand(opctask.RunLocalCommand(tp))
+		if verifyCmd, _, err := tCmd.Find([]string{"verify"}); err == nil {
+			trustedresources.AddClusterPolicies(verifyCmd, tp, "Task")
+		}
+	}
+	if trCmd, _, err := tkn.Find([]string{"taskrun"}); err == nil {
+		opcresults.AddHistory(trCmd, tp, resultscommon.ResourceTypeTaskRun)
+		trCmd.AddCommand(opcpipelinerun.TaskRunRerunCommand(tp))
+	}
+	if bCmd, _, err := tkn.Find([]string{"bundle"}); err == nil {
+		if pushCmd, _, err := bCmd.Find([]string{"push"}); err == nil {
+			opcbundle.AddSigning(pushCmd)
+		}
+		bCmd.AddCommand(
+			opcbundle.PullCommand(tp),
+			opcbundle.InspectCommand(tp),
+			opcbundle.DiffCommand(tp),
+			opcbundle.VerifyCommand(tp),
+		)
+	}
+	tkn.AddCommand(
+		stepaction.Command(tp),
+		lint.Command(),
+		trustedresources.SignCommand(tp),
+		trustedresources.VerifyCommand(tp),
+	)
 	clients := params.New()
 	pac := tknpac.Root(clients)
 	pac.Use = "pac"
@@ -47,10 +97,20 @@ func main() {
 	tkn.AddCommand(pac)
 
 	// adding manual approval gate cli
-	p := &magcli.ApprovalTaskParams{}
+	p := approvaltask.NewParams()
 	mag := magcmd.Root(p)
 	mag.Use = "approvaltask"
 	mag.Short = magShortDesc
+	p.AddFlags(mag)
+	replaceCommand(mag, approvaltask.ListCommand(p))
+	replaceCommand(mag, approvaltask.DescribeCommand(p))
+	mag.AddCommand(
+		approvaltask.HistoryCommand(p),
+		approvaltask.RevokeCommand(p),
+		approvaltask.DelegateCommand(p),
+		approvaltask.AddGateCommand(p),
+		approvaltask.WhoamiCommand(p),
+	)
 	tkn.AddCommand(mag)
 
 	// adding results
@@ -58,6 +118,23 @@ func main() {
 	results := resultscmd.Root(rp)
 	results.Use = "results"
 	results.Short = resultsShortDesc
+	if prCmd, _, err := results.Find([]string{"pipelinerun"}); err == nil {
+		replaceCommand(prCmd, opcresults.PipelineRunListCommand(rp))
+		replaceCommand(prCmd, opcresults.PipelineRunLogsCommand(rp))
+		prCmd.AddCommand(opcresults.PipelineRunRerunCommand(rp, tp))
+	}
+	if trCmd, _, err := results.Find([]string{"taskrun"}); err == nil {
+		replaceCommand(trCmd, opcresults.TaskRunListCommand(rp))
+	}
+	if logsCmd, _, err := results.Find([]string{"logs"}); err == nil {
+		logsCmd.AddCommand(opcresults.LogsGrepCommand(rp))
+	}
+	results.AddCommand(
+		opcresults.ExportCommand(rp),
+		opcresults.StatsCommand(rp),
+		opcresults.DeleteCommand(rp),
+		opcresults.PruneCommand(rp),
+	)
 	tkn.AddCommand(results)
 
 	// adding tekton assist
@@ -117,3 +194,14 @@ CoreTkn:
 		os.Exit(1)
 	}
 }
+
+// replaceCommand replaces the subcommand of parent with the same name as cmd,
+// used to extend the commands from the integrated CLIs.
+func replaceCommand(parent, cmd *cobra.Command) {
+	for _, c := range parent.Commands() {
+		if c.Name() == cmd.Name() {
+			parent.RemoveCommand(c)
+		}
+	}
+	parent.AddCommand(cmd)
+}
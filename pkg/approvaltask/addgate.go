@@ -0,0 +1,463 @@
+package approvaltask
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openshift-pipelines/manual-approval-gate/pkg/apis/approvaltask/v1alpha1"
+	"github.com/openshift-pipelines/manual-approval-gate/pkg/cli"
+	"github.com/openshift-pipelines/manual-approval-gate/pkg/cli/flags"
+	"github.com/spf13/cobra"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"go.yaml.in/yaml/v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+var pipelineResource = schema.GroupVersionResource{Group: "tekton.dev", Version: "v1", Resource: "pipelines"}
+
+const (
+	userPrefix  = "user:"
+	groupPrefix = "group:"
+)
+
+type addGateOptions struct {
+	Name        string
+	Before      string
+	Approvers   []string
+	Required    int
+	Description string
+	Timeout     string
+	Filename    string
+	DryRun      bool
+}
+
+func AddGateCommand(p cli.Params) *cobra.Command {
+	opts := &addGateOptions{Required: 1}
+	eg := `Require two approvals from alice or the release group before the deploy task of the Pipeline foo:
+    opc approvaltask add-gate foo --before deploy --approvers user:alice,group:release --required 2
+
+Add an approval gate to a Pipeline in a local file:
+    opc approvaltask add-gate -f pipeline.yaml --before deploy --approvers alice,bob --description "Deploy to production?"
+
+Add an approval gate to the Pipeline foo of a local file with several Pipelines:
+    opc approvaltask add-gate foo -f pipelines.yaml --before deploy --approvers alice
+
+Print the modified Pipeline without updating it:
+    opc approvaltask add-gate foo --before deploy --approvers alice --dry-run
+`
+	c := &cobra.Command{
+		Use:     "add-gate [pipeline]",
+		Short:   "Add an approval gate to a pipeline",
+		Long:    `This command inserts an approval task in a pipeline before the given task, the task then runs only once the approval task is approved. The pipeline is edited in place, its comments and version are kept. Approval gates cannot be added before finally tasks.`,
+		Example: eg,
+		Annotations: map[string]string{
+			"commandType": "main",
+		},
+		Args: func(cmd *cobra.Command, args []string) error {
+			if opts.Filename != "" {
+				return cobra.MaximumNArgs(1)(cmd, args)
+			}
+			if len(args) != 1 {
+				return fmt.Errorf("requires the name of the pipeline or a file with --filename")
+			}
+			return nil
+		},
+		PersistentPreRunE: flags.PersistentPreRunE(p),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			approvers, err := parseApprovers(opts.Approvers, opts.Required)
+			if err != nil {
+				return err
+			}
+
+			if opts.Filename != "" {
+				name := ""
+				if len(args) == 1 {
+					name = args[0]
+				}
+				return addGateToFile(cmd, name, opts, approvers)
+			}
+			return addGateToCluster(cmd, p, args[0], opts, approvers)
+		},
+	}
+	flags.AddOptions(c)
+
+	c.Flags().StringVar(&opts.Before, "before", "", "name of the pipeline task which has to wait for the approval")
+	c.Flags().StringSliceVar(&opts.Approvers, "approvers", nil, "comma separated approvers, prefixed by group: for groups (i.e: user:alice,group:release)")
+	c.Flags().IntVar(&opts.Required, "required", opts.Required, "number of approvals required")
+	c.Flags().StringVar(&opts.Description, "description", "", "description of the approval shown to the approvers")
+	c.Flags().StringVar(&opts.Timeout, "timeout", "", "timeout of the approval task (i.e: 1h)")
+	c.Flags().StringVar(&opts.Name, "name", "", "name of the approval pipeline task (default: approve-<before>)")
+	c.Flags().StringVarP(&opts.Filename, "filename", "f", "", "local file containing the pipeline to edit")
+	c.Flags().BoolVar(&opts.DryRun, "dry-run", false, "print the modified pipeline instead of saving it")
+	_ = c.MarkFlagRequired("before")
+	_ = c.MarkFlagRequired("approvers")
+
+	return c
+}
+
+// parseApprovers validates the approvers given on the command line and returns
+// them as values of the approvers param of the approval task, where users are
+// plain names and groups are prefixed by group:.
+func parseApprovers(approvers []string, required int) ([]string, error) {
+	if len(approvers) == 0 {
+		return nil, fmt.Errorf("at least one approver is required")
+	}
+	if required < 1 {
+		return nil, fmt.Errorf("the number of required approvals must be at least 1")
+	}
+
+	values := []string{}
+	for _, a := range approvers {
+		a = strings.TrimSpace(a)
+		approverType := approverTypeUser
+		name := a
+		if n, ok := strings.CutPrefix(a, groupPrefix); ok {
+			approverType = approverTypeGroup
+			name = n
+		} else if n, ok := strings.CutPrefix(a, userPrefix); ok {
+			name = n
+		}
+		if name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("invalid approver %q", a)
+		}
+
+		value := name
+		if approverType == approverTypeGroup {
+			value = groupPrefix + name
+		}
+		if slices.Contains(values, value) {
+			return nil, fmt.Errorf("approver %q is specified more than once", a)
+		}
+		values = append(values, value)
+	}
+
+	// the approval task counts one approval per user or group entry
+	if required > len(values) {
+		return nil, fmt.Errorf("%d approvals are required but only %d approvers are specified", required, len(values))
+	}
+	return values, nil
+}
+
+// addGate inserts the approval task before the given pipeline task of the
+// Pipeline document, the approval task takes over its dependencies and the
+// task runs after it. The document is edited in place so that its comments,
+// the order of its fields and its version are kept.
+func addGate(pipeline *yaml.Node, opts *addGateOptions, approvers []string) error {
+	var typeMeta struct {
+		APIVersion string `yaml:"apiVersion"`
+		Kind       string `yaml:"kind"`
+		Metadata   struct {
+			Name string `yaml:"name"`
+		} `yaml:"metadata"`
+	}
+	if err := pipeline.Decode(&typeMeta); err != nil {
+		return err
+	}
+	switch typeMeta.APIVersion {
+	case "tekton.dev/v1", "tekton.dev/v1beta1":
+	default:
+		return fmt.Errorf("unsupported Pipeline version %s", typeMeta.APIVersion)
+	}
+	pipelineName := typeMeta.Metadata.Name
+
+	var timeout *metav1.Duration
+	if opts.Timeout != "" {
+		d, err := time.ParseDuration(opts.Timeout)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid timeout %q, it must be a positive duration (i.e: 1h)", opts.Timeout)
+		}
+		timeout = &metav1.Duration{Duration: d}
+	}
+
+	name := opts.Name
+	if name == "" {
+		name = "approve-" + opts.Before
+	}
+	if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
+		return fmt.Errorf("invalid approval task name %q: %s", name, strings.Join(errs, ", "))
+	}
+
+	spec := nodeValue(pipeline, "spec")
+	tasks := nodeValue(spec, "tasks")
+	if tasks == nil || tasks.Kind != yaml.SequenceNode {
+		return fmt.Errorf("pipeline %s has no tasks", pipelineName)
+	}
+	if finally := nodeValue(spec, "finally"); finally != nil {
+		for _, t := range finally.Content {
+			switch nodeValue(t, "name").Value {
+			case name:
+				return fmt.Errorf("pipeline %s already has a task named %s", pipelineName, name)
+			case opts.Before:
+				return fmt.Errorf("task %s is a finally task of pipeline %s, approval gates can only be added before the tasks of the pipeline", opts.Before, pipelineName)
+			}
+		}
+	}
+
+	index := -1
+	for i, t := range tasks.Content {
+		switch nodeValue(t, "name").Value {
+		case name:
+			return fmt.Errorf("pipeline %s already has a task named %s", pipelineName, name)
+		case opts.Before:
+			index = i
+		}
+	}
+	if index < 0 {
+		return fmt.Errorf("task %s not found in pipeline %s", opts.Before, pipelineName)
+	}
+
+	before := v1.PipelineTask{}
+	if err := decodeNode(tasks.Content[index], &before); err != nil {
+		return fmt.Errorf("invalid task %s in pipeline %s: %v", opts.Before, pipelineName, err)
+	}
+
+	params := v1.Params{
+		// approvers is an array param, even with a single approver
+		{Name: "approvers", Value: v1.ParamValue{Type: v1.ParamTypeArray, ArrayVal: approvers}},
+		{Name: "numberOfApprovalsRequired", Value: *v1.NewStructuredValues(strconv.Itoa(opts.Required))},
+	}
+	if opts.Description != "" {
+		params = append(params, v1.Param{Name: "description", Value: *v1.NewStructuredValues(opts.Description)})
+	}
+	gate, err := encodeNode(v1.PipelineTask{
+		Name: name,
+		TaskRef: &v1.TaskRef{
+			APIVersion: v1alpha1.SchemeGroupVersion.String(),
+			Kind:       "ApprovalTask",
+		},
+		// the approvers are not asked when the task would be skipped
+		When:     before.When,
+		Params:   params,
+		RunAfter: before.Deps(),
+		Timeout:  timeout,
+	})
+	if err != nil {
+		return err
+	}
+	runAfter, err := encodeNode([]string{name})
+	if err != nil {
+		return err
+	}
+
+	setNodeValue(tasks.Content[index], "runAfter", runAfter)
+	tasks.Content = slices.Insert(tasks.Content, index, gate)
+	return nil
+}
+
+// nodeValue returns the value of the key of the mapping node, nil when it is
+// missing.
+func nodeValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setNodeValue replaces the value of the key of the mapping node, the key is
+// added when missing.
+func setNodeValue(node *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content[i+1] = value
+			return
+		}
+	}
+	node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+}
+
+// decodeNode decodes the node with the JSON tags of the Tekton types.
+func decodeNode(node *yaml.Node, into any) error {
+	var v any
+	if err := node.Decode(&v); err != nil {
+		return err
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, into)
+}
+
+// encodeNode returns the node of the value encoded with the JSON tags of the
+// Tekton types, in block style.
+func encodeNode(v any) (*yaml.Node, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	doc := &yaml.Node{}
+	if err := yaml.Unmarshal(b, doc); err != nil {
+		return nil, err
+	}
+	node := doc.Content[0]
+	blockStyle(node)
+	return node, nil
+}
+
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, n := range node.Content {
+		blockStyle(n)
+	}
+}
+
+// encodeDocuments returns the YAML of the documents.
+func encodeDocuments(docs []*yaml.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	for _, doc := range docs {
+		if err := enc.Encode(doc); err != nil {
+			return nil, err
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// pipelineDocument returns the Pipeline document of the file, the name of the
+// Pipeline is required when it has several.
+func pipelineDocument(filename, name string, docs []*yaml.Node) (*yaml.Node, error) {
+	var found *yaml.Node
+	for _, doc := range docs {
+		if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+			continue
+		}
+		root := doc.Content[0]
+		if kind := nodeValue(root, "kind"); kind == nil || kind.Value != "Pipeline" {
+			continue
+		}
+		if name != "" {
+			if n := nodeValue(nodeValue(root, "metadata"), "name"); n == nil || n.Value != name {
+				continue
+			}
+		}
+		if found != nil {
+			return nil, fmt.Errorf("%s contains several Pipelines, give the name of the one to edit", filename)
+		}
+		found = root
+	}
+	if found == nil {
+		if name != "" {
+			return nil, fmt.Errorf("%s does not contain the Pipeline %s", filename, name)
+		}
+		return nil, fmt.Errorf("%s does not contain a Pipeline", filename)
+	}
+	return found, nil
+}
+
+func addGateToFile(cmd *cobra.Command, name string, opts *addGateOptions, approvers []string) error {
+	info, err := os.Stat(opts.Filename)
+	if err != nil {
+		return err
+	}
+	b, err := os.ReadFile(opts.Filename)
+	if err != nil {
+		return err
+	}
+
+	docs := []*yaml.Node{}
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	for {
+		doc := &yaml.Node{}
+		err := dec.Decode(doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", opts.Filename, err)
+		}
+		docs = append(docs, doc)
+	}
+	pipeline, err := pipelineDocument(opts.Filename, name, docs)
+	if err != nil {
+		return err
+	}
+	if err := addGate(pipeline, opts, approvers); err != nil {
+		return err
+	}
+
+	out, err := encodeDocuments(docs)
+	if err != nil {
+		return err
+	}
+	if opts.DryRun {
+		_, err := cmd.OutOrStdout().Write(out)
+		return err
+	}
+	if err := os.WriteFile(opts.Filename, out, info.Mode().Perm()); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(cmd.OutOrStdout(), "Approval gate added before task %s in %s\n", opts.Before, opts.Filename)
+	return err
+}
+
+func addGateToCluster(cmd *cobra.Command, p cli.Params, name string, opts *addGateOptions, approvers []string) error {
+	cs, err := p.Clients()
+	if err != nil {
+		return err
+	}
+
+	ns := p.Namespace()
+	ctx := cmd.Context()
+	obj, err := cs.Dynamic.Resource(pipelineResource).Namespace(ns).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get Pipeline %s from namespace %s: %v", name, ns, err)
+	}
+
+	b, err := obj.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	doc := &yaml.Node{}
+	if err := yaml.Unmarshal(b, doc); err != nil {
+		return err
+	}
+	blockStyle(doc)
+	if err := addGate(doc.Content[0], opts, approvers); err != nil {
+		return err
+	}
+
+	if opts.DryRun {
+		out, err := encodeDocuments([]*yaml.Node{doc})
+		if err != nil {
+			return err
+		}
+		_, err = cmd.OutOrStdout().Write(out)
+		return err
+	}
+
+	var v any
+	if err := doc.Decode(&v); err != nil {
+		return err
+	}
+	if b, err = json.Marshal(v); err != nil {
+		return err
+	}
+	if err := obj.UnmarshalJSON(b); err != nil {
+		return err
+	}
+	if _, err := cs.Dynamic.Resource(pipelineResource).Namespace(ns).Update(ctx, obj, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update Pipeline %s in namespace %s: %v", name, ns, err)
+	}
+
+	_, err = fmt.Fprintf(cmd.OutOrStdout(), "Approval gate added before task %s in Pipeline %s in %s namespace\n", opts.Before, name, ns)
+	return err
+}
@@ -0,0 +1,341 @@
+package approvaltask
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/cobra"
+)
+
+const pipelines = `# the build pipelines
+apiVersion: tekton.dev/v1beta1
+kind: Pipeline
+metadata:
+  name: build
+spec:
+  tasks:
+    - name: build # compiles the app
+      taskRef:
+        name: buildah
+    - name: deploy
+      runAfter: [build]
+      params:
+        - name: image
+          value: $(tasks.build.results.IMAGE_URL)
+      taskRef:
+        name: kubectl
+  finally:
+    - name: notify
+      taskRef:
+        name: slack
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  replicas: "2"
+---
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: release
+spec:
+  tasks:
+    - name: deploy
+      taskRef:
+        name: kubectl
+`
+
+func TestAddGateToFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		pipeline string
+		opts     addGateOptions
+		want     string
+		wantErr  string
+	}{{
+		name:     "gate added in place",
+		pipeline: "build",
+		opts:     addGateOptions{Before: "deploy", Required: 1, Description: "Deploy?"},
+		want: `# the build pipelines
+apiVersion: tekton.dev/v1beta1
+kind: Pipeline
+metadata:
+  name: build
+spec:
+  tasks:
+    - name: build # compiles the app
+      taskRef:
+        name: buildah
+    - name: approve-deploy
+      taskRef:
+        kind: ApprovalTask
+        apiVersion: openshift-pipelines.org/v1alpha1
+      runAfter:
+        - build
+      params:
+        - name: approvers
+          value:
+            - alice
+            - group:release
+        - name: numberOfApprovalsRequired
+          value: "1"
+        - name: description
+          value: Deploy?
+    - name: deploy
+      runAfter:
+        - approve-deploy
+      params:
+        - name: image
+          value: $(tasks.build.results.IMAGE_URL)
+      taskRef:
+        name: kubectl
+  finally:
+    - name: notify
+      taskRef:
+        name: slack
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  replicas: "2"
+---
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: release
+spec:
+  tasks:
+    - name: deploy
+      taskRef:
+        name: kubectl
+`,
+	}, {
+		name:    "several pipelines",
+		opts:    addGateOptions{Before: "deploy", Required: 1},
+		wantErr: "pipelines.yaml contains several Pipelines, give the name of the one to edit",
+	}, {
+		name:     "unknown pipeline",
+		pipeline: "test",
+		opts:     addGateOptions{Before: "deploy", Required: 1},
+		wantErr:  "pipelines.yaml does not contain the Pipeline test",
+	}, {
+		name:     "finally task",
+		pipeline: "build",
+		opts:     addGateOptions{Before: "notify", Required: 1},
+		wantErr:  "task notify is a finally task of pipeline build, approval gates can only be added before the tasks of the pipeline",
+	}, {
+		name:     "existing task",
+		pipeline: "build",
+		opts:     addGateOptions{Before: "deploy", Name: "build", Required: 1},
+		wantErr:  "pipeline build already has a task named build",
+	}, {
+		name:     "unknown task",
+		pipeline: "release",
+		opts:     addGateOptions{Before: "test", Required: 1},
+		wantErr:  "task test not found in pipeline release",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "pipelines.yaml")
+			if err := os.WriteFile(filename, []byte(pipelines), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			out := &bytes.Buffer{}
+			cmd := &cobra.Command{}
+			cmd.SetOut(out)
+			tt.opts.Filename = filename
+			tt.opts.DryRun = true
+
+			err := addGateToFile(cmd, tt.pipeline, &tt.opts, []string{"alice", "group:release"})
+			if tt.wantErr != "" {
+				if err == nil || strings.TrimPrefix(err.Error(), filepath.Dir(filename)+"/") != tt.wantErr {
+					t.Fatalf("addGateToFile() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d := cmp.Diff(tt.want, out.String()); d != "" {
+				t.Errorf("addGateToFile() mismatch (-want +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestParseApprovers(t *testing.T) {
+	tests := []struct {
+		name      string
+		approvers []string
+		required  int
+		want      []string
+		wantErr   string
+	}{{
+		name:      "users and groups",
+		approvers: []string{"user:alice", " bob", "group:release"},
+		required:  3,
+		want:      []string{"alice", "bob", "group:release"},
+	}, {
+		name:      "duplicate",
+		approvers: []string{"alice", "user:alice"},
+		required:  1,
+		wantErr:   `approver "user:alice" is specified more than once`,
+	}, {
+		name:      "duplicate group",
+		approvers: []string{"group:release", "alice", "group:release"},
+		required:  1,
+		wantErr:   `approver "group:release" is specified more than once`,
+	}, {
+		name:      "user and group of the same name",
+		approvers: []string{"release", "group:release"},
+		required:  2,
+		want:      []string{"release", "group:release"},
+	}, {
+		name:      "too many approvals",
+		approvers: []string{"alice"},
+		required:  2,
+		wantErr:   "2 approvals are required but only 1 approvers are specified",
+	}, {
+		name:      "too many approvals with groups",
+		approvers: []string{"alice", "group:release"},
+		required:  3,
+		wantErr:   "3 approvals are required but only 2 approvers are specified",
+	}, {
+		name:      "no approval",
+		approvers: []string{"alice"},
+		required:  0,
+		wantErr:   "the number of required approvals must be at least 1",
+	}, {
+		name:      "empty group",
+		approvers: []string{"group:"},
+		required:  1,
+		wantErr:   `invalid approver "group:"`,
+	}, {
+		name:      "blank group",
+		approvers: []string{"group: "},
+		required:  1,
+		wantErr:   `invalid approver "group:"`,
+	}, {
+		name:      "empty user",
+		approvers: []string{"user:"},
+		required:  1,
+		wantErr:   `invalid approver "user:"`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseApprovers(tt.approvers, tt.required)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("parseApprovers() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("parseApprovers() mismatch (-want +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestAddGateTimeoutAndWhen(t *testing.T) {
+	pipeline := `apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: release
+spec:
+  tasks:
+    - name: deploy
+      when:
+        - input: $(params.env)
+          operator: in
+          values: [prod]
+      taskRef:
+        name: kubectl
+`
+	tests := []struct {
+		name    string
+		timeout string
+		want    string
+		wantErr string
+	}{{
+		name:    "timeout and when of the task",
+		timeout: "90m",
+		want: `apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: release
+spec:
+  tasks:
+    - name: approve-deploy
+      taskRef:
+        kind: ApprovalTask
+        apiVersion: openshift-pipelines.org/v1alpha1
+      when:
+        - input: $(params.env)
+          operator: in
+          values:
+            - prod
+      params:
+        - name: approvers
+          value:
+            - alice
+        - name: numberOfApprovalsRequired
+          value: "1"
+      timeout: 1h30m0s
+    - name: deploy
+      when:
+        - input: $(params.env)
+          operator: in
+          values: [prod]
+      taskRef:
+        name: kubectl
+      runAfter:
+        - approve-deploy
+`,
+	}, {
+		name:    "invalid timeout",
+		timeout: "1 hour",
+		wantErr: `invalid timeout "1 hour", it must be a positive duration (i.e: 1h)`,
+	}, {
+		name:    "negative timeout",
+		timeout: "-1h",
+		wantErr: `invalid timeout "-1h", it must be a positive duration (i.e: 1h)`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "pipeline.yaml")
+			if err := os.WriteFile(filename, []byte(pipeline), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			out := &bytes.Buffer{}
+			cmd := &cobra.Command{}
+			cmd.SetOut(out)
+			opts := &addGateOptions{Before: "deploy", Required: 1, Timeout: tt.timeout, Filename: filename, DryRun: true}
+
+			err := addGateToFile(cmd, "", opts, []string{"alice"})
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("addGateToFile() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d := cmp.Diff(tt.want, out.String()); d != "" {
+				t.Errorf("addGateToFile() mismatch (-want +got):\n%s", d)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+// Package approvaltask extends the manual approval gate CLI with opc specific
+// commands.
+package approvaltask
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/openshift-pipelines/manual-approval-gate/pkg/actions"
+	"github.com/openshift-pipelines/manual-approval-gate/pkg/apis/approvaltask/v1alpha1"
+	"github.com/openshift-pipelines/manual-approval-gate/pkg/cli"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"knative.dev/pkg/apis"
+)
+
+var taskGroupResource = schema.GroupVersionResource{Group: "openshift-pipelines.org", Resource: "approvaltasks"}
+
+const (
+	approverTypeUser  = "User"
+	approverTypeGroup = "Group"
+
+	pipelineRunLabel = "tekton.dev/pipelineRun"
+)
+
+// response converts an approver input (approve, reject, pending) to the
+// response recorded in the ApprovalTask status.
+func response(input string) string {
+	switch input {
+	case "approve", "approved":
+		return "approved"
+	case "reject", "rejected":
+		return "rejected"
+	default:
+		return "pending"
+	}
+}
+
+// completionTime returns the time the ApprovalTask has been approved or
+// rejected, nil if it is still pending.
+func completionTime(at *v1alpha1.ApprovalTask) *metav1.Time {
+	if at.Status.State == "" || at.Status.State == "pending" {
+		return nil
+	}
+	cond := at.Status.GetCondition(apis.ConditionSucceeded)
+	if cond == nil {
+		return nil
+	}
+	return &cond.LastTransitionTime.Inner
+}
+
+// pendingApprovals returns the number of approvals still needed, counting
+// every user who responded only once even if they are in several groups.
+func pendingApprovals(at *v1alpha1.ApprovalTask) int {
+	respondedUsers := make(map[string]bool)
+
+	for _, approver := range at.Status.ApproversResponse {
+		switch v1alpha1.DefaultedApproverType(approver.Type) {
+		case approverTypeUser:
+			respondedUsers[approver.Name] = true
+		case approverTypeGroup:
+			for _, member := range approver.GroupMembers {
+				if member.Response == "approved" || member.Response == "rejected" {
+					respondedUsers[member.Name] = true
+				}
+			}
+		}
+	}
+
+	return at.Spec.NumberOfApprovalsRequired - len(respondedUsers)
+}
+
+// rejected returns the number of users who rejected the ApprovalTask.
+func rejected(at *v1alpha1.ApprovalTask) int {
+	rejectedUsers := make(map[string]bool)
+
+	for _, approver := range at.Status.ApproversResponse {
+		switch v1alpha1.DefaultedApproverType(approver.Type) {
+		case approverTypeUser:
+			if approver.Response == "rejected" {
+				rejectedUsers[approver.Name] = true
+			}
+		case approverTypeGroup:
+			for _, member := range approver.GroupMembers {
+				if member.Response == "rejected" {
+					rejectedUsers[member.Name] = true
+				}
+			}
+		}
+	}
+
+	return len(rejectedUsers)
+}
+
+// isApprover returns true if the user is an approver of the ApprovalTask,
+// either directly or through one of their groups.
+func isApprover(at *v1alpha1.ApprovalTask, username string, groups []string) bool {
+	for _, approver := range at.Spec.Approvers {
+		switch v1alpha1.DefaultedApproverType(approver.Type) {
+		case approverTypeUser:
+			if approver.Name == username {
+				return true
+			}
+		case approverTypeGroup:
+			if slices.Contains(groups, approver.Name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// updateApprovalTask updates the ApprovalTask on the cluster.
+func updateApprovalTask(ctx context.Context, cs *cli.Clients, at *v1alpha1.ApprovalTask) error {
+	gvr, err := actions.GetGroupVersionResource(taskGroupResource, cs.ApprovalTask.Discovery())
+	if err != nil {
+		return err
+	}
+
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(at)
+	if err != nil {
+		return fmt.Errorf("failed to convert ApprovalTask %s: %w", at.Name, err)
+	}
+
+	_, err = cs.Dynamic.Resource(*gvr).Namespace(at.Namespace).Update(ctx, &unstructured.Unstructured{Object: obj}, metav1.UpdateOptions{})
+	return err
+}
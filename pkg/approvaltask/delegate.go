@@ -0,0 +1,172 @@
+package approvaltask
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/openshift-pipelines/manual-approval-gate/pkg/actions"
+	"github.com/openshift-pipelines/manual-approval-gate/pkg/apis/approvaltask/v1alpha1"
+	"github.com/openshift-pipelines/manual-approval-gate/pkg/cli"
+	"github.com/openshift-pipelines/manual-approval-gate/pkg/cli/flags"
+	"github.com/spf13/cobra"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type delegateOptions struct {
+	To      string
+	Message string
+}
+
+func DelegateCommand(p cli.Params) *cobra.Command {
+	opts := &delegateOptions{}
+	eg := `Hand over your approval of the ApprovalTask foo to alice:
+    opc approvaltask delegate foo --to alice -m "on leave until monday"
+`
+	c := &cobra.Command{
+		Use:     "delegate",
+		Short:   "Delegate your approval of the approvaltask to another user",
+		Long:    `This command adds another user to the approvers of the approvaltask, before you respond. Only the approvals given to you as a user can be delegated, not the ones of your groups. The delegation is recorded in the openshift-pipelines.org/approver-history annotation. It needs the permission to patch approvaltasks in the namespace.`,
+		Example: eg,
+		Annotations: map[string]string{
+			"commandType": "main",
+		},
+		Args:              cobra.ExactArgs(1),
+		PersistentPreRunE: flags.PersistentPreRunE(p),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.To == "" {
+				return fmt.Errorf("the user to delegate to must be specified with --to")
+			}
+
+			cs, err := p.Clients()
+			if err != nil {
+				return err
+			}
+
+			ns := p.Namespace()
+			username, groups, err := p.GetUserInfo()
+			if err != nil {
+				return err
+			}
+
+			at, err := actions.Get(taskGroupResource, cs, &cli.Options{Namespace: ns, Name: args[0]})
+			if err != nil {
+				return fmt.Errorf("failed to get ApprovalTask %s from namespace %s: %v", args[0], ns, err)
+			}
+			if at.Status.State != inputPending {
+				return fmt.Errorf("ApprovalTask %s is already %s, it cannot be delegated anymore", args[0], at.Status.State)
+			}
+			if !isApprover(at, username, groups) {
+				return fmt.Errorf("approver: %s, is not present in the approvers list", username)
+			}
+			for _, approver := range at.Spec.Approvers {
+				if v1alpha1.DefaultedApproverType(approver.Type) == approverTypeUser && approver.Name == opts.To {
+					return fmt.Errorf("%s is already an approver of ApprovalTask %s", opts.To, args[0])
+				}
+			}
+
+			review := &authorizationv1.SelfSubjectAccessReview{
+				Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+					ResourceAttributes: &authorizationv1.ResourceAttributes{
+						Namespace: ns,
+						Verb:      "patch",
+						Group:     taskGroupResource.Group,
+						Resource:  taskGroupResource.Resource,
+						Name:      args[0],
+					},
+				},
+			}
+			res, err := cs.Kube.AuthorizationV1().SelfSubjectAccessReviews().Create(cmd.Context(), review, metav1.CreateOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to check permissions on ApprovalTask %s: %v", args[0], err)
+			}
+			if !res.Status.Allowed {
+				return fmt.Errorf("%s is not allowed to patch ApprovalTask %s in namespace %s", username, args[0], ns)
+			}
+
+			patch, err := delegatePatch(at, username, opts.To, opts.Message, time.Now())
+			if err != nil {
+				return err
+			}
+
+			gvr, err := actions.GetGroupVersionResource(taskGroupResource, cs.ApprovalTask.Discovery())
+			if err != nil {
+				return err
+			}
+			if _, err := cs.Dynamic.Resource(*gvr).Namespace(ns).Patch(cmd.Context(), args[0], types.JSONPatchType, patch, metav1.PatchOptions{}); err != nil {
+				return fmt.Errorf("failed to delegate ApprovalTask %s from namespace %s: %v", args[0], ns, err)
+			}
+
+			_, err = fmt.Fprintf(cmd.OutOrStdout(), "ApprovalTask %s is delegated to %s in %s namespace\n", args[0], opts.To, ns)
+			return err
+		},
+	}
+
+	c.Flags().StringVar(&opts.To, "to", "", "user to delegate the approval to")
+	c.Flags().StringVarP(&opts.Message, "message", "m", "", "message explaining the delegation")
+
+	flags.AddOptions(c)
+
+	return c
+}
+
+// delegatePatch returns the JSON patch adding the delegate to the approvers
+// and recording the delegation in the approver history annotation. The test
+// operations make it fail if the response of the user or the history changed
+// in the meantime.
+func delegatePatch(at *v1alpha1.ApprovalTask, username, to, message string, now time.Time) ([]byte, error) {
+	for i, approver := range at.Spec.Approvers {
+		if v1alpha1.DefaultedApproverType(approver.Type) != approverTypeUser || approver.Name != username {
+			continue
+		}
+		if approver.Input != inputPending {
+			return nil, fmt.Errorf("approver: %s, has already responded to ApprovalTask %s, revoke the response before delegating", username, at.Name)
+		}
+
+		delegateMessage := fmt.Sprintf("delegated by %s", username)
+		if message != "" {
+			delegateMessage = fmt.Sprintf("%s: %s", delegateMessage, message)
+		}
+		history, err := appendApproverHistory(at, ApproverChange{Action: changeDelegated, Approver: username, To: to, Message: message, Time: now})
+		if err != nil {
+			return nil, err
+		}
+
+		path := fmt.Sprintf("/spec/approvers/%d", i)
+		ops := []map[string]any{{
+			"op":    "test",
+			"path":  path + "/name",
+			"value": username,
+		}, {
+			"op":    "test",
+			"path":  path + "/input",
+			"value": inputPending,
+		}, {
+			"op":   "add",
+			"path": "/spec/approvers/-",
+			"value": v1alpha1.ApproverDetails{
+				Name:    to,
+				Input:   inputPending,
+				Message: delegateMessage,
+				Type:    approverTypeUser,
+			},
+		}}
+
+		annotation := "/metadata/annotations/" + strings.ReplaceAll(approverHistoryAnnotation, "/", "~1")
+		switch previous, ok := at.Annotations[approverHistoryAnnotation]; {
+		case at.Annotations == nil:
+			ops = append(ops, map[string]any{"op": "add", "path": "/metadata/annotations", "value": map[string]string{approverHistoryAnnotation: history}})
+		case ok:
+			ops = append(ops,
+				map[string]any{"op": "test", "path": annotation, "value": previous},
+				map[string]any{"op": "replace", "path": annotation, "value": history})
+		default:
+			ops = append(ops, map[string]any{"op": "add", "path": annotation, "value": history})
+		}
+		return json.Marshal(ops)
+	}
+	return nil, fmt.Errorf("approver: %s, is an approver of ApprovalTask %s only through a group, group approvals cannot be delegated", username, at.Name)
+}
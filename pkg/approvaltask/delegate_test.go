@@ -0,0 +1,87 @@
+package approvaltask
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openshift-pipelines/manual-approval-gate/pkg/apis/approvaltask/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDelegatePatch(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	approvers := []v1alpha1.ApproverDetails{
+		{Name: "release", Type: approverTypeGroup, Input: inputPending},
+		{Name: "alice", Input: inputPending},
+		{Name: "bob", Input: "approve"},
+	}
+
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		username    string
+		message     string
+		want        string
+		wantErr     string
+	}{{
+		name:     "delegate is added without annotations",
+		username: "alice",
+		want: `[{"op":"test","path":"/spec/approvers/1/name","value":"alice"},` +
+			`{"op":"test","path":"/spec/approvers/1/input","value":"pending"},` +
+			`{"op":"add","path":"/spec/approvers/-","value":{"name":"carol","input":"pending","message":"delegated by alice","type":"User"}},` +
+			`{"op":"add","path":"/metadata/annotations","value":{"openshift-pipelines.org/approver-history":"[{\"action\":\"delegated\",\"approver\":\"alice\",\"to\":\"carol\",\"time\":\"2024-05-01T10:00:00Z\"}]"}}]`,
+	}, {
+		name:        "delegation is added to the annotations",
+		annotations: map[string]string{"team": "a"},
+		username:    "alice",
+		message:     "on leave",
+		want: `[{"op":"test","path":"/spec/approvers/1/name","value":"alice"},` +
+			`{"op":"test","path":"/spec/approvers/1/input","value":"pending"},` +
+			`{"op":"add","path":"/spec/approvers/-","value":{"name":"carol","input":"pending","message":"delegated by alice: on leave","type":"User"}},` +
+			`{"op":"add","path":"/metadata/annotations/openshift-pipelines.org~1approver-history","value":"[{\"action\":\"delegated\",\"approver\":\"alice\",\"to\":\"carol\",\"message\":\"on leave\",\"time\":\"2024-05-01T10:00:00Z\"}]"}]`,
+	}, {
+		name:        "delegation is appended to the approver history",
+		annotations: map[string]string{approverHistoryAnnotation: `[{"action":"revoked","approver":"alice","response":"approved","time":"2024-04-30T10:00:00Z"}]`},
+		username:    "alice",
+		want: `[{"op":"test","path":"/spec/approvers/1/name","value":"alice"},` +
+			`{"op":"test","path":"/spec/approvers/1/input","value":"pending"},` +
+			`{"op":"add","path":"/spec/approvers/-","value":{"name":"carol","input":"pending","message":"delegated by alice","type":"User"}},` +
+			`{"op":"test","path":"/metadata/annotations/openshift-pipelines.org~1approver-history","value":"[{\"action\":\"revoked\",\"approver\":\"alice\",\"response\":\"approved\",\"time\":\"2024-04-30T10:00:00Z\"}]"},` +
+			`{"op":"replace","path":"/metadata/annotations/openshift-pipelines.org~1approver-history","value":"[{\"action\":\"revoked\",\"approver\":\"alice\",\"response\":\"approved\",\"time\":\"2024-04-30T10:00:00Z\"},{\"action\":\"delegated\",\"approver\":\"alice\",\"to\":\"carol\",\"time\":\"2024-05-01T10:00:00Z\"}]"}]`,
+	}, {
+		name:        "invalid approver history",
+		annotations: map[string]string{approverHistoryAnnotation: `{}`},
+		username:    "alice",
+		wantErr:     "invalid openshift-pipelines.org/approver-history annotation on ApprovalTask gate: json: cannot unmarshal object into Go value of type []approvaltask.ApproverChange",
+	}, {
+		name:     "responded approver",
+		username: "bob",
+		wantErr:  "approver: bob, has already responded to ApprovalTask gate, revoke the response before delegating",
+	}, {
+		name:     "group approver",
+		username: "dave",
+		wantErr:  "approver: dave, is an approver of ApprovalTask gate only through a group, group approvals cannot be delegated",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			at := &v1alpha1.ApprovalTask{
+				ObjectMeta: metav1.ObjectMeta{Name: "gate", Annotations: tt.annotations},
+				Spec:       v1alpha1.ApprovalTaskSpec{Approvers: approvers},
+			}
+			got, err := delegatePatch(at, tt.username, "carol", tt.message, now)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("delegatePatch() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d := cmp.Diff(tt.want, string(got)); d != "" {
+				t.Errorf("delegatePatch() mismatch (-want +got):\n%s", d)
+			}
+		})
+	}
+}
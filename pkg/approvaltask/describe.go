@@ -0,0 +1,61 @@
+package approvaltask
+
+import (
+	"fmt"
+
+	"github.com/openshift-pipelines/manual-approval-gate/pkg/actions"
+	"github.com/openshift-pipelines/manual-approval-gate/pkg/cli"
+	"github.com/openshift-pipelines/manual-approval-gate/pkg/cli/cmd/describe"
+	"github.com/spf13/cobra"
+	cliopts "k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+// DescribeCommand returns the manual approval gate describe command with
+// support for the -o print flags, the default output is left untouched.
+func DescribeCommand(p cli.Params) *cobra.Command {
+	c := describe.Command(p)
+	f := cliopts.NewPrintFlags("describe")
+	runE := c.RunE
+
+	c.Example = `Describe an ApprovalTask:
+    opc approvaltask describe foo
+
+Print an ApprovalTask as json:
+    opc approvaltask describe foo -o json
+
+Print the approvers of an ApprovalTask:
+    opc approvaltask describe foo -o jsonpath='{.spec.approvers[*].name}'
+`
+	c.RunE = func(cmd *cobra.Command, args []string) error {
+		output, err := cmd.LocalFlags().GetString("output")
+		if err != nil {
+			return fmt.Errorf("output option not set properly: %v", err)
+		}
+		if output == "" {
+			return runE(cmd, args)
+		}
+
+		cs, err := p.Clients()
+		if err != nil {
+			return err
+		}
+
+		at, err := actions.Get(taskGroupResource, cs, &cli.Options{Namespace: p.Namespace(), Name: args[0]})
+		if err != nil {
+			return fmt.Errorf("failed to Get ApprovalTasks %s from %s namespace: %v", args[0], p.Namespace(), err)
+		}
+
+		if output == "name" {
+			_, err := fmt.Fprintf(cmd.OutOrStdout(), "approvaltask.%s/%s\n", taskGroupResource.Group, at.Name)
+			return err
+		}
+		printer, err := f.ToPrinter()
+		if err != nil {
+			return err
+		}
+		return printer.PrintObj(at, cmd.OutOrStdout())
+	}
+	f.AddFlags(c)
+
+	return c
+}
@@ -0,0 +1,401 @@
+package approvaltask
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/openshift-pipelines/manual-approval-gate/pkg/actions"
+	"github.com/openshift-pipelines/manual-approval-gate/pkg/apis/approvaltask/v1alpha1"
+	"github.com/openshift-pipelines/manual-approval-gate/pkg/cli"
+	"github.com/openshift-pipelines/manual-approval-gate/pkg/cli/flags"
+	opcflags "github.com/openshift-pipelines/opc/pkg/flags"
+	"github.com/spf13/cobra"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	"github.com/tektoncd/results/pkg/cli/client/records"
+	resultscommon "github.com/tektoncd/results/pkg/cli/common"
+	"github.com/tektoncd/results/pkg/cli/common/prerun"
+	pb "github.com/tektoncd/results/proto/v1alpha2/results_go_proto"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/apis"
+)
+
+const (
+	sourceCluster = "cluster"
+	sourceResults = "results"
+
+	customRunFilter = `data_type=="tekton.dev/v1beta1.CustomRun" && data.spec.customRef.kind=="ApprovalTask"`
+)
+
+type historyOptions struct {
+	AllNamespaces bool
+	Since         string
+	Output        string
+	Results       bool
+}
+
+// HistoryEntry is a single decision taken by an approver on an ApprovalTask.
+type HistoryEntry struct {
+	Namespace      string       `json:"namespace"`
+	ApprovalTask   string       `json:"approvalTask"`
+	PipelineRun    string       `json:"pipelineRun,omitempty"`
+	Approver       string       `json:"approver"`
+	Group          string       `json:"group,omitempty"`
+	Response       string       `json:"response"`
+	Delegate       string       `json:"delegate,omitempty"`
+	Message        string       `json:"message,omitempty"`
+	State          string       `json:"state"`
+	StartTime      *metav1.Time `json:"startTime,omitempty"`
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	Source         string       `json:"source"`
+}
+
+func HistoryCommand(p cli.Params) *cobra.Command {
+	opts := &historyOptions{}
+	eg := `List the decisions taken on ApprovalTasks in the current namespace:
+    opc approvaltask history
+
+Export the decisions of the last 30 days in all namespaces as CSV:
+    opc approvaltask history -A --since 30d -o csv
+
+Include the ApprovalTasks which have been pruned and are only in Tekton Results:
+    opc approvaltask history --results -o json
+`
+	c := &cobra.Command{
+		Use:     "history",
+		Short:   "List the decisions taken on approval tasks",
+		Long:    `This command lists who approved or rejected approval tasks, with their message and the final state of the task. The revoked responses and the delegations recorded on the approval tasks of the cluster are listed too.`,
+		Example: eg,
+		Annotations: map[string]string{
+			"commandType": "main",
+		},
+		Args:              cobra.NoArgs,
+		PersistentPreRunE: flags.PersistentPreRunE(p),
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			switch opts.Output {
+			case "", "csv", "json":
+			default:
+				return fmt.Errorf("invalid output format %q, must be one of csv or json", opts.Output)
+			}
+			since, err := opcflags.ParseTime(opts.Since, time.Now())
+			if err != nil {
+				return err
+			}
+
+			cs, err := p.Clients()
+			if err != nil {
+				return err
+			}
+
+			ns := p.Namespace()
+			if opts.AllNamespaces {
+				ns = ""
+			}
+
+			var at *v1alpha1.ApprovalTaskList
+			if err := actions.List(taskGroupResource, cs, metav1.ListOptions{}, ns, &at); err != nil {
+				return fmt.Errorf("failed to list ApprovalTasks from namespace %s: %v", ns, err)
+			}
+
+			entries := []HistoryEntry{}
+			seen := map[string]bool{}
+			for i := range at.Items {
+				seen[at.Items[i].Namespace+"/"+at.Items[i].Name] = true
+				e, err := approvalTaskHistory(&at.Items[i])
+				if err != nil {
+					return err
+				}
+				entries = append(entries, e...)
+			}
+
+			if opts.Results {
+				archived, err := resultsHistory(cmd, resultsParams(p), ns, since, seen)
+				if err != nil {
+					return err
+				}
+				entries = append(entries, archived...)
+			}
+
+			entries = filterHistory(entries, since)
+			return printHistory(cmd.OutOrStdout(), entries, opts.Output)
+		},
+	}
+	flags.AddOptions(c)
+
+	c.Flags().BoolVarP(&opts.AllNamespaces, "all-namespaces", "A", opts.AllNamespaces, "list decisions from all namespaces")
+	c.Flags().StringVar(&opts.Since, "since", "", "only show approval tasks started after this time (duration like 7d, 12h or RFC3339 timestamp)")
+	c.Flags().StringVarP(&opts.Output, "output", "o", "", "output format, one of csv or json")
+	c.Flags().BoolVar(&opts.Results, "results", false, "also read the decisions of pruned approval tasks from Tekton Results")
+
+	return c
+}
+
+// approvalTaskHistory returns the decisions recorded in the status of an
+// ApprovalTask, one entry per user and per group member who responded,
+// followed by the revocations and delegations of its approver history, the
+// most recent first.
+func approvalTaskHistory(at *v1alpha1.ApprovalTask) ([]HistoryEntry, error) {
+	base := HistoryEntry{
+		Namespace:      at.Namespace,
+		ApprovalTask:   at.Name,
+		PipelineRun:    at.Labels[pipelineRunLabel],
+		State:          response(at.Status.State),
+		StartTime:      at.Status.StartTime,
+		CompletionTime: completionTime(at),
+		Source:         sourceCluster,
+	}
+	if base.StartTime == nil {
+		base.StartTime = &at.CreationTimestamp
+	}
+
+	entries := []HistoryEntry{}
+	for _, approver := range at.Status.ApproversResponse {
+		if v1alpha1.DefaultedApproverType(approver.Type) == approverTypeGroup {
+			for _, member := range approver.GroupMembers {
+				if response(member.Response) == "pending" {
+					continue
+				}
+				e := base
+				e.Approver = member.Name
+				e.Group = approver.Name
+				e.Response = response(member.Response)
+				e.Message = member.Message
+				entries = append(entries, e)
+			}
+			continue
+		}
+		if response(approver.Response) == "pending" {
+			continue
+		}
+		e := base
+		e.Approver = approver.Name
+		e.Response = response(approver.Response)
+		e.Message = approver.Message
+		entries = append(entries, e)
+	}
+
+	changes, err := approverHistory(at)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(changes) - 1; i >= 0; i-- {
+		e := base
+		e.Approver = changes[i].Approver
+		e.Group = changes[i].Group
+		e.Response = changes[i].Action
+		e.Delegate = changes[i].To
+		e.Message = changes[i].Message
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// resultsParams returns the Results params with the cluster of the command.
+func resultsParams(p cli.Params) *resultscommon.ResultsParams {
+	rp := &resultscommon.ResultsParams{}
+	if kp, ok := p.(*Params); ok {
+		rp.SetKubeConfigPath(kp.KubeConfigPath())
+		rp.SetKubeContext(kp.KubeContext())
+	}
+	rp.SetNamespace(p.Namespace())
+	return rp
+}
+
+// historyFilter returns the CEL filter of the CustomRuns of the ApprovalTasks
+// created after since, when it is set.
+func historyFilter(since time.Time) string {
+	if since.IsZero() {
+		return customRunFilter
+	}
+	return fmt.Sprintf(`%s && create_time>=timestamp(%q)`, customRunFilter, since.UTC().Format(time.RFC3339))
+}
+
+// resultsHistory returns the decisions of the ApprovalTasks which are not in
+// the cluster anymore, from the Tekton Results records of their CustomRuns.
+func resultsHistory(cmd *cobra.Command, rp *resultscommon.ResultsParams, ns string, since time.Time, seen map[string]bool) ([]HistoryEntry, error) {
+	restClient, err := prerun.InitClient(rp, cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Tekton Results client: %w", err)
+	}
+
+	parent := fmt.Sprintf("%s/results/-", ns)
+	if ns == "" {
+		parent = resultscommon.AllNamespacesResultsParent
+	}
+	req := &pb.ListRecordsRequest{
+		Parent:   parent,
+		Filter:   historyFilter(since),
+		OrderBy:  "create_time desc",
+		PageSize: 100,
+	}
+
+	entries := []HistoryEntry{}
+	rc := records.NewClient(restClient)
+	for {
+		resp, err := rc.ListRecords(cmd.Context(), req, resultscommon.NameUIDAndDataField+",next_page_token")
+		if err != nil {
+			return nil, fmt.Errorf("failed to list CustomRuns from Tekton Results: %w", err)
+		}
+		for _, record := range resp.Records {
+			var run v1beta1.CustomRun
+			if err := json.Unmarshal(record.Data.Value, &run); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal CustomRun %s: %w", record.Name, err)
+			}
+			if seen[run.Namespace+"/"+run.Name] {
+				continue
+			}
+			seen[run.Namespace+"/"+run.Name] = true
+			e, err := customRunHistory(&run)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, e...)
+		}
+		if resp.NextPageToken == "" {
+			break
+		}
+		req.PageToken = resp.NextPageToken
+	}
+	return entries, nil
+}
+
+// customRunHistory returns the decisions stored by the approval controller in
+// the status of the CustomRun owning an ApprovalTask, the approvers who did
+// not respond are skipped like in approvalTaskHistory.
+func customRunHistory(run *v1beta1.CustomRun) ([]HistoryEntry, error) {
+	status := v1alpha1.ApprovalTaskRunStatus{}
+	if err := run.Status.DecodeExtraFields(&status); err != nil {
+		return nil, fmt.Errorf("failed to decode status of CustomRun %s: %w", run.Name, err)
+	}
+	if status.ApprovalTaskSpec == nil {
+		return nil, nil
+	}
+
+	state := "pending"
+	if cond := run.Status.GetCondition(apis.ConditionSucceeded); cond != nil {
+		switch {
+		case cond.IsTrue():
+			state = "approved"
+		case cond.IsFalse():
+			state = "rejected"
+		}
+	}
+	base := HistoryEntry{
+		Namespace:      run.Namespace,
+		ApprovalTask:   run.Name,
+		PipelineRun:    run.Labels[pipelineRunLabel],
+		State:          state,
+		StartTime:      run.Status.StartTime,
+		CompletionTime: run.Status.CompletionTime,
+		Source:         sourceResults,
+	}
+	if base.StartTime == nil {
+		base.StartTime = &run.CreationTimestamp
+	}
+
+	entries := []HistoryEntry{}
+	for _, approver := range status.ApprovalTaskSpec.Approvers {
+		if v1alpha1.DefaultedApproverType(approver.Type) == approverTypeGroup {
+			for _, user := range approver.Users {
+				if response(user.Input) == "pending" {
+					continue
+				}
+				e := base
+				e.Approver = user.Name
+				e.Group = approver.Name
+				e.Response = response(user.Input)
+				e.Message = user.Message
+				entries = append(entries, e)
+			}
+			continue
+		}
+		if response(approver.Input) == "pending" {
+			continue
+		}
+		e := base
+		e.Approver = approver.Name
+		e.Response = response(approver.Input)
+		e.Message = approver.Message
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+func filterHistory(entries []HistoryEntry, since time.Time) []HistoryEntry {
+	filtered := []HistoryEntry{}
+	for _, e := range entries {
+		if !since.IsZero() && e.StartTime != nil && e.StartTime.Time.Before(since) {
+			continue
+		}
+		filtered = append(filtered, e)
+	}
+	sort.SliceStable(filtered, func(i, j int) bool {
+		return timeOf(filtered[i]).After(timeOf(filtered[j]))
+	})
+	return filtered
+}
+
+func timeOf(e HistoryEntry) time.Time {
+	if e.CompletionTime != nil {
+		return e.CompletionTime.Time
+	}
+	if e.StartTime != nil {
+		return e.StartTime.Time
+	}
+	return time.Time{}
+}
+
+func formatTime(t *metav1.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func printHistory(out io.Writer, entries []HistoryEntry, output string) error {
+	switch output {
+	case "json":
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	case "csv":
+		w := csv.NewWriter(out)
+		if err := w.Write([]string{"namespace", "approvaltask", "pipelinerun", "approver", "group", "response", "delegate", "message", "state", "started", "completed", "source"}); err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if err := w.Write([]string{e.Namespace, e.ApprovalTask, e.PipelineRun, e.Approver, e.Group, e.Response, e.Delegate, e.Message, e.State, formatTime(e.StartTime), formatTime(e.CompletionTime), e.Source}); err != nil {
+				return err
+			}
+		}
+		w.Flush()
+		return w.Error()
+	}
+
+	if len(entries) == 0 {
+		_, err := fmt.Fprintln(out, "No approval decisions found")
+		return err
+	}
+	w := tabwriter.NewWriter(out, 0, 5, 3, ' ', tabwriter.TabIndent)
+	fmt.Fprintln(w, "NAMESPACE\tAPPROVALTASK\tAPPROVER\tGROUP\tRESPONSE\tMESSAGE\tSTATE\tCOMPLETED")
+	for _, e := range entries {
+		response := e.Response
+		if e.Delegate != "" {
+			response = fmt.Sprintf("%s to %s", response, e.Delegate)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			e.Namespace, e.ApprovalTask, e.Approver, dash(e.Group), response, dash(e.Message), e.State, dash(formatTime(e.CompletionTime)))
+	}
+	return w.Flush()
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "---"
+	}
+	return s
+}
@@ -0,0 +1,117 @@
+package approvaltask
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openshift-pipelines/manual-approval-gate/pkg/apis/approvaltask/v1alpha1"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestApprovalTaskHistory(t *testing.T) {
+	start := metav1.NewTime(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC))
+	at := &v1alpha1.ApprovalTask{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "gate",
+			Namespace: "ns",
+			Labels:    map[string]string{pipelineRunLabel: "pr"},
+			Annotations: map[string]string{approverHistoryAnnotation: `[` +
+				`{"action":"revoked","approver":"alice","response":"approved","message":"wrong build","time":"2024-05-01T10:10:00Z"},` +
+				`{"action":"delegated","approver":"bob","to":"alice","message":"on leave","time":"2024-05-01T10:20:00Z"}]`},
+		},
+		Status: v1alpha1.ApprovalTaskStatus{
+			State:     "pending",
+			StartTime: &start,
+			ApproversResponse: []v1alpha1.ApproverState{
+				{Name: "alice", Response: "approved", Message: "lgtm"},
+				{Name: "bob", Response: "pending"},
+				{Name: "release", Type: approverTypeGroup, GroupMembers: []v1alpha1.GroupMemberState{
+					{Name: "carol", Response: "rejected", Message: "not yet"},
+					{Name: "dave", Response: "pending"},
+				}},
+			},
+		},
+	}
+
+	base := HistoryEntry{Namespace: "ns", ApprovalTask: "gate", PipelineRun: "pr", State: "pending", StartTime: &start, Source: sourceCluster}
+	alice := base
+	alice.Approver, alice.Response, alice.Message = "alice", "approved", "lgtm"
+	carol := base
+	carol.Approver, carol.Group, carol.Response, carol.Message = "carol", "release", "rejected", "not yet"
+	delegated := base
+	delegated.Approver, delegated.Response, delegated.Delegate, delegated.Message = "bob", "delegated", "alice", "on leave"
+	revoked := base
+	revoked.Approver, revoked.Response, revoked.Message = "alice", "revoked", "wrong build"
+
+	got, err := approvalTaskHistory(at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := cmp.Diff([]HistoryEntry{alice, carol, delegated, revoked}, got); d != "" {
+		t.Errorf("approvalTaskHistory() mismatch (-want +got):\n%s", d)
+	}
+}
+
+func TestCustomRunHistory(t *testing.T) {
+	start := metav1.NewTime(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC))
+	run := &v1beta1.CustomRun{ObjectMeta: metav1.ObjectMeta{Name: "gate", Namespace: "ns"}}
+	run.Status.StartTime = &start
+	status := v1alpha1.ApprovalTaskRunStatus{ApprovalTaskSpec: &v1alpha1.ApprovalTaskSpec{
+		Approvers: []v1alpha1.ApproverDetails{
+			{Name: "alice", Input: "approve", Message: "lgtm"},
+			{Name: "bob", Input: "pending"},
+			{Name: "release", Type: approverTypeGroup, Users: []v1alpha1.UserDetails{
+				{Name: "carol", Input: "reject", Message: "not yet"},
+				{Name: "dave", Input: "pending"},
+			}},
+		},
+	}}
+	if err := run.Status.EncodeExtraFields(&status); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := customRunHistory(run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, e := range got {
+		names = append(names, e.Approver+"="+e.Response)
+	}
+	if d := cmp.Diff([]string{"alice=approved", "carol=rejected"}, names); d != "" {
+		t.Errorf("customRunHistory() mismatch (-want +got):\n%s", d)
+	}
+}
+
+func TestHistoryFilter(t *testing.T) {
+	if got := historyFilter(time.Time{}); got != customRunFilter {
+		t.Errorf("historyFilter() = %s, want %s", got, customRunFilter)
+	}
+	since := time.Date(2024, 5, 1, 12, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+	want := customRunFilter + ` && create_time>=timestamp("2024-05-01T10:00:00Z")`
+	if got := historyFilter(since); got != want {
+		t.Errorf("historyFilter() = %s, want %s", got, want)
+	}
+}
+
+func TestFilterHistory(t *testing.T) {
+	at := func(h int) *metav1.Time {
+		t := metav1.NewTime(time.Date(2024, 5, 1, h, 0, 0, 0, time.UTC))
+		return &t
+	}
+	entries := []HistoryEntry{
+		{Approver: "old", StartTime: at(1), CompletionTime: at(2)},
+		{Approver: "pending", StartTime: at(5)},
+		{Approver: "recent", StartTime: at(3), CompletionTime: at(4)},
+	}
+
+	got := []string{}
+	for _, e := range filterHistory(entries, time.Date(2024, 5, 1, 3, 0, 0, 0, time.UTC)) {
+		got = append(got, e.Approver)
+	}
+	if d := cmp.Diff([]string{"pending", "recent"}, got); d != "" {
+		t.Errorf("filterHistory() mismatch (-want +got):\n%s", d)
+	}
+}
@@ -0,0 +1,334 @@
+package approvaltask
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/openshift-pipelines/manual-approval-gate/pkg/cli"
+	userv1typedclient "github.com/openshift/client-go/user/clientset/versioned/typed/user/v1"
+	"github.com/spf13/cobra"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8s "k8s.io/client-go/kubernetes"
+	authenticationv1client "k8s.io/client-go/kubernetes/typed/authentication/v1"
+	"k8s.io/client-go/rest"
+)
+
+// Identity is the user name and groups used to match the approvers of an
+// ApprovalTask.
+type Identity struct {
+	Username string   `json:"username"`
+	Groups   []string `json:"groups"`
+	// Resolver is the name of the resolver which determined the identity.
+	Resolver string `json:"resolver"`
+}
+
+// IdentityResolver determines the identity of the user running the command
+// from the client configuration.
+type IdentityResolver interface {
+	Name() string
+	Resolve(ctx context.Context, cfg *rest.Config) (*Identity, error)
+}
+
+// Params are the manual approval gate params with impersonation support and
+// an identity resolution which does not depend on OpenShift.
+type Params struct {
+	*cli.ApprovalTaskParams
+
+	// Resolvers are tried in order until one of them determines the identity.
+	Resolvers []IdentityResolver
+
+	kubeConfigPath string
+	kubeContext    string
+	as             string
+	asGroups       []string
+	oidc           *oidcResolver
+	clients        *cli.Clients
+}
+
+var _ cli.Params = (*Params)(nil)
+
+func NewParams() *Params {
+	oidc := &oidcResolver{UsernameClaim: "sub", GroupsClaim: "groups"}
+	return &Params{
+		ApprovalTaskParams: &cli.ApprovalTaskParams{},
+		Resolvers: []IdentityResolver{
+			selfSubjectReviewResolver{},
+			openshiftUserResolver{},
+			impersonationResolver{},
+			oidc,
+		},
+		oidc: oidc,
+	}
+}
+
+// AddFlags adds the cluster, impersonation and identity flags to the command.
+func (p *Params) AddFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(&p.kubeConfigPath, "kubeconfig", "", "kubectl config file (default: $HOME/.kube/config)")
+	cmd.PersistentFlags().StringVar(&p.kubeContext, "context", "", "name of the kubeconfig context to use (default: kubectl config current-context)")
+	cmd.PersistentFlags().StringVar(&p.as, "as", "", "username to impersonate for the operation")
+	cmd.PersistentFlags().StringSliceVar(&p.asGroups, "as-group", nil, "group to impersonate for the operation, this flag can be repeated to specify multiple groups")
+	cmd.PersistentFlags().StringVar(&p.oidc.UsernameClaim, "oidc-username-claim", p.oidc.UsernameClaim, "claim of the OIDC token holding the username, when the identity is read from the token")
+	cmd.PersistentFlags().StringVar(&p.oidc.GroupsClaim, "oidc-groups-claim", p.oidc.GroupsClaim, "claim of the OIDC token holding the groups, when the identity is read from the token")
+}
+
+// Clients returns the clients, impersonating the user given with --as and
+// --as-group when set.
+func (p *Params) Clients(cfg ...*rest.Config) (*cli.Clients, error) {
+	p.ApprovalTaskParams.SetKubeConfigPath(p.kubeConfigPath)
+	p.ApprovalTaskParams.SetKubeContext(p.kubeContext)
+	if len(cfg) != 0 && cfg[0] != nil {
+		cs, err := p.ApprovalTaskParams.Clients(cfg...)
+		p.clients = cs
+		return cs, err
+	}
+
+	cs, err := p.ApprovalTaskParams.Clients()
+	if err != nil {
+		return nil, err
+	}
+	if p.as == "" && len(p.asGroups) == 0 {
+		p.clients = cs
+		return cs, nil
+	}
+	if p.as == "" {
+		return nil, fmt.Errorf("--as-group requires --as to be set")
+	}
+
+	config := rest.CopyConfig(cs.Config)
+	config.Impersonate = rest.ImpersonationConfig{
+		UserName: p.as,
+		Groups:   p.asGroups,
+	}
+	cs, err = p.ApprovalTaskParams.Clients(config)
+	p.clients = cs
+	return cs, err
+}
+
+// KubeClient returns the Kubernetes client of the cluster given with
+// --kubeconfig and --context.
+func (p *Params) KubeClient() (k8s.Interface, error) {
+	p.ApprovalTaskParams.SetKubeConfigPath(p.kubeConfigPath)
+	p.ApprovalTaskParams.SetKubeContext(p.kubeContext)
+	return p.ApprovalTaskParams.KubeClient()
+}
+
+// SetKubeConfigPath sets the kubeconfig, the same as --kubeconfig.
+func (p *Params) SetKubeConfigPath(path string) {
+	p.kubeConfigPath = path
+}
+
+// SetKubeContext sets the kubeconfig context, the same as --context.
+func (p *Params) SetKubeContext(context string) {
+	p.kubeContext = context
+}
+
+// KubeConfigPath returns the kubeconfig given with --kubeconfig.
+func (p *Params) KubeConfigPath() string {
+	return p.kubeConfigPath
+}
+
+// KubeContext returns the kubeconfig context given with --context.
+func (p *Params) KubeContext() string {
+	return p.kubeContext
+}
+
+// Identity returns the identity of the user, trying each resolver in order.
+func (p *Params) Identity(ctx context.Context) (*Identity, error) {
+	if p.clients == nil {
+		if _, err := p.Clients(); err != nil {
+			return nil, err
+		}
+	}
+
+	errs := []error{}
+	for _, r := range p.Resolvers {
+		id, err := r.Resolve(ctx, p.clients.Config)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", r.Name(), err))
+			continue
+		}
+		if id == nil || id.Username == "" {
+			continue
+		}
+		id.Resolver = r.Name()
+		return id, nil
+	}
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("cannot determine the current user, use --as to set it explicitly")
+	}
+	return nil, fmt.Errorf("cannot determine the current user, use --as to set it explicitly: %w", errors.Join(errs...))
+}
+
+func (p *Params) GetUserInfo() (string, []string, error) {
+	id, err := p.Identity(context.Background())
+	if err != nil {
+		return "", []string{}, err
+	}
+	return id.Username, id.Groups, nil
+}
+
+// impersonationResolver uses the user impersonated with --as, which is the
+// user the API server sees, when the cluster cannot tell it.
+type impersonationResolver struct{}
+
+func (impersonationResolver) Name() string {
+	return "impersonation"
+}
+
+func (impersonationResolver) Resolve(_ context.Context, cfg *rest.Config) (*Identity, error) {
+	if cfg.Impersonate.UserName == "" {
+		return nil, nil
+	}
+	return &Identity{Username: cfg.Impersonate.UserName, Groups: cfg.Impersonate.Groups}, nil
+}
+
+// selfSubjectReviewResolver asks the API server who the user is, it needs
+// Kubernetes 1.28 or later.
+type selfSubjectReviewResolver struct{}
+
+func (selfSubjectReviewResolver) Name() string {
+	return "selfsubjectreview"
+}
+
+func (selfSubjectReviewResolver) Resolve(ctx context.Context, cfg *rest.Config) (*Identity, error) {
+	client, err := authenticationv1client.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.SelfSubjectReviews().Create(ctx, &authenticationv1.SelfSubjectReview{}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return &Identity{Username: res.Status.UserInfo.Username, Groups: res.Status.UserInfo.Groups}, nil
+}
+
+// openshiftUserResolver reads the current user from the OpenShift user API.
+type openshiftUserResolver struct{}
+
+func (openshiftUserResolver) Name() string {
+	return "openshift"
+}
+
+func (openshiftUserResolver) Resolve(ctx context.Context, cfg *rest.Config) (*Identity, error) {
+	client, err := userv1typedclient.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	user, err := client.Users().Get(ctx, "~", metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return &Identity{Username: user.Name, Groups: user.Groups}, nil
+}
+
+// oidcResolver reads the identity from the claims of the OIDC token of the
+// kubeconfig, or of the exec credential plugin (kubelogin, oc...). The token
+// is not verified, the API server does it when the approval is sent.
+type oidcResolver struct {
+	UsernameClaim string
+	GroupsClaim   string
+}
+
+func (*oidcResolver) Name() string {
+	return "oidc"
+}
+
+func (r *oidcResolver) Resolve(ctx context.Context, cfg *rest.Config) (*Identity, error) {
+	token := cfg.BearerToken
+	if token == "" && cfg.BearerTokenFile != "" {
+		b, err := os.ReadFile(cfg.BearerTokenFile)
+		if err != nil {
+			return nil, err
+		}
+		token = strings.TrimSpace(string(b))
+	}
+	if token == "" && cfg.AuthProvider != nil && cfg.AuthProvider.Name == "oidc" {
+		token = cfg.AuthProvider.Config["id-token"]
+	}
+	if token == "" && cfg.ExecProvider != nil {
+		var err error
+		if token, err = execToken(ctx, cfg); err != nil {
+			return nil, fmt.Errorf("failed to get the token of the exec credential plugin %s: %w", cfg.ExecProvider.Command, err)
+		}
+	}
+	if token == "" {
+		return nil, fmt.Errorf("no token in kubeconfig")
+	}
+
+	claims, err := tokenClaims(token)
+	if err != nil {
+		return nil, err
+	}
+
+	username, ok := claims[r.UsernameClaim].(string)
+	if !ok || username == "" {
+		return nil, fmt.Errorf("token has no %s claim", r.UsernameClaim)
+	}
+
+	id := &Identity{Username: username, Groups: []string{}}
+	switch groups := claims[r.GroupsClaim].(type) {
+	case string:
+		id.Groups = append(id.Groups, groups)
+	case []any:
+		for _, g := range groups {
+			if s, ok := g.(string); ok {
+				id.Groups = append(id.Groups, s)
+			}
+		}
+	}
+	return id, nil
+}
+
+// execToken returns the bearer token of the exec credential plugin of the
+// config, the plugin is run by the client-go authenticator and the token is
+// read from the request it authenticates, which is not sent.
+func execToken(ctx context.Context, cfg *rest.Config) (string, error) {
+	token := ""
+	rt, err := rest.HTTPWrappersForConfig(cfg, roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		token, _ = strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+	}))
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, cfg.Host, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	return token, nil
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// tokenClaims decodes the claims of a JWT without verifying its signature.
+func tokenClaims(token string) (map[string]any, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("token is not a JWT")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode token payload: %w", err)
+	}
+	claims := map[string]any{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("cannot decode token claims: %w", err)
+	}
+	return claims, nil
+}
@@ -0,0 +1,109 @@
+package approvaltask
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/rest"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// jwt returns an unsigned JWT with the claims.
+func jwt(claims string) string {
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString([]byte(`{"alg":"none"}`)) + "." + enc.EncodeToString([]byte(claims)) + "."
+}
+
+func TestOIDCResolver(t *testing.T) {
+	token := jwt(`{"sub":"alice","email":"alice@example.com","groups":["dev","release"]}`)
+
+	plugin := filepath.Join(t.TempDir(), "credentials")
+	credential := fmt.Sprintf(`{"apiVersion":"client.authentication.k8s.io/v1","kind":"ExecCredential","status":{"token":%q}}`, token)
+	if err := os.WriteFile(plugin, []byte("#!/bin/sh\necho '"+credential+"'\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte(token+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		cfg      *rest.Config
+		resolver oidcResolver
+		want     *Identity
+		wantErr  string
+	}{{
+		name:     "bearer token",
+		cfg:      &rest.Config{BearerToken: token},
+		resolver: oidcResolver{UsernameClaim: "sub", GroupsClaim: "groups"},
+		want:     &Identity{Username: "alice", Groups: []string{"dev", "release"}},
+	}, {
+		name:     "token file",
+		cfg:      &rest.Config{BearerTokenFile: tokenFile},
+		resolver: oidcResolver{UsernameClaim: "email", GroupsClaim: "groups"},
+		want:     &Identity{Username: "alice@example.com", Groups: []string{"dev", "release"}},
+	}, {
+		name:     "auth provider",
+		cfg:      &rest.Config{AuthProvider: &clientcmdapi.AuthProviderConfig{Name: "oidc", Config: map[string]string{"id-token": token}}},
+		resolver: oidcResolver{UsernameClaim: "sub", GroupsClaim: "roles"},
+		want:     &Identity{Username: "alice", Groups: []string{}},
+	}, {
+		name: "exec provider",
+		cfg: &rest.Config{Host: "https://api.example.com", ExecProvider: &clientcmdapi.ExecConfig{
+			Command:         plugin,
+			APIVersion:      "client.authentication.k8s.io/v1",
+			InteractiveMode: clientcmdapi.NeverExecInteractiveMode,
+		}},
+		resolver: oidcResolver{UsernameClaim: "sub", GroupsClaim: "groups"},
+		want:     &Identity{Username: "alice", Groups: []string{"dev", "release"}},
+	}, {
+		name:     "no token",
+		cfg:      &rest.Config{},
+		resolver: oidcResolver{UsernameClaim: "sub", GroupsClaim: "groups"},
+		wantErr:  "no token in kubeconfig",
+	}, {
+		name:     "missing claim",
+		cfg:      &rest.Config{BearerToken: token},
+		resolver: oidcResolver{UsernameClaim: "preferred_username", GroupsClaim: "groups"},
+		wantErr:  "token has no preferred_username claim",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.resolver.Resolve(context.Background(), tt.cfg)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Resolve() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("Resolve() mismatch (-want +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestParamsFlags(t *testing.T) {
+	p := NewParams()
+	c := &cobra.Command{Use: "approvaltask"}
+	p.AddFlags(c)
+	if err := c.ParseFlags([]string{"--kubeconfig", "/tmp/kubeconfig", "--context", "prod", "--as", "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	p.SetNamespace("ns")
+
+	rp := resultsParams(p)
+	if rp.KubeConfigPath() != "/tmp/kubeconfig" || rp.KubeContext() != "prod" || rp.Namespace() != "ns" {
+		t.Errorf("resultsParams() = %s %s %s, want /tmp/kubeconfig prod ns", rp.KubeConfigPath(), rp.KubeContext(), rp.Namespace())
+	}
+}
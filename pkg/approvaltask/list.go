@@ -0,0 +1,170 @@
+package approvaltask
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"text/template"
+
+	"github.com/openshift-pipelines/manual-approval-gate/pkg/actions"
+	"github.com/openshift-pipelines/manual-approval-gate/pkg/apis/approvaltask/v1alpha1"
+	"github.com/openshift-pipelines/manual-approval-gate/pkg/cli"
+	"github.com/openshift-pipelines/manual-approval-gate/pkg/cli/flags"
+	"github.com/openshift-pipelines/manual-approval-gate/pkg/cli/formatter"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	cliopts "k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+const (
+	sortByAge     = "age"
+	sortByPending = "pending"
+)
+
+const listTemplate = `{{- $at := len .ApprovalTasks.Items }}{{ if eq $at 0 -}}
+No ApprovalTasks found
+{{else -}}
+{{- if not $.NoHeaders -}}
+{{- if $.AllNamespaces -}}
+NAMESPACE	NAME	NumberOfApprovalsRequired	PendingApprovals	Rejected	STATUS
+{{ else -}}
+NAME	NumberOfApprovalsRequired	PendingApprovals	Rejected	STATUS
+{{ end -}}
+{{- end -}}
+{{- range .ApprovalTasks.Items -}}
+{{- if $.AllNamespaces -}}
+{{.Namespace}}	{{.Name}}	{{.Spec.NumberOfApprovalsRequired}}	{{pendingApprovals .}}	{{rejected .}}	{{state .}}
+{{ else -}}
+{{.Name}}	{{.Spec.NumberOfApprovalsRequired}}	{{pendingApprovals .}}	{{rejected .}}	{{state .}}
+{{ end -}}
+{{- end -}}
+{{- end -}}
+`
+
+type listOptions struct {
+	AllNamespaces bool
+	NoHeaders     bool
+	LabelSelector string
+	SortBy        string
+}
+
+func ListCommand(p cli.Params) *cobra.Command {
+	opts := &listOptions{}
+	f := cliopts.NewPrintFlags("list")
+	eg := `List all ApprovalTasks in the current namespace:
+    opc approvaltask list
+
+List the ApprovalTasks of a PipelineRun as yaml:
+    opc approvaltask list --label tekton.dev/pipelineRun=foo -o yaml
+
+List the ApprovalTasks waiting for the most approvals first:
+    opc approvaltask list --sort-by pending
+`
+
+	c := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List all approval tasks",
+		Long:    `This command lists all the approval tasks.`,
+		Example: eg,
+		Annotations: map[string]string{
+			"commandType": "main",
+		},
+		PersistentPreRunE: flags.PersistentPreRunE(p),
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			switch opts.SortBy {
+			case "", sortByAge, sortByPending:
+			default:
+				return fmt.Errorf("invalid sort key %q, must be one of %s or %s", opts.SortBy, sortByAge, sortByPending)
+			}
+
+			cs, err := p.Clients()
+			if err != nil {
+				return err
+			}
+
+			ns := p.Namespace()
+			if opts.AllNamespaces {
+				ns = ""
+			}
+
+			var at *v1alpha1.ApprovalTaskList
+			if err := actions.List(taskGroupResource, cs, metav1.ListOptions{LabelSelector: opts.LabelSelector}, ns, &at); err != nil {
+				return fmt.Errorf("failed to list ApprovalTasks from namespace %s: %v", ns, err)
+			}
+			sortApprovalTasks(at.Items, opts.SortBy)
+
+			output, err := cmd.LocalFlags().GetString("output")
+			if err != nil {
+				return fmt.Errorf("output option not set properly: %v", err)
+			}
+
+			if output == "name" {
+				w := cmd.OutOrStdout()
+				for _, t := range at.Items {
+					if _, err := fmt.Fprintf(w, "approvaltask.%s/%s\n", taskGroupResource.Group, t.Name); err != nil {
+						return err
+					}
+				}
+				return nil
+			} else if output != "" {
+				p, err := f.ToPrinter()
+				if err != nil {
+					return err
+				}
+				return p.PrintObj(at, cmd.OutOrStdout())
+			}
+
+			return printApprovalTasks(cmd.OutOrStdout(), at, opts.AllNamespaces, opts.NoHeaders)
+		},
+	}
+	flags.AddOptions(c)
+	f.AddFlags(c)
+
+	c.Flags().BoolVarP(&opts.AllNamespaces, "all-namespaces", "A", opts.AllNamespaces, "list ApprovalTasks from all namespaces")
+	c.Flags().BoolVarP(&opts.NoHeaders, "no-headers", "", opts.NoHeaders, "do not print column headers with output (default print column headers with output)")
+	c.Flags().StringVarP(&opts.LabelSelector, "label", "", opts.LabelSelector, "A selector (label query) to filter on, supports '=', '==', and '!='")
+	c.Flags().StringVarP(&opts.SortBy, "sort-by", "", opts.SortBy, "sort the approval tasks, one of age (newest first) or pending (most pending approvals first)")
+
+	return c
+}
+
+func sortApprovalTasks(items []v1alpha1.ApprovalTask, sortBy string) {
+	switch sortBy {
+	case sortByAge:
+		sort.SliceStable(items, func(i, j int) bool {
+			return items[j].CreationTimestamp.Before(&items[i].CreationTimestamp)
+		})
+	case sortByPending:
+		sort.SliceStable(items, func(i, j int) bool {
+			return pendingApprovals(&items[i]) > pendingApprovals(&items[j])
+		})
+	}
+}
+
+func printApprovalTasks(out io.Writer, at *v1alpha1.ApprovalTaskList, allNamespaces, noHeaders bool) error {
+	funcMap := template.FuncMap{
+		"pendingApprovals": pendingApprovals,
+		"state":            formatter.State,
+		"rejected":         rejected,
+	}
+
+	var data = struct {
+		ApprovalTasks *v1alpha1.ApprovalTaskList
+		AllNamespaces bool
+		NoHeaders     bool
+	}{
+		ApprovalTasks: at,
+		AllNamespaces: allNamespaces,
+		NoHeaders:     noHeaders,
+	}
+
+	w := tabwriter.NewWriter(out, 0, 5, 3, ' ', tabwriter.TabIndent)
+	t := template.Must(template.New("List ApprovalTasks").Funcs(funcMap).Parse(listTemplate))
+	if err := t.Execute(w, data); err != nil {
+		return err
+	}
+
+	return w.Flush()
+}
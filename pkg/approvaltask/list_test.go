@@ -0,0 +1,256 @@
+package approvaltask
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/google/go-cmp/cmp"
+	"github.com/openshift-pipelines/manual-approval-gate/pkg/apis/approvaltask/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func approvalTask(ns, name string, age time.Duration, required int, state string, responses ...v1alpha1.ApproverState) v1alpha1.ApprovalTask {
+	return v1alpha1.ApprovalTask{
+		TypeMeta: metav1.TypeMeta{APIVersion: "openshift-pipelines.org/v1alpha1", Kind: "ApprovalTask"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         ns,
+			CreationTimestamp: metav1.NewTime(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC).Add(-age)),
+		},
+		Spec:   v1alpha1.ApprovalTaskSpec{NumberOfApprovalsRequired: required},
+		Status: v1alpha1.ApprovalTaskStatus{State: state, ApproversResponse: responses},
+	}
+}
+
+// approvalTasks returns a pending ApprovalTask with two pending approvals,
+// an older rejected one and a recent approved one.
+func approvalTasks() []v1alpha1.ApprovalTask {
+	return []v1alpha1.ApprovalTask{
+		approvalTask("ns", "deploy", time.Hour, 2, "pending"),
+		approvalTask("ns", "release", 2*time.Hour, 2, "rejected",
+			v1alpha1.ApproverState{Name: "alice", Response: "rejected"},
+			v1alpha1.ApproverState{Name: "release", Type: approverTypeGroup, GroupMembers: []v1alpha1.GroupMemberState{{Name: "bob", Response: "approved"}}}),
+		approvalTask("ns", "test", time.Minute, 1, "approved", v1alpha1.ApproverState{Name: "alice", Response: "approved"}),
+	}
+}
+
+func TestPrintApprovalTasks(t *testing.T) {
+	color.NoColor = true
+
+	tests := []struct {
+		name          string
+		items         []v1alpha1.ApprovalTask
+		allNamespaces bool
+		noHeaders     bool
+		want          string
+	}{{
+		name:  "namespace",
+		items: approvalTasks(),
+		want: `NAME      NumberOfApprovalsRequired   PendingApprovals   Rejected   STATUS
+deploy    2                           2                  0          Pending
+release   2                           0                  1          Rejected
+test      1                           0                  0          Approved
+`,
+	}, {
+		name:          "all namespaces",
+		items:         approvalTasks()[:1],
+		allNamespaces: true,
+		want: `NAMESPACE   NAME     NumberOfApprovalsRequired   PendingApprovals   Rejected   STATUS
+ns          deploy   2                           2                  0          Pending
+`,
+	}, {
+		name:          "all namespaces without headers",
+		items:         approvalTasks()[:1],
+		allNamespaces: true,
+		noHeaders:     true,
+		want: `ns   deploy   2   2   0   Pending
+`,
+	}, {
+		name: "none",
+		want: "No ApprovalTasks found\n",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			if err := printApprovalTasks(out, &v1alpha1.ApprovalTaskList{Items: tt.items}, tt.allNamespaces, tt.noHeaders); err != nil {
+				t.Fatal(err)
+			}
+			if d := cmp.Diff(tt.want, out.String()); d != "" {
+				t.Errorf("printApprovalTasks() (-want +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestSortApprovalTasks(t *testing.T) {
+	tests := []struct {
+		sortBy string
+		want   []string
+	}{{
+		sortBy: "",
+		want:   []string{"deploy", "release", "test"},
+	}, {
+		sortBy: sortByAge,
+		want:   []string{"test", "deploy", "release"},
+	}, {
+		sortBy: sortByPending,
+		want:   []string{"deploy", "release", "test"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.sortBy, func(t *testing.T) {
+			items := approvalTasks()
+			sortApprovalTasks(items, tt.sortBy)
+			got := []string{}
+			for _, at := range items {
+				got = append(got, at.Name)
+			}
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("sortApprovalTasks() (-want +got):\n%s", d)
+			}
+		})
+	}
+}
+
+// fakeCluster serves the discovery of the ApprovalTasks and lists the
+// ApprovalTasks of the namespace ns, it records the label selectors.
+func fakeCluster(t *testing.T, items []v1alpha1.ApprovalTask) (string, *[]string) {
+	t.Helper()
+	selectors := &[]string{}
+	write := func(w http.ResponseWriter, v any) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(v); err != nil {
+			t.Error(err)
+		}
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api":
+			write(w, &metav1.APIVersions{TypeMeta: metav1.TypeMeta{Kind: "APIVersions"}})
+		case "/apis":
+			version := metav1.GroupVersionForDiscovery{GroupVersion: "openshift-pipelines.org/v1alpha1", Version: "v1alpha1"}
+			write(w, &metav1.APIGroupList{
+				TypeMeta: metav1.TypeMeta{Kind: "APIGroupList", APIVersion: "v1"},
+				Groups:   []metav1.APIGroup{{Name: "openshift-pipelines.org", Versions: []metav1.GroupVersionForDiscovery{version}, PreferredVersion: version}},
+			})
+		case "/apis/openshift-pipelines.org/v1alpha1":
+			write(w, &metav1.APIResourceList{
+				TypeMeta:     metav1.TypeMeta{Kind: "APIResourceList", APIVersion: "v1"},
+				GroupVersion: "openshift-pipelines.org/v1alpha1",
+				APIResources: []metav1.APIResource{{Name: "approvaltasks", Namespaced: true, Kind: "ApprovalTask", Verbs: metav1.Verbs{"get", "list"}}},
+			})
+		case "/apis/openshift-pipelines.org/v1alpha1/namespaces/ns/approvaltasks":
+			*selectors = append(*selectors, r.URL.Query().Get("labelSelector"))
+			write(w, &v1alpha1.ApprovalTaskList{
+				TypeMeta: metav1.TypeMeta{APIVersion: "openshift-pipelines.org/v1alpha1", Kind: "ApprovalTaskList"},
+				Items:    items,
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	path := filepath.Join(t.TempDir(), "kubeconfig")
+	config := `apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: ` + server.URL + `
+contexts:
+- name: test
+  context:
+    cluster: test
+    namespace: ns
+current-context: test
+`
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path, selectors
+}
+
+func TestListCommand(t *testing.T) {
+	color.NoColor = true
+
+	tests := []struct {
+		name      string
+		args      []string
+		selectors []string
+		want      string
+		wantErr   string
+	}{{
+		name:      "label and sort by pending approvals without headers",
+		args:      []string{"--label", "tekton.dev/pipelineRun=foo", "--sort-by", "pending", "--no-headers"},
+		selectors: []string{"tekton.dev/pipelineRun=foo"},
+		want: `deploy    2   2   0   Pending
+release   2   0   1   Rejected
+test      1   0   0   Approved
+`,
+	}, {
+		name:      "sort by age",
+		args:      []string{"--sort-by", "age"},
+		selectors: []string{""},
+		want: `NAME      NumberOfApprovalsRequired   PendingApprovals   Rejected   STATUS
+test      1                           0                  0          Approved
+deploy    2                           2                  0          Pending
+release   2                           0                  1          Rejected
+`,
+	}, {
+		name:      "names",
+		args:      []string{"-o", "name", "--sort-by", "age"},
+		selectors: []string{""},
+		want: `approvaltask.openshift-pipelines.org/test
+approvaltask.openshift-pipelines.org/deploy
+approvaltask.openshift-pipelines.org/release
+`,
+	}, {
+		name:      "jsonpath",
+		args:      []string{"-o", "jsonpath={range .items[*]}{.metadata.name}={.status.state}{\"\\n\"}{end}"},
+		selectors: []string{""},
+		want: `deploy=pending
+release=rejected
+test=approved
+`,
+	}, {
+		name:    "invalid sort key",
+		args:    []string{"--sort-by", "name"},
+		wantErr: `invalid sort key "name", must be one of age or pending`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kubeconfig, selectors := fakeCluster(t, approvalTasks())
+			p := NewParams()
+			p.SetKubeConfigPath(kubeconfig)
+
+			c := ListCommand(p)
+			out := &bytes.Buffer{}
+			c.SetOut(out)
+			c.SetErr(out)
+			c.SetArgs(tt.args)
+			err := c.Execute()
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("list error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d := cmp.Diff(tt.want, out.String()); d != "" {
+				t.Errorf("list (-want +got):\n%s", d)
+			}
+			if d := cmp.Diff(tt.selectors, *selectors); d != "" {
+				t.Errorf("label selectors (-want +got):\n%s", d)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package approvaltask
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/openshift-pipelines/manual-approval-gate/pkg/actions"
+	"github.com/openshift-pipelines/manual-approval-gate/pkg/apis/approvaltask/v1alpha1"
+	"github.com/openshift-pipelines/manual-approval-gate/pkg/cli"
+	"github.com/openshift-pipelines/manual-approval-gate/pkg/cli/flags"
+	"github.com/spf13/cobra"
+)
+
+const (
+	inputPending = "pending"
+
+	// approverHistoryAnnotation records the revocations and the delegations
+	// of the approvers on the ApprovalTask. The approvers have a single
+	// message which is overwritten by their next response, the annotation
+	// keeps the messages of the revoked responses and of the delegations.
+	approverHistoryAnnotation = "openshift-pipelines.org/approver-history"
+
+	changeRevoked   = "revoked"
+	changeDelegated = "delegated"
+)
+
+// ApproverChange is a revocation or a delegation by an approver.
+type ApproverChange struct {
+	Action   string `json:"action"`
+	Approver string `json:"approver"`
+	Group    string `json:"group,omitempty"`
+	// Response is the revoked response.
+	Response string `json:"response,omitempty"`
+	// To is the user the approval is delegated to.
+	To      string    `json:"to,omitempty"`
+	Message string    `json:"message,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+func RevokeCommand(p cli.Params) *cobra.Command {
+	opts := &cli.Options{}
+	eg := `Revoke your approval or rejection of the ApprovalTask foo:
+    opc approvaltask revoke foo -m "need to check the changelog first"
+`
+	c := &cobra.Command{
+		Use:     "revoke",
+		Short:   "Revoke your response to the approvaltask",
+		Long:    `This command resets your response to the approvaltask back to pending, as long as the approvaltask is still waiting for approvals. The message of your response is kept, the revocation is recorded in the openshift-pipelines.org/approver-history annotation.`,
+		Example: eg,
+		Annotations: map[string]string{
+			"commandType": "main",
+		},
+		Args:              cobra.ExactArgs(1),
+		PersistentPreRunE: flags.PersistentPreRunE(p),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cs, err := p.Clients()
+			if err != nil {
+				return err
+			}
+
+			ns := p.Namespace()
+			username, groups, err := p.GetUserInfo()
+			if err != nil {
+				return err
+			}
+
+			at, err := actions.Get(taskGroupResource, cs, &cli.Options{Namespace: ns, Name: args[0]})
+			if err != nil {
+				return fmt.Errorf("failed to get ApprovalTask %s from namespace %s: %v", args[0], ns, err)
+			}
+			if at.Status.State != inputPending {
+				return fmt.Errorf("ApprovalTask %s is already %s, responses cannot be revoked anymore", args[0], at.Status.State)
+			}
+
+			revoked, err := revoke(at, username, groups, opts.Message, time.Now())
+			if err != nil {
+				return err
+			}
+			if !revoked {
+				return fmt.Errorf("approver: %s, has not responded to ApprovalTask %s", username, args[0])
+			}
+
+			if err := updateApprovalTask(cmd.Context(), cs, at); err != nil {
+				return fmt.Errorf("failed to revoke response on ApprovalTask %s from namespace %s: %v", args[0], ns, err)
+			}
+
+			_, err = fmt.Fprintf(cmd.OutOrStdout(), "Response of %s on ApprovalTask %s is revoked in %s namespace\n", username, args[0], ns)
+			return err
+		},
+	}
+
+	c.Flags().StringVarP(&opts.Message, "message", "m", "", "message explaining why the response is revoked")
+
+	flags.AddOptions(c)
+
+	return c
+}
+
+// revoke resets the response of the user to pending, on the user approver and
+// on the groups they responded for, and records the revoked responses in the
+// approver history annotation. It returns false if the user has not responded.
+func revoke(at *v1alpha1.ApprovalTask, username string, groups []string, message string, now time.Time) (bool, error) {
+	revocations := []ApproverChange{}
+	for i, approver := range at.Spec.Approvers {
+		switch v1alpha1.DefaultedApproverType(approver.Type) {
+		case approverTypeUser:
+			if approver.Name != username || approver.Input == inputPending {
+				continue
+			}
+			revocations = append(revocations, ApproverChange{Action: changeRevoked, Approver: username, Response: response(approver.Input), Message: message, Time: now})
+			at.Spec.Approvers[i].Input = inputPending
+		case approverTypeGroup:
+			if !slices.Contains(groups, approver.Name) {
+				continue
+			}
+			responded := false
+			for j, user := range approver.Users {
+				if user.Name == username && user.Input != inputPending {
+					revocations = append(revocations, ApproverChange{Action: changeRevoked, Approver: username, Group: approver.Name, Response: response(user.Input), Message: message, Time: now})
+					at.Spec.Approvers[i].Users[j].Input = inputPending
+				}
+				if at.Spec.Approvers[i].Users[j].Input != inputPending {
+					responded = true
+				}
+			}
+			// the group input reflects the last response of its members, reset
+			// it when nobody else in the group has responded
+			if !responded {
+				at.Spec.Approvers[i].Input = inputPending
+			}
+		}
+	}
+	if len(revocations) == 0 {
+		return false, nil
+	}
+
+	history, err := appendApproverHistory(at, revocations...)
+	if err != nil {
+		return false, err
+	}
+	if at.Annotations == nil {
+		at.Annotations = map[string]string{}
+	}
+	at.Annotations[approverHistoryAnnotation] = history
+	return true, nil
+}
+
+// approverHistory returns the changes recorded in the approver history
+// annotation of the ApprovalTask.
+func approverHistory(at *v1alpha1.ApprovalTask) ([]ApproverChange, error) {
+	changes := []ApproverChange{}
+	v, ok := at.Annotations[approverHistoryAnnotation]
+	if !ok {
+		return changes, nil
+	}
+	if err := json.Unmarshal([]byte(v), &changes); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation on ApprovalTask %s: %v", approverHistoryAnnotation, at.Name, err)
+	}
+	return changes, nil
+}
+
+// appendApproverHistory returns the value of the approver history annotation
+// with the changes appended.
+func appendApproverHistory(at *v1alpha1.ApprovalTask, changes ...ApproverChange) (string, error) {
+	history, err := approverHistory(at)
+	if err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(append(history, changes...))
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
@@ -0,0 +1,85 @@
+package approvaltask
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openshift-pipelines/manual-approval-gate/pkg/apis/approvaltask/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRevoke(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	at := &v1alpha1.ApprovalTask{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "gate",
+			Annotations: map[string]string{approverHistoryAnnotation: `[{"action":"revoked","approver":"bob","response":"rejected","time":"2024-04-30T10:00:00Z"}]`},
+		},
+		Spec: v1alpha1.ApprovalTaskSpec{Approvers: []v1alpha1.ApproverDetails{
+			{Name: "alice", Input: "approve", Message: "lgtm"},
+			{Name: "release", Type: approverTypeGroup, Input: "approve", Users: []v1alpha1.UserDetails{
+				{Name: "alice", Input: "approve", Message: "lgtm"},
+			}},
+			{Name: "qa", Type: approverTypeGroup, Input: "approve", Users: []v1alpha1.UserDetails{
+				{Name: "alice", Input: "approve"},
+				{Name: "carol", Input: "approve"},
+			}},
+		}},
+	}
+
+	revoked, err := revoke(at, "alice", []string{"release", "qa"}, "wrong build", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !revoked {
+		t.Fatal("revoke() = false, want true")
+	}
+
+	want := []v1alpha1.ApproverDetails{
+		{Name: "alice", Input: inputPending, Message: "lgtm"},
+		{Name: "release", Type: approverTypeGroup, Input: inputPending, Users: []v1alpha1.UserDetails{
+			{Name: "alice", Input: inputPending, Message: "lgtm"},
+		}},
+		{Name: "qa", Type: approverTypeGroup, Input: "approve", Users: []v1alpha1.UserDetails{
+			{Name: "alice", Input: inputPending},
+			{Name: "carol", Input: "approve"},
+		}},
+	}
+	if d := cmp.Diff(want, at.Spec.Approvers); d != "" {
+		t.Errorf("approvers mismatch (-want +got):\n%s", d)
+	}
+
+	changes := []ApproverChange{}
+	if err := json.Unmarshal([]byte(at.Annotations[approverHistoryAnnotation]), &changes); err != nil {
+		t.Fatal(err)
+	}
+	wantChanges := []ApproverChange{
+		{Action: changeRevoked, Approver: "bob", Response: "rejected", Time: now.Add(-24 * time.Hour)},
+		{Action: changeRevoked, Approver: "alice", Response: "approved", Message: "wrong build", Time: now},
+		{Action: changeRevoked, Approver: "alice", Group: "release", Response: "approved", Message: "wrong build", Time: now},
+		{Action: changeRevoked, Approver: "alice", Group: "qa", Response: "approved", Message: "wrong build", Time: now},
+	}
+	if d := cmp.Diff(wantChanges, changes); d != "" {
+		t.Errorf("approver history mismatch (-want +got):\n%s", d)
+	}
+}
+
+func TestRevokeNotResponded(t *testing.T) {
+	at := &v1alpha1.ApprovalTask{
+		Spec: v1alpha1.ApprovalTaskSpec{Approvers: []v1alpha1.ApproverDetails{
+			{Name: "alice", Input: inputPending},
+		}},
+	}
+	revoked, err := revoke(at, "alice", nil, "", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if revoked {
+		t.Error("revoke() = true, want false")
+	}
+	if _, ok := at.Annotations[approverHistoryAnnotation]; ok {
+		t.Error("approver history annotation added without any revocation")
+	}
+}
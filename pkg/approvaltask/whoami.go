@@ -0,0 +1,58 @@
+package approvaltask
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/openshift-pipelines/manual-approval-gate/pkg/cli/flags"
+	"github.com/spf13/cobra"
+)
+
+func WhoamiCommand(p *Params) *cobra.Command {
+	var output string
+	eg := `Show the user and groups used to match the approvers:
+    opc approvaltask whoami
+
+Show the identity used when impersonating another user:
+    opc approvaltask whoami --as alice --as-group release
+`
+	c := &cobra.Command{
+		Use:     "whoami",
+		Short:   "Show the user and groups used to match the approvers",
+		Long:    `This command shows the username and groups which are matched against the approvers of the approval tasks, and how they have been determined.`,
+		Example: eg,
+		Annotations: map[string]string{
+			"commandType": "main",
+		},
+		Args:              cobra.NoArgs,
+		PersistentPreRunE: flags.PersistentPreRunE(p),
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if output != "" && output != "json" {
+				return fmt.Errorf("invalid output format %q, must be json", output)
+			}
+
+			id, err := p.Identity(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			if output == "json" {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(id)
+			}
+			groups := strings.Join(id.Groups, ", ")
+			if groups == "" {
+				groups = "---"
+			}
+			_, err = fmt.Fprintf(cmd.OutOrStdout(), "Username: %s\nGroups: %s\nResolved by: %s\n", id.Username, groups, id.Resolver)
+			return err
+		},
+	}
+	flags.AddOptions(c)
+
+	c.Flags().StringVarP(&output, "output", "o", "", "output format, json is the only supported one")
+
+	return c
+}
@@ -0,0 +1,103 @@
+// Package bundle extends the bundle commands of the Tekton CLI with commands
+// reading the Tekton objects of the bundles.
+package bundle
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/tektoncd/cli/pkg/bundle"
+	tkremote "github.com/tektoncd/pipeline/pkg/remote/oci"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/yaml"
+)
+
+// bundleOptions are the options to read a bundle from its registry, with the
+// same flags as the list command.
+type bundleOptions struct {
+	remoteOptions bundle.RemoteOptions
+	cacheOptions  bundle.CacheOptions
+}
+
+// object is a Tekton object of a bundle with the layer it is stored in.
+type object struct {
+	Layer      string `json:"layer"`
+	Size       int64  `json:"size"`
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	YAML       []byte `json:"-"`
+}
+
+// key identifies the object in the bundle, the kind is lowercase as in the
+// annotations of the layers.
+func (o *object) key() string {
+	return fmt.Sprintf("%s/%s", o.Kind, o.Name)
+}
+
+// fileName returns the name of the file the object is written to, the kind
+// and the name come from the annotations of the layer so they are checked to
+// be DNS-1123 labels as for the objects of a cluster.
+func (o *object) fileName() (string, error) {
+	for _, field := range []struct{ name, value string }{{"kind", o.Kind}, {"name", o.Name}} {
+		if errs := validation.IsDNS1123Label(field.value); len(errs) > 0 {
+			return "", fmt.Errorf("invalid %s %q of layer %s: %s", field.name, field.value, o.Layer, strings.Join(errs, ", "))
+		}
+	}
+	return fmt.Sprintf("%s-%s.yaml", o.Kind, o.Name), nil
+}
+
+func parseReference(ref string) (name.Reference, error) {
+	r, err := name.ParseReference(ref, name.StrictValidation, name.Insecure)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bundle reference %q: %v", ref, err)
+	}
+	return r, nil
+}
+
+func (opts *bundleOptions) read(ref string) (ggcrv1.Image, error) {
+	r, err := parseReference(ref)
+	if err != nil {
+		return nil, err
+	}
+	return bundle.Read(r, &opts.cacheOptions, opts.remoteOptions.ToOptions()...)
+}
+
+// objects returns the Tekton objects of the bundle in the order of its
+// layers, converted to YAML.
+func objects(img ggcrv1.Image) ([]*object, error) {
+	manifest, err := img.Manifest()
+	if err != nil {
+		return nil, err
+	}
+	objs := []*object{}
+	var convErr error
+	err = bundle.List(img, func(_, _, _ string, _ runtime.Object, raw []byte) {
+		// the objects are listed in the order of the layers of the manifest
+		o := layerObject(manifest.Layers[len(objs)])
+		y, err := yaml.JSONToYAML(raw)
+		if err != nil && convErr == nil {
+			convErr = fmt.Errorf("failed to convert %s to YAML: %v", o.key(), err)
+		}
+		o.YAML = y
+		objs = append(objs, o)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return objs, convErr
+}
+
+// layerObject returns the object of the layer from its annotations.
+func layerObject(l ggcrv1.Descriptor) *object {
+	return &object{
+		Layer:      l.Digest.String(),
+		Size:       l.Size,
+		APIVersion: l.Annotations[tkremote.APIVersionAnnotation],
+		Kind:       strings.ToLower(l.Annotations[tkremote.KindAnnotation]),
+		Name:       l.Annotations[tkremote.TitleAnnotation],
+	}
+}
@@ -0,0 +1,97 @@
+package bundle
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tektoncd/cli/pkg/bundle"
+)
+
+const bundleTask = `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: build
+spec:
+  steps:
+    - name: build
+      image: ubi9
+`
+
+const bundlePipeline = `apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: release
+spec:
+  tasks:
+    - name: build
+      taskRef:
+        name: build
+`
+
+func TestObjects(t *testing.T) {
+	img, err := bundle.BuildTektonBundle([]string{bundleTask, bundlePipeline}, nil, nil, time.Now(), &bytes.Buffer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	objs, err := objects(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objs) != 2 || !bytes.Contains(objs[1].YAML, []byte("image: ubi9")) {
+		t.Fatalf("unexpected objects: %v", objs)
+	}
+	for _, o := range objs {
+		if o.Layer == "" || o.Size == 0 {
+			t.Errorf("layer of %s missing", o.key())
+		}
+		o.Layer, o.Size, o.YAML = "", 0, nil
+	}
+	want := []*object{
+		{APIVersion: "v1", Kind: "pipeline", Name: "release"},
+		{APIVersion: "v1", Kind: "task", Name: "build"},
+	}
+	if d := cmp.Diff(want, objs); d != "" {
+		t.Errorf("objects() mismatch (-want +got):\n%s", d)
+	}
+}
+
+func TestFileName(t *testing.T) {
+	tests := []struct {
+		name    string
+		obj     *object
+		want    string
+		wantErr string
+	}{{
+		name: "valid",
+		obj:  &object{Kind: "task", Name: "build"},
+		want: "task-build.yaml",
+	}, {
+		name:    "parent directory",
+		obj:     &object{Layer: "sha256:abc", Kind: "task", Name: "../../.bashrc"},
+		wantErr: `invalid name "../../.bashrc" of layer sha256:abc: a lowercase RFC 1123 label must consist of lower case alphanumeric characters or '-', and must start and end with an alphanumeric character (e.g. 'my-name',  or '123-abc', regex used for validation is '[a-z0-9]([-a-z0-9]*[a-z0-9])?')`,
+	}, {
+		name:    "separator in kind",
+		obj:     &object{Layer: "sha256:abc", Kind: "task/x", Name: "build"},
+		wantErr: `invalid kind "task/x" of layer sha256:abc: a lowercase RFC 1123 label must consist of lower case alphanumeric characters or '-', and must start and end with an alphanumeric character (e.g. 'my-name',  or '123-abc', regex used for validation is '[a-z0-9]([-a-z0-9]*[a-z0-9])?')`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.obj.fileName()
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("fileName() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("fileName() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package bundle
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/cobra"
+	"github.com/tektoncd/cli/pkg/bundle"
+	"github.com/tektoncd/cli/pkg/cli"
+)
+
+type diffOptions struct {
+	bundleOptions
+	NameOnly bool
+}
+
+// DiffCommand returns the command comparing the Tekton objects of two
+// bundles.
+func DiffCommand(_ cli.Params) *cobra.Command {
+	opts := &diffOptions{}
+	eg := `Show the changes of the Tekton objects between two versions of a bundle:
+
+    opc bundle diff docker.io/myorg/mybundle:1.0 docker.io/myorg/mybundle:1.1
+
+Only list the objects which were added, removed or modified:
+
+    opc bundle diff docker.io/myorg/mybundle:1.0 docker.io/myorg/mybundle:1.1 --name-only
+`
+
+	c := &cobra.Command{
+		Use:   "diff",
+		Short: "Show the changes of the Tekton objects between two bundles",
+		Long: `Show the changes of the Tekton objects between two bundles as unified diffs of their YAML, the objects
+are matched by kind and name.
+
+Authentication and caching are the same as for the list command.`,
+		Example: eg,
+		Args:    cobra.ExactArgs(2),
+		Annotations: map[string]string{
+			"commandType": "main",
+			"kubernetes":  "false",
+		},
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			objsA, err := opts.objects(args[0])
+			if err != nil {
+				return err
+			}
+			objsB, err := opts.objects(args[1])
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			changed := 0
+			for _, key := range objectKeys(objsA, objsB) {
+				a, b := objsA[key], objsB[key]
+				var yamlA, yamlB string
+				if a != nil {
+					yamlA = string(a.YAML)
+				}
+				if b != nil {
+					yamlB = string(b.YAML)
+				}
+				if a != nil && b != nil && yamlA == yamlB {
+					continue
+				}
+
+				changed++
+				if opts.NameOnly {
+					change := "modified"
+					switch {
+					case a == nil:
+						change = "added"
+					case b == nil:
+						change = "removed"
+					}
+					fmt.Fprintf(out, "%s\t%s\n", change, key)
+					continue
+				}
+				diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+					A:        lines(yamlA),
+					B:        lines(yamlB),
+					FromFile: fmt.Sprintf("%s %s", args[0], key),
+					ToFile:   fmt.Sprintf("%s %s", args[1], key),
+					Context:  3,
+				})
+				if err != nil {
+					return err
+				}
+				fmt.Fprint(out, diff)
+			}
+			if changed == 0 {
+				fmt.Fprintln(out, "No differences between the Tekton objects of the bundles")
+			}
+			return nil
+		},
+	}
+	c.Flags().BoolVar(&opts.NameOnly, "name-only", false, "only list the objects added, removed or modified")
+	bundle.AddRemoteFlags(c.Flags(), &opts.remoteOptions)
+	bundle.AddCacheFlags(c.Flags(), &opts.cacheOptions)
+
+	return c
+}
+
+// objects returns the Tekton objects of the bundle by kind and name.
+func (opts *diffOptions) objects(ref string) (map[string]*object, error) {
+	img, err := opts.read(ref)
+	if err != nil {
+		return nil, err
+	}
+	objs, err := objects(img)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle %s: %v", ref, err)
+	}
+	m := map[string]*object{}
+	for _, o := range objs {
+		m[o.key()] = o
+	}
+	return m, nil
+}
+
+// lines splits the YAML in lines keeping their end, an empty YAML has no
+// lines.
+func lines(s string) []string {
+	l := strings.SplitAfter(s, "\n")
+	if l[len(l)-1] == "" {
+		l = l[:len(l)-1]
+	}
+	return l
+}
+
+func objectKeys(a, b map[string]*object) []string {
+	keys := []string{}
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
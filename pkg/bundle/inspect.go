@@ -0,0 +1,141 @@
+package bundle
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/spf13/cobra"
+	"github.com/tektoncd/cli/pkg/bundle"
+	"github.com/tektoncd/cli/pkg/cli"
+	"github.com/tektoncd/cli/pkg/formatted"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type inspectOptions struct {
+	bundleOptions
+	Output string
+}
+
+// Inspection is the description of a bundle image.
+type Inspection struct {
+	Reference   string            `json:"reference"`
+	Digest      string            `json:"digest"`
+	MediaType   string            `json:"mediaType"`
+	Created     time.Time         `json:"created"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Objects     []*object         `json:"objects"`
+}
+
+// InspectCommand returns the command describing the image of a bundle.
+func InspectCommand(_ cli.Params) *cobra.Command {
+	opts := &inspectOptions{}
+	eg := `Describe the image of a bundle:
+
+    opc bundle inspect docker.io/myorg/mybundle:1.0
+`
+
+	c := &cobra.Command{
+		Use:   "inspect",
+		Short: "Describe the image of a bundle",
+		Long: `Describe the image of a bundle: its digest, creation time, the annotations of its manifest, the labels of its
+configuration and the Tekton object stored in each layer.
+
+Authentication and caching are the same as for the list command.`,
+		Example: eg,
+		Args:    cobra.ExactArgs(1),
+		Annotations: map[string]string{
+			"commandType": "main",
+			"kubernetes":  "false",
+		},
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.Output != "" && opts.Output != "json" {
+				return fmt.Errorf("invalid output format %q, only json is supported", opts.Output)
+			}
+			img, err := opts.read(args[0])
+			if err != nil {
+				return err
+			}
+			digest, err := img.Digest()
+			if err != nil {
+				return err
+			}
+			manifest, err := img.Manifest()
+			if err != nil {
+				return err
+			}
+			config, err := img.ConfigFile()
+			if err != nil {
+				return err
+			}
+
+			i := &Inspection{
+				Reference:   args[0],
+				Digest:      digest.String(),
+				MediaType:   string(manifest.MediaType),
+				Created:     config.Created.UTC(),
+				Annotations: manifest.Annotations,
+				Labels:      config.Config.Labels,
+				Objects:     []*object{},
+			}
+			for _, l := range manifest.Layers {
+				i.Objects = append(i.Objects, layerObject(l))
+			}
+
+			if opts.Output == "json" {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(i)
+			}
+			return printInspection(cmd.OutOrStdout(), i)
+		},
+	}
+	c.Flags().StringVarP(&opts.Output, "output", "o", "", "output format, json or empty for text")
+	bundle.AddRemoteFlags(c.Flags(), &opts.remoteOptions)
+	bundle.AddCacheFlags(c.Flags(), &opts.cacheOptions)
+
+	return c
+}
+
+func printInspection(out io.Writer, i *Inspection) error {
+	w := tabwriter.NewWriter(out, 0, 5, 3, ' ', tabwriter.TabIndent)
+	created := metav1.NewTime(i.Created)
+	fmt.Fprintf(w, "Reference:\t%s\n", i.Reference)
+	fmt.Fprintf(w, "Digest:\t%s\n", i.Digest)
+	fmt.Fprintf(w, "Media Type:\t%s\n", i.MediaType)
+	fmt.Fprintf(w, "Created:\t%s (%s)\n", i.Created.Format(time.RFC3339), formatted.Age(&created, clockwork.NewRealClock()))
+
+	printMap(w, "Annotations", i.Annotations)
+	printMap(w, "Labels", i.Labels)
+
+	fmt.Fprintf(w, "\nObjects\n")
+	fmt.Fprintf(w, " LAYER\tKIND\tNAME\tVERSION\tSIZE\n")
+	for _, o := range i.Objects {
+		fmt.Fprintf(w, " %s\t%s\t%s\t%s\t%d\n", o.Layer, o.Kind, o.Name, o.APIVersion, o.Size)
+	}
+	return w.Flush()
+}
+
+func printMap(w io.Writer, title string, m map[string]string) {
+	fmt.Fprintf(w, "\n%s\n", title)
+	if len(m) == 0 {
+		fmt.Fprintf(w, " No %s\n", strings.ToLower(title))
+		return
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	fmt.Fprintf(w, " NAME\tVALUE\n")
+	for _, k := range keys {
+		fmt.Fprintf(w, " %s\t%s\n", k, m[k])
+	}
+}
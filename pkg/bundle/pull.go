@@ -0,0 +1,92 @@
+package bundle
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/tektoncd/cli/pkg/bundle"
+	"github.com/tektoncd/cli/pkg/cli"
+)
+
+type pullOptions struct {
+	bundleOptions
+	Dir string
+}
+
+// PullCommand returns the command writing the Tekton objects of a bundle to
+// YAML files.
+func PullCommand(_ cli.Params) *cobra.Command {
+	opts := &pullOptions{Dir: "."}
+	eg := `Write the Tekton objects of a bundle to the directory tekton:
+
+    opc bundle pull docker.io/myorg/mybundle:1.0 --dir tekton
+`
+
+	c := &cobra.Command{
+		Use:   "pull",
+		Short: "Write the Tekton objects of a bundle to YAML files",
+		Long: `Write each Tekton object of a bundle to a YAML file named after its kind and name, like task-build.yaml,
+in the given directory. The directory is created if it does not exist, the existing files are replaced.
+
+Authentication and caching are the same as for the list command.`,
+		Example: eg,
+		Args:    cobra.ExactArgs(1),
+		Annotations: map[string]string{
+			"commandType": "main",
+			"kubernetes":  "false",
+		},
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			img, err := opts.read(args[0])
+			if err != nil {
+				return err
+			}
+			objs, err := objects(img)
+			if err != nil {
+				return err
+			}
+
+			return writeFiles(cmd.OutOrStdout(), opts.Dir, objs)
+		},
+	}
+	c.Flags().StringVar(&opts.Dir, "dir", opts.Dir, "directory to write the YAML files to")
+	bundle.AddRemoteFlags(c.Flags(), &opts.remoteOptions)
+	bundle.AddCacheFlags(c.Flags(), &opts.cacheOptions)
+
+	return c
+}
+
+// writeFiles writes the objects to their files in the directory, nothing is
+// written when the file of an object is not valid.
+func writeFiles(out io.Writer, dir string, objs []*object) error {
+	paths := make([]string, len(objs))
+	seen := map[string]bool{}
+	for i, o := range objs {
+		if seen[o.key()] {
+			return fmt.Errorf("the bundle contains several %s named %s", o.Kind, o.Name)
+		}
+		seen[o.key()] = true
+		name, err := o.fileName()
+		if err != nil {
+			return err
+		}
+		paths[i] = filepath.Join(dir, name)
+		if rel, err := filepath.Rel(filepath.Clean(dir), filepath.Clean(paths[i])); err != nil || rel != name {
+			return fmt.Errorf("the file of %s %s is not in directory %s", o.Kind, o.Name, dir)
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for i, o := range objs {
+		if err := os.WriteFile(paths[i], o.YAML, 0o644); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Wrote %s %s to %s\n", o.Kind, o.Name, paths[i])
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package bundle
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWriteFiles(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "tekton")
+	objs := []*object{
+		{Kind: "task", Name: "build", YAML: []byte("kind: Task\n")},
+		{Kind: "pipeline", Name: "release", YAML: []byte("kind: Pipeline\n")},
+	}
+	out := &bytes.Buffer{}
+	if err := writeFiles(out, dir, objs); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "Wrote task build to " + filepath.Join(dir, "task-build.yaml") + "\n" +
+		"Wrote pipeline release to " + filepath.Join(dir, "pipeline-release.yaml") + "\n"
+	if d := cmp.Diff(want, out.String()); d != "" {
+		t.Errorf("output mismatch (-want +got):\n%s", d)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "pipeline-release.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "kind: Pipeline\n" {
+		t.Errorf("pipeline-release.yaml = %q", b)
+	}
+}
+
+func TestWriteFilesInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		objs    []*object
+		wantErr string
+	}{{
+		name: "path traversal",
+		objs: []*object{
+			{Kind: "task", Name: "build"},
+			{Layer: "sha256:abc", Kind: "task", Name: "../escape"},
+		},
+		wantErr: `invalid name "../escape" of layer sha256:abc`,
+	}, {
+		name: "absolute path",
+		objs: []*object{
+			{Layer: "sha256:abc", Kind: "task", Name: "/etc/passwd"},
+		},
+		wantErr: `invalid name "/etc/passwd" of layer sha256:abc`,
+	}, {
+		name: "duplicate",
+		objs: []*object{
+			{Kind: "task", Name: "build"},
+			{Kind: "task", Name: "build"},
+		},
+		wantErr: "the bundle contains several task named build",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "tekton")
+			err := writeFiles(&bytes.Buffer{}, dir, tt.objs)
+			if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+				t.Fatalf("writeFiles() error = %v, want %s", err, tt.wantErr)
+			}
+			// nothing is written when an object is invalid
+			if _, err := os.Stat(dir); !os.IsNotExist(err) {
+				t.Errorf("directory %s created: %v", dir, err)
+			}
+		})
+	}
+}
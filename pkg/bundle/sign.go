@@ -0,0 +1,189 @@
+package bundle
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/openshift-pipelines/opc/pkg/signing"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/sigstore/sigstore/pkg/signature/payload"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/tektoncd/cli/pkg/bundle"
+)
+
+// The media type and the annotation of the layers of the signatures, the
+// same as cosign.
+const (
+	simpleSigningMediaType types.MediaType = "application/vnd.dev.cosign.simplesigning.v1+json"
+	signatureAnnotation                    = "dev.cosignproject.cosign/signature"
+	signatureTagSuffix                     = ".sig"
+)
+
+type signOptions struct {
+	SignKey string
+	KMSKey  string
+}
+
+// AddSigning adds the flags signing the bundle to the push command, the
+// bundle is signed once it is pushed.
+func AddSigning(push *cobra.Command) {
+	opts := &signOptions{}
+	push.Long += `
+Signing:
+	The bundle is signed once pushed when a key is given with --sign-key or --kms-key. The signature is stored in the
+	registry the same way as cosign does, next to the bundle with the tag sha256-<digest>.sig, and can be checked with
+	"opc bundle verify" or "cosign verify --insecure-ignore-tlog". The password of an encrypted key is read from the
+	COSIGN_PASSWORD environment variable or from the terminal.
+`
+	push.Flags().StringVar(&opts.SignKey, "sign-key", "", "path of the private key to sign the bundle with, in PEM format as generated by cosign")
+	push.Flags().StringVar(&opts.KMSKey, "kms-key", "", "KMS key to sign the bundle with, like gcpkms://projects/p/locations/l/keyRings/r/cryptoKeys/k")
+
+	preRunE := push.PreRunE
+	push.PreRunE = func(cmd *cobra.Command, args []string) error {
+		if opts.SignKey != "" && opts.KMSKey != "" {
+			return errors.New("only one of --sign-key and --kms-key can be given")
+		}
+		if preRunE != nil {
+			return preRunE(cmd, args)
+		}
+		return nil
+	}
+	runE := push.RunE
+	push.RunE = func(cmd *cobra.Command, args []string) error {
+		if opts.SignKey == "" && opts.KMSKey == "" {
+			return runE(cmd, args)
+		}
+		// the signer is loaded first not to push a bundle which cannot be
+		// signed
+		signer, err := signing.LoadSigner(cmd.Context(), opts.SignKey, opts.KMSKey, signing.KeyPassword(cmd.InOrStdin(), cmd.ErrOrStderr()))
+		if err != nil {
+			return err
+		}
+
+		if err := runE(cmd, args); err != nil {
+			return err
+		}
+		ref, err := parseReference(args[0])
+		if err != nil {
+			return err
+		}
+		remoteOpts, err := pushRemoteOptions(cmd)
+		if err != nil {
+			return err
+		}
+		// the digest the push command wrote the bundle to
+		desc, err := remote.Head(ref, remoteOpts...)
+		if err != nil {
+			return fmt.Errorf("failed to fetch the pushed bundle %s: %v", args[0], err)
+		}
+		sigRef, err := sign(ref.Context().Digest(desc.Digest.String()), signer, remoteOpts...)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Pushed signature to %s\n", sigRef)
+		return nil
+	}
+}
+
+// sign signs the digest of the bundle, the signature is added to the ones
+// already pushed for the digest.
+func sign(digest name.Digest, signer signature.Signer, opts ...remote.Option) (name.Tag, error) {
+	p, err := payload.Cosign{Image: digest}.MarshalJSON()
+	if err != nil {
+		return name.Tag{}, err
+	}
+	sig, err := signer.SignMessage(bytes.NewReader(p))
+	if err != nil {
+		return name.Tag{}, fmt.Errorf("failed to sign bundle: %v", err)
+	}
+
+	sigRef := signatureTag(digest)
+	base, err := remote.Image(sigRef, opts...)
+	if err != nil {
+		if !isNotFound(err) {
+			return name.Tag{}, fmt.Errorf("failed to fetch the signatures of %s: %v", digest, err)
+		}
+		base = mutate.ConfigMediaType(mutate.MediaType(empty.Image, types.OCIManifestSchema1), types.OCIConfigJSON)
+	}
+	img, err := mutate.Append(base, mutate.Addendum{
+		Layer:       newPayloadLayer(p),
+		Annotations: map[string]string{signatureAnnotation: base64.StdEncoding.EncodeToString(sig)},
+	})
+	if err != nil {
+		return name.Tag{}, err
+	}
+	if err := remote.Write(sigRef, img, opts...); err != nil {
+		return name.Tag{}, fmt.Errorf("failed to push the signature of %s: %v", digest, err)
+	}
+	return sigRef, nil
+}
+
+// signatureTag returns the tag of the signatures of the digest, like
+// sha256-<hex>.sig in the repository of the bundle.
+func signatureTag(digest name.Digest) name.Tag {
+	return digest.Context().Tag(strings.Replace(digest.DigestStr(), ":", "-", 1) + signatureTagSuffix)
+}
+
+// pushRemoteOptions returns the options to access the registry from the
+// remote flags of the push command, read into the remote options of tkn as
+// the push command does.
+func pushRemoteOptions(cmd *cobra.Command) ([]remote.Option, error) {
+	ro := &bundle.RemoteOptions{}
+	flags := pflag.NewFlagSet("remote", pflag.ContinueOnError)
+	bundle.AddRemoteFlags(flags, ro)
+	var err error
+	flags.VisitAll(func(f *pflag.Flag) {
+		if pushFlag := cmd.Flags().Lookup(f.Name); pushFlag != nil && pushFlag.Changed && err == nil {
+			err = f.Value.Set(pushFlag.Value.String())
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ro.ToOptions(), nil
+}
+
+func isNotFound(err error) bool {
+	var terr *transport.Error
+	return errors.As(err, &terr) && terr.StatusCode == http.StatusNotFound
+}
+
+// payloadLayer is an uncompressed layer holding the payload of a signature.
+type payloadLayer struct {
+	content []byte
+	digest  ggcrv1.Hash
+}
+
+func newPayloadLayer(content []byte) *payloadLayer {
+	digest, _, _ := ggcrv1.SHA256(bytes.NewReader(content))
+	return &payloadLayer{content: content, digest: digest}
+}
+
+func (l *payloadLayer) Digest() (ggcrv1.Hash, error) { return l.digest, nil }
+
+func (l *payloadLayer) DiffID() (ggcrv1.Hash, error) { return l.digest, nil }
+
+func (l *payloadLayer) Compressed() (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(l.content)), nil
+}
+
+func (l *payloadLayer) Uncompressed() (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(l.content)), nil
+}
+
+func (l *payloadLayer) Size() (int64, error) { return int64(len(l.content)), nil }
+
+func (l *payloadLayer) MediaType() (types.MediaType, error) { return simpleSigningMediaType, nil }
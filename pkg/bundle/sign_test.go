@@ -0,0 +1,142 @@
+package bundle
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"io"
+	"log"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/spf13/cobra"
+	"github.com/tektoncd/cli/pkg/bundle"
+)
+
+// newRegistry returns the host of an in-process registry.
+func newRegistry(t *testing.T) string {
+	t.Helper()
+	server := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+	return strings.TrimPrefix(server.URL, "http://")
+}
+
+// newKeyPair writes a key pair encrypted with the password in the directory
+// of the test, the paths of the private and the public keys are returned.
+func newKeyPair(t *testing.T, password string) (string, string) {
+	t.Helper()
+	priv, pub, err := cryptoutils.GeneratePEMEncodedECDSAKeyPair(elliptic.P256(), cryptoutils.StaticPasswordFunc([]byte(password)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	privPath, pubPath := filepath.Join(dir, "cosign.key"), filepath.Join(dir, "cosign.pub")
+	if err := os.WriteFile(privPath, priv, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(pubPath, pub, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return privPath, pubPath
+}
+
+// pushCommand returns a push command writing a bundle of the task to the
+// registry, with the remote flags of the push command of tkn.
+func pushCommand() *cobra.Command {
+	opts := &bundle.RemoteOptions{}
+	c := &cobra.Command{
+		Use:  "push",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			img, err := bundle.BuildTektonBundle([]string{bundleTask}, nil, nil, time.Unix(0, 0), &bytes.Buffer{})
+			if err != nil {
+				return err
+			}
+			ref, err := parseReference(args[0])
+			if err != nil {
+				return err
+			}
+			digest, err := bundle.Write(img, ref, opts.ToOptions()...)
+			if err != nil {
+				return err
+			}
+			cmd.Printf("\nPushed Tekton Bundle to %s\n", digest)
+			return nil
+		},
+	}
+	bundle.AddRemoteFlags(c.Flags(), opts)
+	AddSigning(c)
+	return c
+}
+
+func TestSignAndVerify(t *testing.T) {
+	host := newRegistry(t)
+	t.Setenv("COSIGN_PASSWORD", "secret")
+	key, pub := newKeyPair(t, "secret")
+	_, otherPub := newKeyPair(t, "other")
+	ref := host + "/org/bundle:1.0"
+
+	// signed twice, the signatures are added to the ones already pushed
+	for range 2 {
+		out := &bytes.Buffer{}
+		push := pushCommand()
+		push.SetOut(out)
+		push.SetArgs([]string{ref, "--sign-key", key})
+		if err := push.Execute(); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(out.String(), "Pushed signature to "+host+"/org/bundle:sha256-") {
+			t.Errorf("signature not pushed: %s", out.String())
+		}
+	}
+
+	out := &bytes.Buffer{}
+	verify := VerifyCommand(nil)
+	verify.SetOut(out)
+	verify.SetArgs([]string{ref, "--key", pub})
+	if err := verify.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(out.String(), "(valid signatures: 2)\n") {
+		t.Errorf("unexpected output: %s", out.String())
+	}
+
+	verify = VerifyCommand(nil)
+	verify.SetOut(&bytes.Buffer{})
+	verify.SetErr(&bytes.Buffer{})
+	verify.SetArgs([]string{ref, "--key", otherPub})
+	if err := verify.Execute(); err == nil {
+		t.Error("bundle verified with another key")
+	}
+}
+
+func TestSignTheDigestOfThePushedTag(t *testing.T) {
+	host := newRegistry(t)
+	t.Setenv("COSIGN_PASSWORD", "secret")
+	key, _ := newKeyPair(t, "secret")
+
+	push := pushCommand()
+	push.SetOut(&bytes.Buffer{})
+	push.SetArgs([]string{host + "/org/bundle:1.0", "--sign-key", key})
+	if err := push.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	ref, err := parseReference(host + "/org/bundle:1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc, err := remote.Head(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := remote.Head(signatureTag(ref.Context().Digest(desc.Digest.String()))); err != nil {
+		t.Errorf("no signature for the digest of the tag: %v", err)
+	}
+}
@@ -0,0 +1,159 @@
+package bundle
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/openshift-pipelines/opc/pkg/signing"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/sigstore/sigstore/pkg/signature/payload"
+	"github.com/spf13/cobra"
+	"github.com/tektoncd/cli/pkg/bundle"
+	"github.com/tektoncd/cli/pkg/cli"
+)
+
+type verifyOptions struct {
+	remoteOptions bundle.RemoteOptions
+	Key           string
+	KMSKey        string
+}
+
+// VerifyCommand returns the command checking the signatures of a bundle.
+func VerifyCommand(_ cli.Params) *cobra.Command {
+	opts := &verifyOptions{}
+	eg := `Check that a bundle is signed with the private key of cosign.pub:
+
+    opc bundle verify docker.io/myorg/mybundle:1.0 --key cosign.pub
+`
+
+	c := &cobra.Command{
+		Use:   "verify",
+		Short: "Verify the signatures of a bundle",
+		Long: `Verify the signatures of a bundle stored in the registry the same way as cosign does, next to the bundle
+with the tag sha256-<digest>.sig. The bundle is verified when one of its signatures was made for the digest of the
+bundle with the private key of the given public key or with the given KMS key.
+
+The signatures pushed by "opc bundle push --sign-key" and by "cosign sign --key" are verified, the transparency log
+is not checked.`,
+		Example: eg,
+		Args:    cobra.ExactArgs(1),
+		Annotations: map[string]string{
+			"commandType": "main",
+			"kubernetes":  "false",
+		},
+		SilenceUsage: true,
+		PreRunE: func(_ *cobra.Command, _ []string) error {
+			if (opts.Key == "") == (opts.KMSKey == "") {
+				return errors.New("one of --key and --kms-key must be given")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ref, err := parseReference(args[0])
+			if err != nil {
+				return err
+			}
+			verifier, err := signing.LoadVerifier(cmd.Context(), opts.Key, opts.KMSKey)
+			if err != nil {
+				return err
+			}
+			remoteOpts := opts.remoteOptions.ToOptions()
+			desc, err := remote.Head(ref, remoteOpts...)
+			if err != nil {
+				return fmt.Errorf("failed to fetch bundle %s: %v", args[0], err)
+			}
+			digest := ref.Context().Digest(desc.Digest.String())
+
+			verified, err := verify(digest, verifier, remoteOpts...)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Verified %s (valid signatures: %d)\n", digest, verified)
+			return nil
+		},
+	}
+	c.Flags().StringVar(&opts.Key, "key", "", "path of the public key to verify the signatures with, in PEM format")
+	c.Flags().StringVar(&opts.KMSKey, "kms-key", "", "KMS key to verify the signatures with")
+	bundle.AddRemoteFlags(c.Flags(), &opts.remoteOptions)
+
+	return c
+}
+
+// verify returns the number of the signatures of the digest verified with
+// the verifier, an error is returned when none is.
+func verify(digest name.Digest, verifier signature.Verifier, opts ...remote.Option) (int, error) {
+	img, err := remote.Image(signatureTag(digest), opts...)
+	if err != nil {
+		if isNotFound(err) {
+			return 0, fmt.Errorf("no signatures found for %s", digest)
+		}
+		return 0, fmt.Errorf("failed to fetch the signatures of %s: %v", digest, err)
+	}
+	manifest, err := img.Manifest()
+	if err != nil {
+		return 0, err
+	}
+
+	verified := 0
+	var lastErr error
+	for _, l := range manifest.Layers {
+		if l.MediaType != simpleSigningMediaType {
+			continue
+		}
+		layer, err := img.LayerByDigest(l.Digest)
+		if err != nil {
+			return 0, err
+		}
+		if err := verifySignature(digest, layer.Compressed, l.Annotations[signatureAnnotation], verifier); err != nil {
+			lastErr = err
+			continue
+		}
+		verified++
+	}
+	if verified == 0 {
+		if lastErr == nil {
+			lastErr = errors.New("no signatures found")
+		}
+		return 0, fmt.Errorf("failed to verify the signatures of %s: %v", digest, lastErr)
+	}
+	return verified, nil
+}
+
+// verifySignature checks the signature of the payload and that the payload
+// was signed for the digest.
+func verifySignature(digest name.Digest, content func() (io.ReadCloser, error), encoded string, verifier signature.Verifier) error {
+	sig, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %v", err)
+	}
+	rc, err := content()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	p, err := io.ReadAll(rc)
+	if err != nil {
+		return err
+	}
+	if err := verifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader(p)); err != nil {
+		return err
+	}
+
+	var simple payload.SimpleContainerImage
+	if err := json.Unmarshal(p, &simple); err != nil {
+		return fmt.Errorf("invalid signature payload: %v", err)
+	}
+	if simple.Critical.Type != payload.CosignSignatureType {
+		return fmt.Errorf("unknown signature type %q", simple.Critical.Type)
+	}
+	if simple.Critical.Image.DockerManifestDigest != digest.DigestStr() {
+		return fmt.Errorf("the signature was made for %s", simple.Critical.Image.DockerManifestDigest)
+	}
+	return nil
+}
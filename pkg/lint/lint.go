@@ -0,0 +1,170 @@
+// Package lint adds the command checking Tekton resources of local files
+// without a cluster.
+package lint
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// The severities of the findings.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// The output formats of the findings.
+const (
+	FormatText  = "text"
+	FormatJSON  = "json"
+	FormatSARIF = "sarif"
+)
+
+var formats = []string{FormatText, FormatJSON, FormatSARIF}
+
+// Finding is a problem found in a resource. Line is 0 when the location of
+// the problem in the file is unknown.
+type Finding struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	File     string `json:"file"`
+	Line     int    `json:"line,omitempty"`
+	Kind     string `json:"kind,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Path     string `json:"path,omitempty"`
+	Message  string `json:"message"`
+}
+
+// rules describe the checks, they are listed in the SARIF output.
+var rules = []struct {
+	ID          string
+	Description string
+}{
+	{"yaml", "The file is valid YAML and the resource matches the schema of its kind"},
+	{"tekton-validation", "The resource passes the validation of the Tekton webhook"},
+	{"unknown-variable", "The variables used in substitutions exist"},
+	{"undeclared-result", "The results used from other tasks are declared by their Task"},
+	{"unused-param", "The declared params are used"},
+	{"unused-workspace", "The declared workspaces are used"},
+	{"missing-run-after", "The tasks sharing a workspace are ordered"},
+	{"resolver-params", "The params required by the resolver are given"},
+	{"task-params", "The params of the referenced Task are given"},
+	{"task-workspaces", "The workspaces of the referenced Task are bound"},
+	{"stepaction-params", "The params of the referenced StepAction are given"},
+	{"pipeline-params", "The params of the referenced Pipeline are given"},
+	{"pipeline-workspaces", "The workspaces of the referenced Pipeline are bound"},
+}
+
+type lintOptions struct {
+	Filenames []string
+	Output    string
+}
+
+// Command returns the command checking the Tekton resources of local files.
+func Command() *cobra.Command {
+	opts := &lintOptions{Output: FormatText}
+	eg := `Check the Tasks and Pipelines of the directory .tekton:
+
+    opc lint -f .tekton
+
+Check two files and write the findings as SARIF for code scanning:
+
+    opc lint -f task.yaml -f pipeline.yaml -o sarif > lint.sarif
+`
+
+	c := &cobra.Command{
+		Use:   "lint",
+		Short: "Check Tekton resources of local files",
+		Long: `Check the Tasks, Pipelines, PipelineRuns and StepActions of local files without a cluster.
+
+The resources are validated the way the Tekton webhook does, then checked by additional rules: the variables used
+in substitutions, the results used from other tasks, the unused params and workspaces, the tasks sharing a workspace
+without being ordered by runAfter and the params of the resolvers. The Tasks, StepActions and Pipelines referenced by
+name are checked against the ones of the given files.
+
+The directories are searched recursively for .yaml and .yml files. The command fails when errors are found.`,
+		Example: eg,
+		Args:    cobra.NoArgs,
+		Annotations: map[string]string{
+			"commandType": "main",
+		},
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if len(opts.Filenames) == 0 {
+				return fmt.Errorf("at least one file or directory must be given with --filename")
+			}
+			if !slices.Contains(formats, opts.Output) {
+				return fmt.Errorf("invalid output format %q, must be one of %s", opts.Output, strings.Join(formats, ", "))
+			}
+
+			files, err := yamlFiles(opts.Filenames)
+			if err != nil {
+				return err
+			}
+			findings := Lint(files)
+			if err := write(cmd.OutOrStdout(), findings, opts.Output); err != nil {
+				return err
+			}
+			if n := count(findings, SeverityError); n > 0 {
+				return fmt.Errorf("found %d errors", n)
+			}
+			return nil
+		},
+	}
+	c.Flags().StringSliceVarP(&opts.Filenames, "filename", "f", []string{}, "file or directory containing the resources to check, can be repeated")
+	c.Flags().StringVarP(&opts.Output, "output", "o", opts.Output, "output format, one of "+strings.Join(formats, ", "))
+
+	return c
+}
+
+// Lint returns the findings of the resources of the files, sorted by file
+// and line.
+func Lint(files []string) []Finding {
+	objs, findings := load(files)
+	catalog := newCatalog(objs)
+	for _, o := range objs {
+		validation := o.validate()
+		findings = append(findings, validation...)
+		findings = append(findings, dedupe(o.check(catalog), validation)...)
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].File != findings[j].File {
+			return findings[i].File < findings[j].File
+		}
+		return findings[i].Line < findings[j].Line
+	})
+	return findings
+}
+
+// dedupe drops the findings about the variables already reported by the
+// validation.
+func dedupe(findings, validation []Finding) []Finding {
+	kept := []Finding{}
+	for _, f := range findings {
+		duplicate := false
+		for _, v := range validation {
+			if expr := variable(f.Message); expr != "" && strings.Contains(v.Message, expr) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+func count(findings []Finding, severity string) int {
+	n := 0
+	for _, f := range findings {
+		if f.Severity == severity {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,323 @@
+package lint
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// lintYAML returns the findings of the documents written to a file, as
+// "<line> <rule> <severity>: <message>".
+func lintYAML(t *testing.T, yaml string) []string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "resources.yaml")
+	if err := os.WriteFile(file, []byte(yaml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for _, f := range Lint([]string{file}) {
+		got = append(got, fmt.Sprintf("%d %s %s: %s", f.Line, f.Rule, f.Severity, f.Message))
+	}
+	return got
+}
+
+const buildTask = `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: build
+spec:
+  params:
+    - name: image
+  workspaces:
+    - name: source
+  results:
+    - name: digest
+  steps:
+    - name: build
+      image: registry.access.redhat.com/ubi9/buildah
+      workingDir: $(workspaces.source.path)
+      script: buildah build -t $(params.image) && echo -n sha > $(results.digest.path)
+`
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want []string
+	}{{
+		name: "no problems",
+		yaml: buildTask,
+		want: []string{},
+	}, {
+		name: "yaml",
+		yaml: "apiVersion: tekton.dev/v1\nkind: Task\n  metadata: {\n",
+		want: []string{"3 yaml error: mapping values are not allowed in this context"},
+	}, {
+		name: "tekton-validation",
+		yaml: `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: empty
+spec:
+  steps: []
+`,
+		want: []string{"6 tekton-validation error: missing field(s)"},
+	}, {
+		name: "unknown-variable",
+		yaml: `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: echo
+spec:
+  steps:
+    - name: echo
+      image: ubi9
+      script: echo $(context.taskRun.name) $(context.foo.name) $(foo.bar)
+`,
+		want: []string{
+			"9 unknown-variable warning: unknown variable $(context.foo.name)",
+			"9 unknown-variable warning: unknown variable $(foo.bar)",
+		},
+	}, {
+		name: "command substitutions",
+		yaml: `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: echo
+spec:
+  steps:
+    - name: echo
+      image: ubi9
+      script: echo $(date +%s) $( ) $(pwd)
+`,
+		want: []string{},
+	}, {
+		name: "undeclared-result",
+		yaml: buildTask + `---
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: release
+spec:
+  workspaces:
+    - name: source
+  tasks:
+    - name: build
+      taskRef:
+        name: build
+      params:
+        - name: image
+          value: quay.io/org/app
+      workspaces:
+        - name: source
+    - name: deploy
+      taskSpec:
+        params:
+          - name: digest
+        steps:
+          - name: deploy
+            image: ubi9
+            script: echo $(params.digest)
+      params:
+        - name: digest
+          value: $(tasks.build.results.image)
+`,
+		want: []string{`44 undeclared-result error: $(tasks.build.results.image) uses the result "image" which is not declared by the Task of "build"`},
+	}, {
+		name: "unused-param",
+		yaml: `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: echo
+spec:
+  params:
+    - name: message
+    - name: unused
+  steps:
+    - name: echo
+      image: ubi9
+      script: echo $(params.message)
+`,
+		want: []string{`8 unused-param warning: param "unused" is never used`},
+	}, {
+		name: "unused-workspace",
+		yaml: `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: echo
+spec:
+  workspaces:
+    - name: source
+    - name: cache
+      mountPath: /cache
+    - name: unused
+  steps:
+    - name: echo
+      image: ubi9
+      script: ls $(workspaces.source.path) /cache
+`,
+		want: []string{`10 unused-workspace warning: workspace "unused" is never used`},
+	}, {
+		name: "unused pipeline workspace",
+		yaml: `apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: release
+spec:
+  workspaces:
+    - name: source
+    - name: unused
+  tasks:
+    - name: list
+      taskSpec:
+        steps:
+          - name: list
+            image: ubi9
+            script: ls $(workspaces.source.path) $( )
+`,
+		want: []string{`8 unused-workspace warning: workspace "unused" is not bound to any task`},
+	}, {
+		name: "missing-run-after",
+		yaml: buildTask + `---
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: release
+spec:
+  workspaces:
+    - name: source
+  tasks:
+    - name: build
+      taskRef:
+        name: build
+      params:
+        - name: image
+          value: quay.io/org/app
+      workspaces:
+        - name: source
+    - name: build-debug
+      taskRef:
+        name: build
+      params:
+        - name: image
+          value: quay.io/org/app-debug
+      workspaces:
+        - name: source
+`,
+		want: []string{`34 missing-run-after warning: tasks "build" and "build-debug" both use workspace "source" and may run at the same time, add runAfter to order them`},
+	}, {
+		name: "resolver-params",
+		yaml: `apiVersion: tekton.dev/v1
+kind: PipelineRun
+metadata:
+  name: release
+spec:
+  pipelineRef:
+    resolver: git
+    params:
+      - name: url
+        value: https://github.com/org/repo
+`,
+		want: []string{`6 resolver-params error: the git resolver requires the param "pathInRepo"`},
+	}, {
+		name: "task-params and task-workspaces",
+		yaml: buildTask + `---
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: release
+spec:
+  tasks:
+    - name: build
+      taskRef:
+        name: build
+      params:
+        - name: tag
+          value: latest
+      workspaces:
+        - name: cache
+          workspace: source
+  workspaces:
+    - name: source
+`,
+		want: []string{
+			`24 task-workspaces error: workspace "source" of Task build is not bound`,
+			`27 task-params error: param "image" required by Task build is not given`,
+			`28 task-params warning: param "tag" is not declared by Task build`,
+			`31 task-workspaces warning: workspace "cache" is not declared by Task build`,
+		},
+	}, {
+		name: "stepaction-params",
+		yaml: `apiVersion: tekton.dev/v1beta1
+kind: StepAction
+metadata:
+  name: echo
+spec:
+  image: ubi9
+  params:
+    - name: message
+  command: ["echo"]
+  args: ["$(params.message)"]
+---
+apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: greet
+spec:
+  steps:
+    - name: greet
+      ref:
+        name: echo
+`,
+		want: []string{`18 stepaction-params error: param "message" required by StepAction echo is not given`},
+	}, {
+		name: "pipeline-params and pipeline-workspaces",
+		yaml: `apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: release
+spec:
+  params:
+    - name: revision
+  workspaces:
+    - name: source
+  tasks:
+    - name: list
+      workspaces:
+        - name: source
+      taskSpec:
+        workspaces:
+          - name: source
+        steps:
+          - name: list
+            image: ubi9
+            script: git -C $(workspaces.source.path) checkout $(params.revision)
+---
+apiVersion: tekton.dev/v1
+kind: PipelineRun
+metadata:
+  name: release-run
+spec:
+  pipelineRef:
+    name: release
+  workspaces:
+    - name: cache
+      emptyDir: {}
+`,
+		want: []string{
+			`26 pipeline-params error: param "revision" required by Pipeline release is not given`,
+			`29 pipeline-workspaces error: workspace "source" of Pipeline release is not bound`,
+			`30 pipeline-workspaces warning: workspace "cache" is not declared by Pipeline release`,
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if d := cmp.Diff(tt.want, lintYAML(t, tt.yaml)); d != "" {
+				t.Errorf("Lint() mismatch (-want +got):\n%s", d)
+			}
+		})
+	}
+}
@@ -0,0 +1,324 @@
+package lint
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	"go.yaml.in/yaml/v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"knative.dev/pkg/apis"
+)
+
+// resource is a Tekton resource validated by the webhook.
+type resource interface {
+	runtime.Object
+	apis.Defaultable
+	apis.Validatable
+}
+
+// kinds are the resources checked, by apiVersion and kind.
+var kinds = map[string]func() resource{
+	"tekton.dev/v1/Task":             func() resource { return &v1.Task{} },
+	"tekton.dev/v1beta1/Task":        func() resource { return &v1beta1.Task{} },
+	"tekton.dev/v1/Pipeline":         func() resource { return &v1.Pipeline{} },
+	"tekton.dev/v1beta1/Pipeline":    func() resource { return &v1beta1.Pipeline{} },
+	"tekton.dev/v1/PipelineRun":      func() resource { return &v1.PipelineRun{} },
+	"tekton.dev/v1beta1/PipelineRun": func() resource { return &v1beta1.PipelineRun{} },
+	"tekton.dev/v1beta1/StepAction":  func() resource { return &v1beta1.StepAction{} },
+	"tekton.dev/v1alpha1/StepAction": func() resource { return &v1alpha1.StepAction{} },
+}
+
+var yamlLine = regexp.MustCompile(`^yaml: line (\d+): `)
+
+// object is a resource of a file, converted to the latest version for the
+// rules. The node is the mapping of the YAML document, used to find the
+// lines of the findings.
+type object struct {
+	file     string
+	node     *yaml.Node
+	kind     string
+	name     string
+	resource resource
+
+	task        *v1.Task
+	pipeline    *v1.Pipeline
+	pipelineRun *v1.PipelineRun
+	stepAction  *v1beta1.StepAction
+}
+
+// yamlFiles returns the YAML files of the paths, the directories are
+// searched recursively skipping the hidden ones.
+func yamlFiles(paths []string) ([]string, error) {
+	files := []string{}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if p != path && strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if ext := filepath.Ext(p); ext == ".yaml" || ext == ".yml" {
+				files = append(files, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// load returns the resources of the files and the findings of the documents
+// which cannot be parsed. The documents of other kinds are ignored.
+func load(files []string) ([]*object, []Finding) {
+	objs := []*object{}
+	findings := []Finding{}
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			findings = append(findings, Finding{Rule: "yaml", Severity: SeverityError, File: file, Message: err.Error()})
+			continue
+		}
+		dec := yaml.NewDecoder(bytes.NewReader(b))
+		for {
+			doc := &yaml.Node{}
+			err := dec.Decode(doc)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				f := Finding{Rule: "yaml", Severity: SeverityError, File: file, Message: err.Error()}
+				if m := yamlLine.FindStringSubmatch(err.Error()); m != nil {
+					f.Line, _ = strconv.Atoi(m[1])
+					f.Message = strings.TrimPrefix(err.Error(), m[0])
+				}
+				findings = append(findings, f)
+				break
+			}
+			if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+				continue
+			}
+			o, finding := newObject(file, doc.Content[0])
+			if finding != nil {
+				findings = append(findings, *finding)
+			}
+			if o != nil {
+				objs = append(objs, o)
+			}
+		}
+	}
+	return objs, findings
+}
+
+// newObject decodes the document, a finding is returned with the object when
+// the document has fields unknown to its kind.
+func newObject(file string, node *yaml.Node) (*object, *Finding) {
+	m := map[string]interface{}{}
+	if err := node.Decode(&m); err != nil {
+		return nil, &Finding{Rule: "yaml", Severity: SeverityError, File: file, Line: node.Line, Message: err.Error()}
+	}
+	apiVersion, _ := m["apiVersion"].(string)
+	kind, _ := m["kind"].(string)
+	newResource, ok := kinds[apiVersion+"/"+kind]
+	if !ok {
+		return nil, nil
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, &Finding{Rule: "yaml", Severity: SeverityError, File: file, Line: node.Line, Kind: kind, Message: err.Error()}
+	}
+
+	o := &object{file: file, node: node, kind: kind, resource: newResource()}
+	var finding *Finding
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(o.resource); err != nil {
+		finding = &Finding{Rule: "yaml", Severity: SeverityError, File: file, Line: node.Line, Kind: kind, Message: strings.TrimPrefix(err.Error(), "json: ")}
+		// the other fields are still checked
+		o.resource = newResource()
+		if err := json.Unmarshal(b, o.resource); err != nil {
+			return nil, finding
+		}
+	}
+	if err := o.convert(); err != nil {
+		return nil, &Finding{Rule: "yaml", Severity: SeverityError, File: file, Line: node.Line, Kind: kind, Message: err.Error()}
+	}
+	finding = o.located(finding)
+	return o, finding
+}
+
+// convert sets the latest version of the resource used by the rules.
+func (o *object) convert() error {
+	ctx := context.Background()
+	switch r := o.resource.(type) {
+	case *v1.Task:
+		o.task = r.DeepCopy()
+	case *v1beta1.Task:
+		o.task = &v1.Task{}
+		if err := r.DeepCopy().ConvertTo(ctx, o.task); err != nil {
+			return err
+		}
+	case *v1.Pipeline:
+		o.pipeline = r.DeepCopy()
+	case *v1beta1.Pipeline:
+		o.pipeline = &v1.Pipeline{}
+		if err := r.DeepCopy().ConvertTo(ctx, o.pipeline); err != nil {
+			return err
+		}
+	case *v1.PipelineRun:
+		o.pipelineRun = r.DeepCopy()
+	case *v1beta1.PipelineRun:
+		o.pipelineRun = &v1.PipelineRun{}
+		if err := r.DeepCopy().ConvertTo(ctx, o.pipelineRun); err != nil {
+			return err
+		}
+	case *v1beta1.StepAction:
+		o.stepAction = r.DeepCopy()
+	case *v1alpha1.StepAction:
+		o.stepAction = &v1beta1.StepAction{}
+		if err := r.DeepCopy().ConvertTo(ctx, o.stepAction); err != nil {
+			return err
+		}
+	}
+	switch {
+	case o.task != nil:
+		o.name = o.task.Name
+	case o.pipeline != nil:
+		o.name = o.pipeline.Name
+	case o.pipelineRun != nil:
+		o.name = o.pipelineRun.Name
+		if o.name == "" {
+			o.name = o.pipelineRun.GenerateName
+		}
+	case o.stepAction != nil:
+		o.name = o.stepAction.Name
+	}
+	return nil
+}
+
+// validate returns the findings of the validation of the Tekton webhook,
+// which validates the resources once their defaults are set.
+func (o *object) validate() []Finding {
+	r, ok := o.resource.DeepCopyObject().(resource)
+	if !ok {
+		return nil
+	}
+	// the name is generated before the resource is validated
+	if m, ok := r.(metav1.Object); ok && m.GetName() == "" && m.GetGenerateName() != "" {
+		m.SetName(m.GetGenerateName() + "lint")
+	}
+	ctx := apis.WithinCreate(context.Background())
+	r.SetDefaults(ctx)
+	findings := []Finding{}
+	for _, fe := range r.Validate(ctx).WrappedErrors() {
+		severity := SeverityError
+		if fe.Level == apis.WarningLevel {
+			severity = SeverityWarning
+		}
+		path, message := "", fe.Message
+		if len(fe.Paths) > 0 {
+			path = fe.Paths[0]
+		}
+		if len(fe.Paths) > 1 {
+			message = fmt.Sprintf("%s: %s", message, strings.Join(fe.Paths, ", "))
+		}
+		if fe.Details != "" {
+			message = fmt.Sprintf("%s: %s", message, fe.Details)
+		}
+		findings = append(findings, o.finding("tekton-validation", severity, path, "%s", message))
+	}
+	return findings
+}
+
+// finding returns a finding of the object at the path, in the format of the
+// paths of the validation like spec.tasks[0].params[name].
+func (o *object) finding(rule, severity, path, format string, args ...interface{}) Finding {
+	return Finding{
+		Rule:     rule,
+		Severity: severity,
+		File:     o.file,
+		Line:     line(o.node, path),
+		Kind:     o.kind,
+		Name:     o.name,
+		Path:     path,
+		Message:  fmt.Sprintf(format, args...),
+	}
+}
+
+// located sets the resource of the finding of the document.
+func (o *object) located(f *Finding) *Finding {
+	if f != nil {
+		f.Kind, f.Name = o.kind, o.name
+	}
+	return f
+}
+
+var pathSegment = regexp.MustCompile(`([^.\[\]]+)|\[([^\]]*)\]`)
+
+// line returns the line of the deepest node of the path found in the
+// document, the items of the lists are given by index or by name.
+func line(node *yaml.Node, path string) int {
+	l := node.Line
+	for _, m := range pathSegment.FindAllStringSubmatch(path, -1) {
+		var key *yaml.Node
+		key, node = child(node, m[1], m[2])
+		if node == nil {
+			break
+		}
+		l = key.Line
+	}
+	return l
+}
+
+// child returns the key and the value of the field, or the item of the list
+// twice.
+func child(node *yaml.Node, field, item string) (*yaml.Node, *yaml.Node) {
+	switch {
+	case field != "" && node.Kind == yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == field {
+				return node.Content[i], node.Content[i+1]
+			}
+		}
+	case field == "" && node.Kind == yaml.SequenceNode:
+		if i, err := strconv.Atoi(item); err == nil {
+			if i >= 0 && i < len(node.Content) {
+				return node.Content[i], node.Content[i]
+			}
+			return nil, nil
+		}
+		for _, n := range node.Content {
+			if _, name := child(n, "name", ""); name != nil && name.Value == item {
+				return n, n
+			}
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,66 @@
+package lint
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func TestWriteSARIF(t *testing.T) {
+	findings := Lint([]string{filepath.Join("testdata", "pipeline.yaml")})
+	out := &bytes.Buffer{}
+	if err := write(out, findings, FormatSARIF); err != nil {
+		t.Fatal(err)
+	}
+
+	golden := filepath.Join("testdata", "pipeline.sarif")
+	if *update {
+		if err := os.WriteFile(golden, out.Bytes(), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := cmp.Diff(string(want), out.String()); d != "" {
+		t.Errorf("SARIF output mismatch (-want +got):\n%s", d)
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	tests := []struct {
+		name     string
+		findings []Finding
+		want     string
+	}{{
+		name: "no findings",
+		want: "No problems found\n",
+	}, {
+		name: "findings",
+		findings: []Finding{
+			{Rule: "yaml", Severity: SeverityError, File: "a.yaml", Message: "invalid"},
+			{Rule: "unused-param", Severity: SeverityWarning, File: "b.yaml", Line: 8, Kind: "Task", Name: "build", Path: "spec.params[1]", Message: `param "unused" is never used`},
+		},
+		want: "a.yaml: error: invalid (yaml)\n" +
+			`b.yaml:8: warning: Task/build: param "unused" is never used [spec.params[1]] (unused-param)` + "\n" +
+			"1 errors, 1 warnings\n",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			if err := write(out, tt.findings, FormatText); err != nil {
+				t.Fatal(err)
+			}
+			if d := cmp.Diff(tt.want, out.String()); d != "" {
+				t.Errorf("write() mismatch (-want +got):\n%s", d)
+			}
+		})
+	}
+}
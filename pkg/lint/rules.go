@@ -0,0 +1,663 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	"go.yaml.in/yaml/v3"
+)
+
+var (
+	variablePattern = regexp.MustCompile(`\$\(([^()]+)\)`)
+	// quotedName matches the variables like params["a.b"] which names
+	// contain dots
+	quotedName = regexp.MustCompile(`^([\w-]+)\[["']([^"']+)["']\](.*)$`)
+)
+
+// resolverParams are the params required by the resolvers of Tekton, one of
+// the params of each group must be given.
+var resolverParams = map[string][][]string{
+	"bundles": {{"bundle"}, {"name"}, {"kind"}},
+	"cluster": {{"name"}, {"namespace"}},
+	"git":     {{"url", "repo"}, {"pathInRepo"}},
+	"http":    {{"url"}},
+	"hub":     {{"name"}, {"version"}},
+}
+
+// The roots of the variables, by the kind of spec they are substituted in.
+var (
+	taskRoots       = names("params", "results", "workspaces", "context", "steps", "step", "credentials", "artifacts")
+	taskContexts    = names("taskRun", "task")
+	pipelineRoots   = names("params", "tasks", "context", "workspaces")
+	pipelineContext = names("pipelineRun", "pipeline", "pipelineTask")
+)
+
+// catalog are the resources of the files, the references by name are
+// checked against them.
+type catalog struct {
+	tasks       map[string]*v1.TaskSpec
+	stepActions map[string]*v1beta1.StepActionSpec
+	pipelines   map[string]*v1.PipelineSpec
+}
+
+func newCatalog(objs []*object) *catalog {
+	c := &catalog{
+		tasks:       map[string]*v1.TaskSpec{},
+		stepActions: map[string]*v1beta1.StepActionSpec{},
+		pipelines:   map[string]*v1.PipelineSpec{},
+	}
+	for _, o := range objs {
+		switch {
+		case o.task != nil:
+			c.tasks[o.name] = &o.task.Spec
+		case o.stepAction != nil:
+			c.stepActions[o.name] = &o.stepAction.Spec
+		case o.pipeline != nil:
+			c.pipelines[o.name] = &o.pipeline.Spec
+		}
+	}
+	return c
+}
+
+// taskSpec returns the spec of the Task of the pipeline task when it is
+// embedded or in the catalog.
+func (c *catalog) taskSpec(pt *v1.PipelineTask) *v1.TaskSpec {
+	if pt.TaskSpec != nil {
+		return &pt.TaskSpec.TaskSpec
+	}
+	if pt.TaskRef != nil && pt.TaskRef.Resolver == "" && (pt.TaskRef.Kind == "" || pt.TaskRef.Kind == v1.NamespacedTaskKind) {
+		return c.tasks[pt.TaskRef.Name]
+	}
+	return nil
+}
+
+// check returns the findings of the semantic rules.
+func (o *object) check(c *catalog) []Finding {
+	findings := []Finding{}
+	switch {
+	case o.task != nil:
+		findings = o.checkTask("spec", &o.task.Spec, nil, nil, c)
+		findings = append(findings, o.unusedParams("spec", o.task.Spec.Params)...)
+		findings = append(findings, o.unusedTaskWorkspaces("spec", &o.task.Spec)...)
+	case o.stepAction != nil:
+		findings = o.checkStepAction()
+		findings = append(findings, o.unusedParams("spec", o.stepAction.Spec.Params)...)
+	case o.pipeline != nil:
+		findings = o.checkPipeline("spec", &o.pipeline.Spec, c)
+	case o.pipelineRun != nil:
+		findings = o.checkPipelineRun(c)
+	}
+	return findings
+}
+
+// checkTask checks a Task, the pipeline task and the pipeline of an embedded
+// Task give the params and the workspaces propagated to it.
+func (o *object) checkTask(path string, spec *v1.TaskSpec, pt *v1.PipelineTask, pipeline *v1.PipelineSpec, c *catalog) []Finding {
+	params := paramNames(spec.Params)
+	workspaces := names()
+	for _, w := range spec.Workspaces {
+		workspaces[w.Name] = true
+	}
+	results := names()
+	for _, r := range spec.Results {
+		results[r.Name] = true
+	}
+	contexts := taskContexts
+	if pipeline != nil {
+		for name := range paramNames(pipeline.Params) {
+			params[name] = true
+		}
+		for _, p := range pt.Params {
+			params[p.Name] = true
+		}
+		for _, w := range pipeline.Workspaces {
+			workspaces[w.Name] = true
+		}
+		contexts = union(taskContexts, pipelineContext)
+	}
+
+	findings := []Finding{}
+	o.variables(path, skipPaths(path+".params"), func(p, expr string, parts []string) {
+		switch root := parts[0]; {
+		case !taskRoots[root] && (pipeline == nil || !pipelineRoots[root]):
+			findings = append(findings, o.finding("unknown-variable", SeverityWarning, p, "unknown variable $(%s)", expr))
+		case len(parts) < 2:
+		case root == "params" && !params[parts[1]]:
+			findings = append(findings, o.finding("unknown-variable", SeverityError, p, "$(%s) uses the undeclared param %q", expr, parts[1]))
+		case root == "results" && !results[parts[1]]:
+			findings = append(findings, o.finding("unknown-variable", SeverityError, p, "$(%s) uses the undeclared result %q", expr, parts[1]))
+		case root == "workspaces" && !workspaces[parts[1]]:
+			findings = append(findings, o.finding("unknown-variable", SeverityError, p, "$(%s) uses the undeclared workspace %q", expr, parts[1]))
+		case root == "context" && !contexts[parts[1]]:
+			findings = append(findings, o.finding("unknown-variable", SeverityWarning, p, "unknown variable $(%s)", expr))
+		}
+	})
+
+	for i, step := range spec.Steps {
+		if step.Ref == nil {
+			continue
+		}
+		stepPath := fmt.Sprintf("%s.steps[%d]", path, i)
+		if step.Ref.Resolver != "" {
+			findings = append(findings, o.resolverParams(stepPath+".ref", string(step.Ref.Resolver), step.Params)...)
+			continue
+		}
+		if sa, ok := c.stepActions[step.Ref.Name]; ok {
+			findings = append(findings, o.refParams("stepaction-params", stepPath+".params", "StepAction "+step.Ref.Name, sa.Params, step.Params, nil)...)
+		}
+	}
+	return findings
+}
+
+func (o *object) checkStepAction() []Finding {
+	params := paramNames(o.stepAction.Spec.Params)
+	findings := []Finding{}
+	o.variables("spec", skipPaths("spec.params"), func(p, expr string, parts []string) {
+		switch root := parts[0]; {
+		case !taskRoots[root]:
+			findings = append(findings, o.finding("unknown-variable", SeverityWarning, p, "unknown variable $(%s)", expr))
+		case root == "params" && len(parts) > 1 && !params[parts[1]]:
+			findings = append(findings, o.finding("unknown-variable", SeverityError, p, "$(%s) uses the undeclared param %q", expr, parts[1]))
+		}
+	})
+	return findings
+}
+
+func (o *object) checkPipeline(path string, spec *v1.PipelineSpec, c *catalog) []Finding {
+	tasks := map[string]*v1.PipelineTask{}
+	for i := range spec.Tasks {
+		tasks[spec.Tasks[i].Name] = &spec.Tasks[i]
+	}
+	for i := range spec.Finally {
+		tasks[spec.Finally[i].Name] = &spec.Finally[i]
+	}
+	params := paramNames(spec.Params)
+	workspaces := names()
+	for _, w := range spec.Workspaces {
+		workspaces[w.Name] = true
+	}
+
+	findings := []Finding{}
+	skip := func(p string) bool {
+		return strings.HasSuffix(p, ".taskSpec") || strings.HasSuffix(p, ".pipelineSpec") || p == path+".params"
+	}
+	o.variables(path, skip, func(p, expr string, parts []string) {
+		switch root := parts[0]; {
+		case !pipelineRoots[root]:
+			findings = append(findings, o.finding("unknown-variable", SeverityWarning, p, "unknown variable $(%s)", expr))
+		case len(parts) < 2:
+		case root == "params" && !params[parts[1]]:
+			findings = append(findings, o.finding("unknown-variable", SeverityError, p, "$(%s) uses the undeclared param %q", expr, parts[1]))
+		case root == "workspaces" && !workspaces[parts[1]]:
+			findings = append(findings, o.finding("unknown-variable", SeverityError, p, "$(%s) uses the undeclared workspace %q", expr, parts[1]))
+		case root == "context" && !pipelineContext[parts[1]]:
+			findings = append(findings, o.finding("unknown-variable", SeverityWarning, p, "unknown variable $(%s)", expr))
+		case root == "tasks" && parts[1] != "status":
+			pt, ok := tasks[parts[1]]
+			if !ok {
+				findings = append(findings, o.finding("unknown-variable", SeverityError, p, "$(%s) uses the unknown task %q", expr, parts[1]))
+				return
+			}
+			if len(parts) < 4 || parts[2] != "results" {
+				return
+			}
+			if ts := c.taskSpec(pt); ts != nil && !declaresResult(ts, parts[3]) {
+				findings = append(findings, o.finding("undeclared-result", SeverityError, p, "$(%s) uses the result %q which is not declared by the Task of %q", expr, parts[3], pt.Name))
+			}
+		}
+	})
+
+	for _, section := range []struct {
+		name  string
+		tasks []v1.PipelineTask
+	}{{"tasks", spec.Tasks}, {"finally", spec.Finally}} {
+		for i := range section.tasks {
+			pt := &section.tasks[i]
+			ptPath := fmt.Sprintf("%s.%s[%d]", path, section.name, i)
+			if pt.TaskSpec != nil {
+				findings = append(findings, o.checkTask(ptPath+".taskSpec", &pt.TaskSpec.TaskSpec, pt, spec, c)...)
+			}
+			if pt.TaskRef != nil && pt.TaskRef.Resolver != "" {
+				findings = append(findings, o.resolverParams(ptPath+".taskRef", string(pt.TaskRef.Resolver), pt.TaskRef.Params)...)
+			}
+			findings = append(findings, o.checkPipelineTask(ptPath, pt, spec, c)...)
+		}
+	}
+	findings = append(findings, o.unusedParams(path, spec.Params)...)
+	findings = append(findings, o.unusedPipelineWorkspaces(path, spec)...)
+	findings = append(findings, o.missingRunAfter(path, spec, c)...)
+	return findings
+}
+
+// checkPipelineTask checks the params and the workspaces given to the Task
+// of the pipeline task.
+func (o *object) checkPipelineTask(path string, pt *v1.PipelineTask, pipeline *v1.PipelineSpec, c *catalog) []Finding {
+	ts := c.taskSpec(pt)
+	if ts == nil {
+		return nil
+	}
+	findings := []Finding{}
+	if pt.TaskSpec != nil {
+		// the params of the pipeline are propagated to the embedded Tasks
+		findings = append(findings, o.refParams("task-params", path+".params", fmt.Sprintf("the Task of %q", pt.Name), ts.Params, pt.Params, paramNames(pipeline.Params))...)
+		return findings
+	}
+	findings = append(findings, o.refParams("task-params", path+".params", "Task "+pt.TaskRef.Name, ts.Params, pt.Params, nil)...)
+
+	bound := names()
+	for j, w := range pt.Workspaces {
+		bound[w.Name] = true
+		if !declaresWorkspace(ts, w.Name) {
+			findings = append(findings, o.finding("task-workspaces", SeverityWarning, fmt.Sprintf("%s.workspaces[%d]", path, j), "workspace %q is not declared by Task %s", w.Name, pt.TaskRef.Name))
+		}
+	}
+	for _, w := range ts.Workspaces {
+		if !w.Optional && !bound[w.Name] {
+			findings = append(findings, o.finding("task-workspaces", SeverityError, path, "workspace %q of Task %s is not bound", w.Name, pt.TaskRef.Name))
+		}
+	}
+	return findings
+}
+
+func (o *object) checkPipelineRun(c *catalog) []Finding {
+	pr := o.pipelineRun
+	findings := []Finding{}
+	var spec *v1.PipelineSpec
+	var ref string
+	switch {
+	case pr.Spec.PipelineSpec != nil:
+		spec, ref = pr.Spec.PipelineSpec, "the Pipeline"
+		findings = append(findings, o.checkPipeline("spec.pipelineSpec", spec, c)...)
+	case pr.Spec.PipelineRef != nil && pr.Spec.PipelineRef.Resolver != "":
+		findings = append(findings, o.resolverParams("spec.pipelineRef", string(pr.Spec.PipelineRef.Resolver), pr.Spec.PipelineRef.Params)...)
+	case pr.Spec.PipelineRef != nil:
+		spec, ref = c.pipelines[pr.Spec.PipelineRef.Name], "Pipeline "+pr.Spec.PipelineRef.Name
+	}
+	if spec == nil {
+		return findings
+	}
+
+	findings = append(findings, o.refParams("pipeline-params", "spec.params", ref, spec.Params, pr.Spec.Params, nil)...)
+	bound := names()
+	for i, w := range pr.Spec.Workspaces {
+		bound[w.Name] = true
+		declared := false
+		for _, d := range spec.Workspaces {
+			declared = declared || d.Name == w.Name
+		}
+		if !declared {
+			findings = append(findings, o.finding("pipeline-workspaces", SeverityWarning, fmt.Sprintf("spec.workspaces[%d]", i), "workspace %q is not declared by %s", w.Name, ref))
+		}
+	}
+	for _, w := range spec.Workspaces {
+		if !w.Optional && !bound[w.Name] {
+			findings = append(findings, o.finding("pipeline-workspaces", SeverityError, "spec.workspaces", "workspace %q of %s is not bound", w.Name, ref))
+		}
+	}
+	return findings
+}
+
+// refParams checks the params given to a referenced resource, the required
+// params may also be propagated.
+func (o *object) refParams(rule, path, ref string, declared v1.ParamSpecs, given v1.Params, propagated map[string]bool) []Finding {
+	findings := []Finding{}
+	names := names()
+	for i, p := range given {
+		names[p.Name] = true
+		if !paramNames(declared)[p.Name] {
+			findings = append(findings, o.finding(rule, SeverityWarning, fmt.Sprintf("%s[%d]", path, i), "param %q is not declared by %s", p.Name, ref))
+		}
+	}
+	for _, p := range declared {
+		if p.Default == nil && !names[p.Name] && !propagated[p.Name] {
+			findings = append(findings, o.finding(rule, SeverityError, path, "param %q required by %s is not given", p.Name, ref))
+		}
+	}
+	return findings
+}
+
+func (o *object) resolverParams(path, resolver string, params v1.Params) []Finding {
+	given := names()
+	for _, p := range params {
+		given[p.Name] = true
+	}
+	findings := []Finding{}
+	for _, group := range resolverParams[resolver] {
+		found := false
+		for _, name := range group {
+			found = found || given[name]
+		}
+		if found {
+			continue
+		}
+		if len(group) == 1 {
+			findings = append(findings, o.finding("resolver-params", SeverityError, path, "the %s resolver requires the param %q", resolver, group[0]))
+			continue
+		}
+		findings = append(findings, o.finding("resolver-params", SeverityError, path, "the %s resolver requires one of the params %q", resolver, group))
+	}
+	return findings
+}
+
+// unusedParams returns the params of the spec at the path which are not used
+// by any variable.
+func (o *object) unusedParams(path string, params v1.ParamSpecs) []Finding {
+	used := names()
+	o.variables(path, skipPaths(path+".params"), func(_, _ string, parts []string) {
+		if parts[0] == "params" && len(parts) > 1 {
+			used[parts[1]] = true
+		}
+	})
+	findings := []Finding{}
+	for i, p := range params {
+		if !used[p.Name] {
+			findings = append(findings, o.finding("unused-param", SeverityWarning, fmt.Sprintf("%s.params[%d]", path, i), "param %q is never used", p.Name))
+		}
+	}
+	return findings
+}
+
+// unusedTaskWorkspaces returns the workspaces of the Task which are neither
+// used by variables nor by the steps and the sidecars, or by their path.
+func (o *object) unusedTaskWorkspaces(path string, spec *v1.TaskSpec) []Finding {
+	used := names()
+	o.variables(path, skipPaths(path+".workspaces"), func(_, _ string, parts []string) {
+		if parts[0] == "workspaces" && len(parts) > 1 {
+			used[parts[1]] = true
+		}
+	})
+	for _, step := range spec.Steps {
+		for _, w := range step.Workspaces {
+			used[w.Name] = true
+		}
+	}
+	for _, sidecar := range spec.Sidecars {
+		for _, w := range sidecar.Workspaces {
+			used[w.Name] = true
+		}
+	}
+	findings := []Finding{}
+	for i, w := range spec.Workspaces {
+		if used[w.Name] || o.contains(path+".steps", w.GetMountPath()) {
+			continue
+		}
+		findings = append(findings, o.finding("unused-workspace", SeverityWarning, fmt.Sprintf("%s.workspaces[%d]", path, i), "workspace %q is never used", w.Name))
+	}
+	return findings
+}
+
+// unusedPipelineWorkspaces returns the workspaces of the pipeline which are
+// not bound to any task.
+func (o *object) unusedPipelineWorkspaces(path string, spec *v1.PipelineSpec) []Finding {
+	used := names()
+	for _, pt := range append(append([]v1.PipelineTask{}, spec.Tasks...), spec.Finally...) {
+		for _, w := range pt.Workspaces {
+			used[boundWorkspace(w)] = true
+		}
+		if pt.TaskSpec != nil {
+			// the workspaces of the pipeline are propagated to the embedded
+			// Tasks
+			for _, expr := range variablesOf(pt.TaskSpec) {
+				if parts := variableParts(expr); len(parts) > 1 && parts[0] == "workspaces" {
+					used[parts[1]] = true
+				}
+			}
+		}
+	}
+	o.variables(path, skipPaths(path+".workspaces"), func(_, _ string, parts []string) {
+		if parts[0] == "workspaces" && len(parts) > 1 {
+			used[parts[1]] = true
+		}
+	})
+	findings := []Finding{}
+	for i, w := range spec.Workspaces {
+		if !used[w.Name] {
+			findings = append(findings, o.finding("unused-workspace", SeverityWarning, fmt.Sprintf("%s.workspaces[%d]", path, i), "workspace %q is not bound to any task", w.Name))
+		}
+	}
+	return findings
+}
+
+// missingRunAfter returns the tasks which may write to a workspace used by
+// another task running at the same time, as neither depends on the other.
+func (o *object) missingRunAfter(path string, spec *v1.PipelineSpec, c *catalog) []Finding {
+	deps := v1.PipelineTaskList(spec.Tasks).Deps()
+	type usage struct {
+		index    int
+		task     *v1.PipelineTask
+		readOnly bool
+	}
+	usages := map[string][]usage{}
+	for i := range spec.Tasks {
+		pt := &spec.Tasks[i]
+		for _, w := range pt.Workspaces {
+			readOnly := false
+			if ts := c.taskSpec(pt); ts != nil {
+				for _, d := range ts.Workspaces {
+					readOnly = readOnly || (d.Name == w.Name && d.ReadOnly)
+				}
+			}
+			ws := boundWorkspace(w)
+			usages[ws] = append(usages[ws], usage{index: i, task: pt, readOnly: readOnly})
+		}
+	}
+
+	workspaces := make([]string, 0, len(usages))
+	for ws := range usages {
+		workspaces = append(workspaces, ws)
+	}
+	sort.Strings(workspaces)
+	findings := []Finding{}
+	for _, ws := range workspaces {
+		u := usages[ws]
+		for i := range u {
+			for j := i + 1; j < len(u); j++ {
+				a, b := u[i], u[j]
+				if (a.readOnly && b.readOnly) || dependsOn(deps, a.task.Name, b.task.Name) || dependsOn(deps, b.task.Name, a.task.Name) {
+					continue
+				}
+				findings = append(findings, o.finding("missing-run-after", SeverityWarning, fmt.Sprintf("%s.tasks[%d].runAfter", path, b.index),
+					"tasks %q and %q both use workspace %q and may run at the same time, add runAfter to order them", a.task.Name, b.task.Name, ws))
+			}
+		}
+	}
+	return findings
+}
+
+// dependsOn returns whether the task depends on the other one, directly or
+// through other tasks.
+func dependsOn(deps map[string][]string, task, other string) bool {
+	seen := names()
+	var visit func(name string) bool
+	visit = func(name string) bool {
+		for _, dep := range deps[name] {
+			if dep == other {
+				return true
+			}
+			if !seen[dep] {
+				seen[dep] = true
+				if visit(dep) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	return visit(task)
+}
+
+// variables calls fn with the variables used by the strings of the node at
+// the path, the parts of the variable are its root and the names following
+// it.
+func (o *object) variables(path string, skip func(path string) bool, fn func(path, expr string, parts []string)) {
+	node := o.nodeAt(path)
+	if node == nil {
+		return
+	}
+	walk(node, path, skip, func(p, value string) {
+		for _, m := range variablePattern.FindAllStringSubmatch(value, -1) {
+			if isVariable(m[1]) {
+				fn(p, m[1], variableParts(m[1]))
+			}
+		}
+	})
+}
+
+// contains returns whether a string of the node at the path contains the
+// value.
+func (o *object) contains(path, value string) bool {
+	found := false
+	if node := o.nodeAt(path); node != nil {
+		walk(node, path, skipPaths(), func(_, s string) {
+			found = found || strings.Contains(s, value)
+		})
+	}
+	return found
+}
+
+func (o *object) nodeAt(path string) *yaml.Node {
+	node := o.node
+	for _, m := range pathSegment.FindAllStringSubmatch(path, -1) {
+		if _, node = child(node, m[1], m[2]); node == nil {
+			return nil
+		}
+	}
+	return node
+}
+
+func walk(node *yaml.Node, path string, skip func(string) bool, fn func(path, value string)) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			p := node.Content[i].Value
+			if path != "" {
+				p = path + "." + p
+			}
+			if !skip(p) {
+				walk(node.Content[i+1], p, skip, fn)
+			}
+		}
+	case yaml.SequenceNode:
+		for i, n := range node.Content {
+			walk(n, fmt.Sprintf("%s[%d]", path, i), skip, fn)
+		}
+	case yaml.ScalarNode:
+		fn(path, node.Value)
+	}
+}
+
+// variablesOf returns the variables used by the embedded Task.
+func variablesOf(spec *v1.EmbeddedTask) []string {
+	exprs := []string{}
+	b, err := json.Marshal(spec)
+	if err != nil {
+		return exprs
+	}
+	for _, m := range variablePattern.FindAllStringSubmatch(string(b), -1) {
+		if isVariable(m[1]) {
+			exprs = append(exprs, m[1])
+		}
+	}
+	return exprs
+}
+
+// isVariable returns whether the expression of $() is a variable, the
+// command substitutions of the scripts are not.
+func isVariable(expr string) bool {
+	return !strings.ContainsAny(expr, " \t\n$") && strings.ContainsAny(expr, ".[")
+}
+
+// variableParts splits the variable into its root and the names following
+// it, without the indexes like [*].
+func variableParts(expr string) []string {
+	expr = strings.TrimSpace(expr)
+	parts := []string{}
+	if m := quotedName.FindStringSubmatch(expr); m != nil {
+		parts = append(parts, m[1], m[2])
+		expr = strings.TrimPrefix(m[3], ".")
+	}
+	if expr != "" {
+		parts = append(parts, strings.Split(expr, ".")...)
+	}
+	for i, p := range parts {
+		if j := strings.Index(p, "["); j > 0 {
+			parts[i] = p[:j]
+		}
+	}
+	return parts
+}
+
+// variable returns the first variable of the message.
+func variable(message string) string {
+	return variablePattern.FindString(message)
+}
+
+func skipPaths(paths ...string) func(string) bool {
+	return func(p string) bool {
+		for _, path := range paths {
+			if p == path {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func declaresResult(spec *v1.TaskSpec, name string) bool {
+	for _, r := range spec.Results {
+		if r.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func declaresWorkspace(spec *v1.TaskSpec, name string) bool {
+	for _, w := range spec.Workspaces {
+		if w.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// boundWorkspace returns the workspace of the pipeline bound to the task,
+// which has the name of the workspace of the task by default.
+func boundWorkspace(w v1.WorkspacePipelineTaskBinding) string {
+	if w.Workspace != "" {
+		return w.Workspace
+	}
+	return w.Name
+}
+
+func paramNames(params v1.ParamSpecs) map[string]bool {
+	n := names()
+	for _, p := range params {
+		n[p.Name] = true
+	}
+	return n
+}
+
+func names(values ...string) map[string]bool {
+	n := map[string]bool{}
+	for _, v := range values {
+		n[v] = true
+	}
+	return n
+}
+
+func union(a, b map[string]bool) map[string]bool {
+	n := names()
+	for k := range a {
+		n[k] = true
+	}
+	for k := range b {
+		n[k] = true
+	}
+	return n
+}
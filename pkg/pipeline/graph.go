@@ -0,0 +1,309 @@
+// Package pipeline extends the pipeline commands of the Tekton CLI with opc
+// specific commands.
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tektoncd/cli/pkg/cli"
+	"github.com/tektoncd/cli/pkg/file"
+	"github.com/tektoncd/cli/pkg/formatted"
+	pipelinepkg "github.com/tektoncd/cli/pkg/pipeline"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+var pipelineGroupResource = schema.GroupVersionResource{Group: "tekton.dev", Resource: "pipelines"}
+
+// The outcomes of the tasks of a PipelineRun used to color the graph.
+const (
+	OutcomePending   = "pending"
+	OutcomeRunning   = "running"
+	OutcomeSucceeded = "succeeded"
+	OutcomeFailed    = "failed"
+	OutcomeCancelled = "cancelled"
+	OutcomeSkipped   = "skipped"
+)
+
+// Graph is the execution DAG of a pipeline.
+type Graph struct {
+	Name  string
+	Nodes []*Node
+	Edges []*Edge
+}
+
+// Node is a task of the pipeline, Outcome is only set for the graph of a
+// PipelineRun.
+type Node struct {
+	Name        string
+	DisplayName string
+	Ref         string
+	When        []string
+	Finally     bool
+	Level       int
+	Outcome     string
+}
+
+// Edge is a dependency of a task on another one, ordered by runAfter, by
+// the results of the other task it uses, or by finally.
+type Edge struct {
+	From     string
+	To       string
+	RunAfter bool
+	Results  []string
+	Finally  bool
+}
+
+type graphOptions struct {
+	Filename string
+	Output   string
+}
+
+// GraphCommand returns the command rendering the execution DAG of a
+// pipeline.
+func GraphCommand(p cli.Params) *cobra.Command {
+	opts := &graphOptions{Output: FormatASCII}
+	eg := `Render the Pipeline named 'foo' in namespace 'bar' as an image with Graphviz:
+
+    opc pipeline graph foo -n bar -o dot | dot -Tsvg > foo.svg
+
+Render the Pipeline of a local file as a Mermaid flowchart:
+
+    opc pipeline graph -f pipeline.yaml -o mermaid
+`
+
+	c := &cobra.Command{
+		Use:               "graph",
+		Short:             "Render the execution graph of a Pipeline",
+		ValidArgsFunction: formatted.ParentCompletion,
+		Long: `Render the execution graph of a Pipeline of the cluster or of a local or remote file as a Graphviz dot
+graph, a Mermaid flowchart or as text.
+
+The dependencies between the tasks are computed the way the Tekton controller does, from runAfter and from the
+results of other tasks used by the params and the when expressions of the tasks. The finally tasks run after all
+the other tasks.`,
+		Example: eg,
+		Args:    cobra.MaximumNArgs(1),
+		Annotations: map[string]string{
+			"commandType": "main",
+		},
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validFormat(opts.Output); err != nil {
+				return err
+			}
+			if (opts.Filename == "") == (len(args) == 0) {
+				return fmt.Errorf("either a Pipeline name or a file with --filename must be given")
+			}
+
+			var pipeline *v1.Pipeline
+			var err error
+			if opts.Filename != "" {
+				pipeline, err = parsePipeline(opts.Filename)
+			} else {
+				var cs *cli.Clients
+				if cs, err = p.Clients(); err != nil {
+					return err
+				}
+				pipeline, err = pipelinepkg.GetPipeline(pipelineGroupResource, cs, args[0], p.Namespace())
+			}
+			if err != nil {
+				return err
+			}
+
+			return Render(cmd.OutOrStdout(), NewGraph(pipeline.Name, &pipeline.Spec), opts.Output)
+		},
+	}
+	c.Flags().StringVarP(&opts.Filename, "filename", "f", "", "local or remote file containing the Pipeline")
+	c.Flags().StringVarP(&opts.Output, "output", "o", opts.Output, "output format, one of "+strings.Join(formats, ", "))
+
+	return c
+}
+
+func parsePipeline(location string) (*v1.Pipeline, error) {
+	httpClient := http.Client{Timeout: 10 * time.Second}
+	b, err := file.LoadFileContent(httpClient, location, file.IsYamlFile(), fmt.Errorf("invalid file format for %s: .yaml or .yml file extension and format required", location))
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]interface{}{}
+	if err := yaml.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	if m["kind"] != "Pipeline" {
+		return nil, fmt.Errorf("%s does not contain a Pipeline", location)
+	}
+
+	pipeline := &v1.Pipeline{}
+	switch m["apiVersion"] {
+	case "tekton.dev/v1":
+		if err := yaml.UnmarshalStrict(b, pipeline); err != nil {
+			return nil, err
+		}
+	case "tekton.dev/v1beta1":
+		pipelineV1beta1 := &v1beta1.Pipeline{}
+		if err := yaml.UnmarshalStrict(b, pipelineV1beta1); err != nil {
+			return nil, err
+		}
+		if err := pipelineV1beta1.ConvertTo(context.Background(), pipeline); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported Pipeline version %v", m["apiVersion"])
+	}
+	return pipeline, nil
+}
+
+// NewGraph returns the execution DAG of the pipeline spec.
+func NewGraph(name string, spec *v1.PipelineSpec) *Graph {
+	g := &Graph{Name: name}
+	levels := TaskLevels(spec.Tasks)
+	dependents := map[string]bool{}
+	for _, pt := range spec.Tasks {
+		g.Nodes = append(g.Nodes, newNode(pt, levels[pt.Name], false))
+		for _, e := range taskEdges(pt) {
+			dependents[e.From] = true
+			g.Edges = append(g.Edges, e)
+		}
+	}
+	sort.SliceStable(g.Nodes, func(i, j int) bool {
+		return g.Nodes[i].Level < g.Nodes[j].Level
+	})
+
+	// the finally tasks run once all the tasks without dependents completed
+	leaves := []string{}
+	for _, pt := range spec.Tasks {
+		if !dependents[pt.Name] {
+			leaves = append(leaves, pt.Name)
+		}
+	}
+	for _, pt := range spec.Finally {
+		g.Nodes = append(g.Nodes, newNode(pt, 0, true))
+		edges := map[string]*Edge{}
+		for _, e := range taskEdges(pt) {
+			e.Finally = true
+			edges[e.From] = e
+		}
+		for _, leaf := range leaves {
+			if _, ok := edges[leaf]; !ok {
+				edges[leaf] = &Edge{From: leaf, To: pt.Name, Finally: true}
+			}
+		}
+		for _, e := range sortedEdges(edges) {
+			g.Edges = append(g.Edges, e)
+		}
+	}
+	return g
+}
+
+// Node returns the node of the task.
+func (g *Graph) Node(name string) *Node {
+	for _, n := range g.Nodes {
+		if n.Name == name {
+			return n
+		}
+	}
+	return nil
+}
+
+func newNode(pt v1.PipelineTask, level int, finally bool) *Node {
+	n := &Node{Name: pt.Name, DisplayName: pt.DisplayName, Ref: taskRef(pt), Level: level, Finally: finally}
+	for _, we := range pt.When {
+		if we.CEL != "" {
+			n.When = append(n.When, we.CEL)
+			continue
+		}
+		n.When = append(n.When, fmt.Sprintf("%s %s [%s]", we.Input, we.Operator, strings.Join(we.Values, ", ")))
+	}
+	return n
+}
+
+// taskEdges returns the dependencies of the task on the other tasks, from
+// runAfter and from the results of the other tasks it uses.
+func taskEdges(pt v1.PipelineTask) []*Edge {
+	edges := map[string]*Edge{}
+	edge := func(from string) *Edge {
+		e, ok := edges[from]
+		if !ok {
+			e = &Edge{From: from, To: pt.Name}
+			edges[from] = e
+		}
+		return e
+	}
+	for _, name := range pt.RunAfter {
+		edge(name).RunAfter = true
+	}
+	for _, ref := range v1.PipelineTaskResultRefs(&pt) {
+		e := edge(ref.PipelineTask)
+		if !slices.Contains(e.Results, ref.Result) {
+			e.Results = append(e.Results, ref.Result)
+		}
+	}
+	return sortedEdges(edges)
+}
+
+func sortedEdges(edges map[string]*Edge) []*Edge {
+	sorted := make([]*Edge, 0, len(edges))
+	for _, e := range edges {
+		sort.Strings(e.Results)
+		sorted = append(sorted, e)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].From < sorted[j].From
+	})
+	return sorted
+}
+
+func taskRef(pt v1.PipelineTask) string {
+	switch {
+	case pt.TaskRef != nil && pt.TaskRef.Resolver != "":
+		return fmt.Sprintf("%s resolver", pt.TaskRef.Resolver)
+	case pt.TaskRef != nil && pt.TaskRef.Kind != "" && pt.TaskRef.Kind != v1.NamespacedTaskKind:
+		return fmt.Sprintf("%s %s", pt.TaskRef.Kind, pt.TaskRef.Name)
+	case pt.TaskRef != nil:
+		return pt.TaskRef.Name
+	case pt.PipelineRef != nil || pt.PipelineSpec != nil:
+		return "pipeline"
+	}
+	return "embedded"
+}
+
+// TaskLevels returns the level of each task in the DAG of the pipeline, the
+// tasks without dependencies are at level 0 and the other ones are one level
+// below their deepest dependency.
+func TaskLevels(tasks []v1.PipelineTask) map[string]int {
+	deps := v1.PipelineTaskList(tasks).Deps()
+	levels := map[string]int{}
+	var level func(name string, visiting map[string]bool) int
+	level = func(name string, visiting map[string]bool) int {
+		if l, ok := levels[name]; ok {
+			return l
+		}
+		if visiting[name] {
+			// invalid pipelines with cycles are rejected by the controller
+			return 0
+		}
+		visiting[name] = true
+		l := 0
+		for _, dep := range deps[name] {
+			if dl := level(dep, visiting) + 1; dl > l {
+				l = dl
+			}
+		}
+		levels[name] = l
+		return l
+	}
+	for _, t := range tasks {
+		level(t.Name, map[string]bool{})
+	}
+	return levels
+}
@@ -0,0 +1,91 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+)
+
+func testPipelineSpec() *v1.PipelineSpec {
+	return &v1.PipelineSpec{
+		Tasks: []v1.PipelineTask{{
+			Name:    "fetch",
+			TaskRef: &v1.TaskRef{Name: "git-clone"},
+		}, {
+			Name:     "build",
+			TaskRef:  &v1.TaskRef{Name: "buildah"},
+			RunAfter: []string{"fetch"},
+			Params: v1.Params{{
+				Name:  "revision",
+				Value: *v1.NewStructuredValues("$(tasks.fetch.results.commit)"),
+			}},
+		}, {
+			Name:     "lint",
+			TaskRef:  &v1.TaskRef{ResolverRef: v1.ResolverRef{Resolver: "hub"}},
+			RunAfter: []string{"fetch"},
+			When:     v1.WhenExpressions{{CEL: "'$(params.lint)' == 'true'"}},
+		}},
+		Finally: []v1.PipelineTask{{
+			Name:     "notify",
+			TaskSpec: &v1.EmbeddedTask{},
+		}},
+	}
+}
+
+func TestTaskLevels(t *testing.T) {
+	tests := []struct {
+		name  string
+		tasks []v1.PipelineTask
+		want  map[string]int
+	}{{
+		name:  "pipeline",
+		tasks: testPipelineSpec().Tasks,
+		want:  map[string]int{"fetch": 0, "build": 1, "lint": 1},
+	}, {
+		name: "deepest dependency",
+		tasks: []v1.PipelineTask{
+			{Name: "a"},
+			{Name: "b", RunAfter: []string{"a"}},
+			{Name: "c", RunAfter: []string{"a", "b"}},
+		},
+		want: map[string]int{"a": 0, "b": 1, "c": 2},
+	}, {
+		name: "cycle",
+		tasks: []v1.PipelineTask{
+			{Name: "a", RunAfter: []string{"b"}},
+			{Name: "b", RunAfter: []string{"a"}},
+		},
+		want: map[string]int{"a": 2, "b": 1},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if d := cmp.Diff(tt.want, TaskLevels(tt.tasks)); d != "" {
+				t.Errorf("TaskLevels() mismatch (-want +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestNewGraph(t *testing.T) {
+	g := NewGraph("release", testPipelineSpec())
+
+	want := &Graph{
+		Name: "release",
+		Nodes: []*Node{
+			{Name: "fetch", Ref: "git-clone"},
+			{Name: "build", Ref: "buildah", Level: 1},
+			{Name: "lint", Ref: "hub resolver", Level: 1, When: []string{"'$(params.lint)' == 'true'"}},
+			{Name: "notify", Ref: "embedded", Finally: true},
+		},
+		Edges: []*Edge{
+			{From: "fetch", To: "build", RunAfter: true, Results: []string{"commit"}},
+			{From: "fetch", To: "lint", RunAfter: true},
+			{From: "build", To: "notify", Finally: true},
+			{From: "lint", To: "notify", Finally: true},
+		},
+	}
+	if d := cmp.Diff(want, g); d != "" {
+		t.Errorf("NewGraph() mismatch (-want +got):\n%s", d)
+	}
+}
@@ -0,0 +1,291 @@
+package pipeline
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/fatih/color"
+)
+
+// The output formats of the graph.
+const (
+	FormatDot     = "dot"
+	FormatMermaid = "mermaid"
+	FormatASCII   = "ascii"
+)
+
+var formats = []string{FormatDot, FormatMermaid, FormatASCII}
+
+// outcomeColors are the fill and border colors of the tasks by outcome.
+var outcomeColors = map[string][2]string{
+	OutcomePending:   {"#ffffff", "#9e9e9e"},
+	OutcomeRunning:   {"#fff9c4", "#f9a825"},
+	OutcomeSucceeded: {"#c8e6c9", "#2e7d32"},
+	OutcomeFailed:    {"#ffcdd2", "#c62828"},
+	OutcomeCancelled: {"#e1bee7", "#6a1b9a"},
+	OutcomeSkipped:   {"#eeeeee", "#757575"},
+}
+
+var outcomeTextColors = map[string]color.Attribute{
+	OutcomeRunning:   color.FgYellow,
+	OutcomeSucceeded: color.FgGreen,
+	OutcomeFailed:    color.FgRed,
+	OutcomeCancelled: color.FgMagenta,
+	OutcomeSkipped:   color.FgHiBlack,
+}
+
+// mermaidKeywords can not be used as node ids in a Mermaid flowchart.
+var mermaidKeywords = map[string]bool{
+	"end": true, "graph": true, "flowchart": true, "subgraph": true, "class": true,
+	"classDef": true, "style": true, "click": true, "linkStyle": true, "direction": true,
+}
+
+func validFormat(format string) error {
+	if slices.Contains(formats, format) {
+		return nil
+	}
+	return fmt.Errorf("invalid output format %q, must be one of %s", format, strings.Join(formats, ", "))
+}
+
+// Render writes the graph in the format.
+func Render(out io.Writer, g *Graph, format string) error {
+	switch format {
+	case FormatDot:
+		return renderDot(out, g)
+	case FormatMermaid:
+		return renderMermaid(out, g)
+	case FormatASCII:
+		return renderASCII(out, g)
+	}
+	return validFormat(format)
+}
+
+// label returns the lines of the label of the node.
+func (n *Node) label() []string {
+	lines := []string{n.Name}
+	if n.DisplayName != "" {
+		lines[0] = fmt.Sprintf("%s (%s)", n.DisplayName, n.Name)
+	}
+	lines = append(lines, n.Ref)
+	for _, w := range n.When {
+		lines = append(lines, "when "+w)
+	}
+	return lines
+}
+
+func (e *Edge) label() string {
+	return strings.Join(e.Results, ", ")
+}
+
+func renderDot(out io.Writer, g *Graph) error {
+	quote := func(s string) string {
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+	}
+	node := func(n *Node) string {
+		lines := n.label()
+		for i := range lines {
+			lines[i] = strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(lines[i])
+		}
+		attrs := fmt.Sprintf(`label="%s"`, strings.Join(lines, `\n`))
+		if n.Outcome != "" {
+			c := outcomeColors[n.Outcome]
+			attrs += fmt.Sprintf(`, fillcolor=%q, color=%q, tooltip=%q`, c[0], c[1], n.Outcome)
+		}
+		if len(n.When) > 0 || n.Outcome == OutcomeSkipped {
+			attrs += `, style="rounded,filled,dashed"`
+		}
+		return fmt.Sprintf("%s [%s];", quote(n.Name), attrs)
+	}
+
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "digraph %s {\n", quote(g.Name))
+	fmt.Fprintln(b, "  rankdir=LR;")
+	fmt.Fprintln(b, `  node [shape=box, style="rounded,filled", fillcolor="#ffffff", fontname="Helvetica"];`)
+	fmt.Fprintln(b, `  edge [fontname="Helvetica", fontsize=10];`)
+	finally := []*Node{}
+	for _, n := range g.Nodes {
+		if n.Finally {
+			finally = append(finally, n)
+			continue
+		}
+		fmt.Fprintf(b, "  %s\n", node(n))
+	}
+	if len(finally) > 0 {
+		fmt.Fprintln(b, "  subgraph cluster_finally {")
+		fmt.Fprintln(b, `    label="finally";`)
+		fmt.Fprintln(b, "    style=dashed;")
+		for _, n := range finally {
+			fmt.Fprintf(b, "    %s\n", node(n))
+		}
+		fmt.Fprintln(b, "  }")
+	}
+	for _, e := range g.Edges {
+		attrs := []string{}
+		if label := e.label(); label != "" {
+			attrs = append(attrs, "label="+quote(label))
+		}
+		if e.Finally && len(e.Results) == 0 {
+			attrs = append(attrs, "style=dotted")
+		}
+		if len(attrs) == 0 {
+			fmt.Fprintf(b, "  %s -> %s;\n", quote(e.From), quote(e.To))
+			continue
+		}
+		fmt.Fprintf(b, "  %s -> %s [%s];\n", quote(e.From), quote(e.To), strings.Join(attrs, ", "))
+	}
+	fmt.Fprintln(b, "}")
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+func renderMermaid(out io.Writer, g *Graph) error {
+	id := func(name string) string {
+		if mermaidKeywords[name] {
+			return "task_" + name
+		}
+		return name
+	}
+	node := func(n *Node) string {
+		lines := n.label()
+		for i := range lines {
+			lines[i] = strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;").Replace(lines[i])
+		}
+		return fmt.Sprintf(`%s["%s"]`, id(n.Name), strings.Join(lines, "<br/>"))
+	}
+
+	b := &strings.Builder{}
+	fmt.Fprintln(b, "flowchart LR")
+	finally := []*Node{}
+	outcomes := map[string][]string{}
+	for _, n := range g.Nodes {
+		if n.Outcome != "" {
+			outcomes[n.Outcome] = append(outcomes[n.Outcome], id(n.Name))
+		}
+		if n.Finally {
+			finally = append(finally, n)
+			continue
+		}
+		fmt.Fprintf(b, "  %s\n", node(n))
+	}
+	if len(finally) > 0 {
+		fmt.Fprintln(b, "  subgraph finally")
+		for _, n := range finally {
+			fmt.Fprintf(b, "    %s\n", node(n))
+		}
+		fmt.Fprintln(b, "  end")
+	}
+	for _, e := range g.Edges {
+		arrow := "-->"
+		if e.Finally && len(e.Results) == 0 {
+			arrow = "-.->"
+		}
+		if label := e.label(); label != "" {
+			fmt.Fprintf(b, "  %s -- \"%s\" %s %s\n", id(e.From), label, arrow, id(e.To))
+			continue
+		}
+		fmt.Fprintf(b, "  %s %s %s\n", id(e.From), arrow, id(e.To))
+	}
+	for _, outcome := range []string{OutcomePending, OutcomeRunning, OutcomeSucceeded, OutcomeFailed, OutcomeCancelled, OutcomeSkipped} {
+		if len(outcomes[outcome]) == 0 {
+			continue
+		}
+		c := outcomeColors[outcome]
+		fmt.Fprintf(b, "  classDef %s fill:%s,stroke:%s\n", outcome, c[0], c[1])
+		fmt.Fprintf(b, "  class %s %s\n", strings.Join(outcomes[outcome], ","), outcome)
+	}
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+// renderASCII writes the tasks by level, with the tasks they depend on and
+// their when expressions.
+func renderASCII(out io.Writer, g *Graph) error {
+	deps := map[string][]string{}
+	for _, e := range g.Edges {
+		if e.Finally && len(e.Results) == 0 {
+			continue
+		}
+		dep := e.From
+		if len(e.Results) > 0 {
+			dep = fmt.Sprintf("%s (%s)", e.From, e.label())
+		}
+		deps[e.To] = append(deps[e.To], dep)
+	}
+	hasOutcome, hasWhen := false, false
+	for _, n := range g.Nodes {
+		hasOutcome = hasOutcome || n.Outcome != ""
+		hasWhen = hasWhen || len(n.When) > 0
+	}
+
+	b := &strings.Builder{}
+	w := tabwriter.NewWriter(b, 0, 5, 3, ' ', 0)
+	header := []string{"STAGE", "TASK", "REF", "AFTER"}
+	if hasOutcome {
+		header = append(header, "STATUS")
+	}
+	if hasWhen {
+		header = append(header, "WHEN")
+	}
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	level, finally := -1, false
+	outcomes := []string{}
+	for _, n := range g.Nodes {
+		stage := ""
+		switch {
+		case n.Finally && !finally:
+			finally = true
+			stage = "finally"
+		case !n.Finally && n.Level != level:
+			level = n.Level
+			stage = fmt.Sprintf("%d", level+1)
+		}
+		after := "---"
+		if len(deps[n.Name]) > 0 {
+			after = "<- " + strings.Join(deps[n.Name], ", ")
+		}
+		columns := []string{stage, n.Name, n.Ref, after}
+		if hasOutcome {
+			columns = append(columns, n.Outcome)
+			outcomes = append(outcomes, n.Outcome)
+		}
+		if hasWhen {
+			// the cells of a column are only aligned when the column is
+			// not missing from the rows between them
+			columns = append(columns, strings.Join(n.When, " && "))
+		}
+		fmt.Fprintln(w, strings.Join(columns, "\t"))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(out, colorOutcomes(b.String(), outcomes))
+	return err
+}
+
+// colorOutcomes colors the outcomes of the STATUS column of the aligned
+// table, the escape sequences would break the alignment of the cells if they
+// were added before.
+func colorOutcomes(table string, outcomes []string) string {
+	lines := strings.SplitAfter(table, "\n")
+	column := strings.Index(lines[0], "STATUS")
+	if column < 0 {
+		return table
+	}
+	for i, outcome := range outcomes {
+		c, ok := outcomeTextColors[outcome]
+		if !ok || i+1 >= len(lines) {
+			continue
+		}
+		// the header is ASCII, the column is counted in runes in the rows
+		line := []rune(lines[i+1])
+		end := column + len(outcome)
+		if len(line) < end || string(line[column:end]) != outcome {
+			continue
+		}
+		lines[i+1] = string(line[:column]) + color.New(c).Sprint(outcome) + string(line[end:])
+	}
+	return strings.Join(lines, "")
+}
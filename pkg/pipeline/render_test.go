@@ -0,0 +1,95 @@
+package pipeline
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRenderASCIIColorsAligned(t *testing.T) {
+	noColor := color.NoColor
+	defer func() { color.NoColor = noColor }()
+
+	g := NewGraph("release", testPipelineSpec())
+	g.Node("fetch").Outcome = OutcomeSucceeded
+	g.Node("build").Outcome = OutcomeFailed
+	g.Node("lint").Outcome = OutcomeSkipped
+	g.Node("notify").Outcome = OutcomePending
+	render := func(disabled bool) string {
+		color.NoColor = disabled
+		out := &bytes.Buffer{}
+		if err := Render(out, g, FormatASCII); err != nil {
+			t.Fatal(err)
+		}
+		return out.String()
+	}
+
+	plain, colored := render(true), render(false)
+	if !strings.Contains(colored, color.New(color.FgRed).Sprint(OutcomeFailed)+"      ") {
+		t.Errorf("failed not colored:\n%s", colored)
+	}
+	// the table is the same as without colors once the escape sequences are
+	// removed
+	stripped := regexp.MustCompile("\x1b\\[[0-9;]*m").ReplaceAllString(colored, "")
+	if d := cmp.Diff(plain, stripped); d != "" {
+		t.Errorf("colored table not aligned (-want +got):\n%s", d)
+	}
+}
+
+func TestRenderASCII(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = true
+	defer func() { color.NoColor = noColor }()
+
+	out := &bytes.Buffer{}
+	if err := Render(out, NewGraph("release", testPipelineSpec()), FormatASCII); err != nil {
+		t.Fatal(err)
+	}
+	want := "STAGE     TASK     REF            AFTER               WHEN\n" +
+		"1         fetch    git-clone      ---                 \n" +
+		"2         build    buildah        <- fetch (commit)   \n" +
+		"          lint     hub resolver   <- fetch            '$(params.lint)' == 'true'\n" +
+		"finally   notify   embedded       ---                 \n"
+	if d := cmp.Diff(want, out.String()); d != "" {
+		t.Errorf("Render() mismatch (-want +got):\n%s", d)
+	}
+}
+
+func TestRenderMermaid(t *testing.T) {
+	g := NewGraph("release", testPipelineSpec())
+	g.Node("fetch").Outcome = OutcomeSucceeded
+
+	out := &bytes.Buffer{}
+	if err := Render(out, g, FormatMermaid); err != nil {
+		t.Fatal(err)
+	}
+	want := `flowchart LR
+  fetch["fetch<br/>git-clone"]
+  build["build<br/>buildah"]
+  lint["lint<br/>hub resolver<br/>when '$(params.lint)' == 'true'"]
+  subgraph finally
+    notify["notify<br/>embedded"]
+  end
+  fetch -- "commit" --> build
+  fetch --> lint
+  build -.-> notify
+  lint -.-> notify
+  classDef succeeded fill:#c8e6c9,stroke:#2e7d32
+  class fetch succeeded
+`
+	if d := cmp.Diff(want, out.String()); d != "" {
+		t.Errorf("Render() mismatch (-want +got):\n%s", d)
+	}
+}
+
+func TestRenderInvalidFormat(t *testing.T) {
+	err := Render(&bytes.Buffer{}, &Graph{}, "svg")
+	want := `invalid output format "svg", must be one of dot, mermaid, ascii`
+	if err == nil || err.Error() != want {
+		t.Errorf("Render() error = %v, want %s", err, want)
+	}
+}
@@ -0,0 +1,451 @@
+package pipelinerun
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+	tknlog "github.com/tektoncd/cli/pkg/log"
+	"github.com/tektoncd/cli/pkg/options"
+)
+
+// maxLogLines is the number of lines of logs kept for each task, logHeight
+// the number of lines shown below the task and redrawInterval the interval
+// between the redraws updating the durations.
+const (
+	maxLogLines    = 500
+	logHeight      = 12
+	redrawInterval = time.Second
+)
+
+var (
+	styleDefault = tcell.StyleDefault
+	styleDim     = tcell.StyleDefault.Dim(true)
+	styleBold    = tcell.StyleDefault.Bold(true)
+	styleError   = tcell.StyleDefault.Foreground(tcell.ColorRed)
+	styleCursor  = tcell.StyleDefault.Reverse(true)
+)
+
+var outcomeIcons = map[string]string{
+	outcomePending:   "○",
+	outcomeRunning:   "●",
+	outcomeSucceeded: "✓",
+	outcomeFailed:    "✗",
+	outcomeCancelled: "⊘",
+	outcomeSkipped:   "↷",
+}
+
+var outcomeStyles = map[string]tcell.Style{
+	outcomePending:   styleDim,
+	outcomeRunning:   tcell.StyleDefault.Foreground(tcell.ColorYellow),
+	outcomeSucceeded: tcell.StyleDefault.Foreground(tcell.ColorGreen),
+	outcomeFailed:    styleError,
+	outcomeCancelled: tcell.StyleDefault.Foreground(tcell.ColorFuchsia),
+	outcomeSkipped:   styleDim,
+}
+
+// stateUpdate is posted to the event loop of the dashboard when the state of
+// the PipelineRun has been refreshed.
+type stateUpdate struct {
+	state *runState
+	err   error
+}
+
+// taskLogs are the last lines of the logs of the TaskRuns of a task.
+type taskLogs struct {
+	lines   []logLine
+	started map[string]bool
+}
+
+type logLine struct {
+	text string
+	err  bool
+}
+
+type segment struct {
+	text  string
+	style tcell.Style
+}
+
+// line is a line of the dashboard, task is the index of the task of the line
+// or -1 when the line is not a task.
+type line struct {
+	segments []segment
+	task     int
+}
+
+// dashboard is the live view of the watch command in a terminal. The logs
+// are read until ctx is done, no event is posted once the dashboard is
+// closed.
+type dashboard struct {
+	w        *watcher
+	ctx      context.Context
+	screen   tcell.Screen
+	state    *runState
+	err      error
+	selected int
+	offset   int
+	expanded map[string]bool
+
+	mu     sync.Mutex
+	logs   map[string]*taskLogs
+	closed bool
+}
+
+func newDashboard(w *watcher) *dashboard {
+	return &dashboard{
+		w:        w,
+		expanded: map[string]bool{},
+		logs:     map[string]*taskLogs{},
+	}
+}
+
+func (d *dashboard) run(ctx context.Context) error {
+	screen, err := tcell.NewScreen()
+	if err != nil {
+		return err
+	}
+	if err := screen.Init(); err != nil {
+		return err
+	}
+	defer screen.Fini()
+	d.screen = screen
+
+	ctx, cancel := context.WithCancel(ctx)
+	d.ctx = ctx
+	// runs before screen.Fini, so that the goroutines can't post events to
+	// the finalized screen
+	defer func() {
+		cancel()
+		d.mu.Lock()
+		d.closed = true
+		d.mu.Unlock()
+	}()
+	go d.w.watch(ctx, func(s *runState, err error) {
+		d.post(tcell.NewEventInterrupt(stateUpdate{state: s, err: err}))
+	})
+	go d.redraw(ctx)
+
+	d.draw()
+	for {
+		switch ev := screen.PollEvent().(type) {
+		case nil:
+			return nil
+		case *tcell.EventResize:
+			screen.Sync()
+		case *tcell.EventInterrupt:
+			if u, ok := ev.Data().(stateUpdate); ok {
+				d.err = u.err
+				if u.state != nil {
+					d.state = u.state
+					d.followLogs()
+				}
+			}
+		case *tcell.EventKey:
+			if d.handleKey(ev) {
+				return nil
+			}
+		}
+		d.draw()
+	}
+}
+
+// post posts the event to the screen unless the dashboard is closed.
+func (d *dashboard) post(ev tcell.Event) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.closed {
+		_ = d.screen.PostEvent(ev)
+	}
+}
+
+// redraw posts an event every redrawInterval until ctx is done, so that the
+// durations of the running tasks are updated between the changes of state.
+func (d *dashboard) redraw(ctx context.Context) {
+	ticker := time.NewTicker(redrawInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.post(tcell.NewEventInterrupt(nil))
+		}
+	}
+}
+
+// handleKey handles the navigation keys, true is returned to quit.
+func (d *dashboard) handleKey(ev *tcell.EventKey) bool {
+	tasks := 0
+	if d.state != nil {
+		tasks = len(d.state.Tasks)
+	}
+	switch ev.Key() {
+	case tcell.KeyCtrlC, tcell.KeyEscape:
+		return true
+	case tcell.KeyUp:
+		d.selected--
+	case tcell.KeyDown:
+		d.selected++
+	case tcell.KeyHome:
+		d.selected = 0
+	case tcell.KeyEnd:
+		d.selected = tasks - 1
+	case tcell.KeyEnter:
+		d.toggle()
+	case tcell.KeyRune:
+		switch ev.Rune() {
+		case 'q':
+			return true
+		case 'k':
+			d.selected--
+		case 'j':
+			d.selected++
+		case ' ', 'l':
+			d.toggle()
+		}
+	}
+	d.selected = max(0, min(d.selected, tasks-1))
+	return false
+}
+
+func (d *dashboard) toggle() {
+	if d.state == nil || d.selected >= len(d.state.Tasks) {
+		return
+	}
+	name := d.state.Tasks[d.selected].Name
+	d.expanded[name] = !d.expanded[name]
+	d.followLogs()
+}
+
+// followLogs starts to read the logs of the TaskRuns of the expanded tasks
+// which are not read yet, the TaskRuns of a task are added while it runs.
+func (d *dashboard) followLogs() {
+	if d.state == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, t := range d.state.Tasks {
+		if !d.expanded[t.Name] {
+			continue
+		}
+		logs, ok := d.logs[t.Name]
+		if !ok {
+			logs = &taskLogs{started: map[string]bool{}}
+			d.logs[t.Name] = logs
+		}
+		for _, tr := range t.TaskRuns {
+			if !logs.started[tr] {
+				logs.started[tr] = true
+				go d.readLogs(d.ctx, logs, tr)
+			}
+		}
+	}
+}
+
+// readLogs follows the logs of the TaskRun until they end or ctx is done.
+func (d *dashboard) readLogs(ctx context.Context, logs *taskLogs, taskRun string) {
+	add := func(l logLine) {
+		d.mu.Lock()
+		logs.lines = append(logs.lines, l)
+		if len(logs.lines) > maxLogLines {
+			logs.lines = logs.lines[len(logs.lines)-maxLogLines:]
+		}
+		d.mu.Unlock()
+		d.post(tcell.NewEventInterrupt(nil))
+	}
+
+	reader, err := tknlog.NewReader(tknlog.LogTypeTask, &options.LogOptions{
+		Params:      d.w.p,
+		TaskrunName: taskRun,
+		Follow:      true,
+	})
+	if err != nil {
+		add(logLine{text: err.Error(), err: true})
+		return
+	}
+	logC, errC, err := reader.Read()
+	if err != nil {
+		add(logLine{text: err.Error(), err: true})
+		return
+	}
+	for logC != nil || errC != nil {
+		select {
+		case <-ctx.Done():
+			return
+		case l, ok := <-logC:
+			if !ok {
+				logC = nil
+				continue
+			}
+			if l.Log == "EOFLOG" {
+				continue
+			}
+			add(logLine{text: fmt.Sprintf("[%s] %s", l.Step, l.Log)})
+		case e, ok := <-errC:
+			if !ok {
+				errC = nil
+				continue
+			}
+			add(logLine{text: e.Error(), err: true})
+		}
+	}
+}
+
+func (d *dashboard) draw() {
+	d.screen.Clear()
+	width, height := d.screen.Size()
+	now := time.Now()
+
+	if d.state == nil {
+		drawLine(d.screen, 0, width, []segment{{fmt.Sprintf("Waiting for PipelineRun %s...", d.w.name), styleDim}})
+	} else {
+		header := []segment{
+			{"PipelineRun " + d.state.Name + "  ", styleBold},
+			{outcomeIcons[d.state.Outcome] + " " + runSummary(d.state, now), outcomeStyles[d.state.Outcome]},
+		}
+		if !d.state.completed() {
+			header = append(header, segment{"  " + elapsed(d.state.Start, nil, now), styleDim})
+		}
+		drawLine(d.screen, 0, width, header)
+	}
+	if d.err != nil {
+		drawLine(d.screen, 1, width, []segment{{d.err.Error(), styleError}})
+	}
+	drawLine(d.screen, height-1, width, []segment{{"↑/↓ select  enter show/hide logs  q quit", styleDim}})
+
+	lines := d.lines(now)
+	top, view := 2, height-3
+	if view <= 0 {
+		d.screen.Show()
+		return
+	}
+	// keep the selected task and as many lines of its logs as possible visible
+	first := 0
+	for i, l := range lines {
+		if l.task == d.selected {
+			first = i
+			break
+		}
+	}
+	last := first
+	for last+1 < len(lines) && lines[last+1].task == -1 {
+		last++
+	}
+	if last-d.offset >= view {
+		d.offset = last - view + 1
+	}
+	if first < d.offset {
+		d.offset = first
+	}
+	d.offset = max(0, min(d.offset, len(lines)-view))
+	for i := 0; i < view && d.offset+i < len(lines); i++ {
+		drawLine(d.screen, top+i, width, lines[d.offset+i].segments)
+	}
+	d.screen.Show()
+}
+
+// lines returns the lines of the tasks, with the logs of the expanded tasks
+// below them.
+func (d *dashboard) lines(now time.Time) []line {
+	if d.state == nil {
+		return nil
+	}
+	nameWidth := 0
+	for _, t := range d.state.Tasks {
+		nameWidth = max(nameWidth, 2*t.Level+runewidth.StringWidth(taskLabel(t)))
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	lines := []line{}
+	finally := false
+	for i, t := range d.state.Tasks {
+		if t.Finally && !finally {
+			finally = true
+			lines = append(lines, line{segments: []segment{{"  finally", styleDim}}, task: -2})
+		}
+		cursor, nameStyle := "  ", styleDefault
+		if i == d.selected {
+			cursor, nameStyle = "› ", styleCursor
+		}
+		label := taskLabel(t)
+		indent := strings.Repeat("  ", t.Level)
+		segments := []segment{
+			{cursor + indent, styleBold},
+			{outcomeIcons[t.Outcome] + " ", outcomeStyles[t.Outcome]},
+			{label, nameStyle},
+			{strings.Repeat(" ", nameWidth-len(indent)-runewidth.StringWidth(label)+2), styleDefault},
+			{fmt.Sprintf("%-10s", t.Outcome), outcomeStyles[t.Outcome]},
+		}
+		if t.Start != nil {
+			segments = append(segments, segment{fmt.Sprintf("%-9s", elapsed(t.Start, t.Completion, now)), styleDefault})
+		}
+		if t.Retries > 0 {
+			segments = append(segments, segment{fmt.Sprintf("retry %d/%d  ", t.Attempts, t.Retries), styleDefault})
+		}
+		if t.Step != "" {
+			segments = append(segments, segment{"step " + t.Step + "  ", styleDefault})
+		}
+		if reason := reasonSuffix(t.Outcome, t.Reason); reason != "" {
+			segments = append(segments, segment{strings.Trim(reason, " ()"), styleDim})
+		}
+		lines = append(lines, line{segments: segments, task: i})
+
+		if d.expanded[t.Name] {
+			lines = append(lines, d.logLines(t)...)
+		}
+	}
+	return lines
+}
+
+func (d *dashboard) logLines(t *taskState) []line {
+	indent := segment{"      │ ", styleDim}
+	logs := d.logs[t.Name]
+	switch {
+	case len(t.TaskRuns) == 0:
+		return []line{{segments: []segment{indent, {"no TaskRun yet", styleDim}}, task: -1}}
+	case logs == nil || len(logs.lines) == 0:
+		return []line{{segments: []segment{indent, {"waiting for logs...", styleDim}}, task: -1}}
+	}
+	lines := []line{}
+	for _, l := range logs.lines[max(0, len(logs.lines)-logHeight):] {
+		style := styleDefault
+		if l.err {
+			style = styleError
+		}
+		lines = append(lines, line{segments: []segment{indent, {l.text, style}}, task: -1})
+	}
+	return lines
+}
+
+// taskLabel is the name of the task with its display name.
+func taskLabel(t *taskState) string {
+	label := t.Name
+	if t.DisplayName != "" {
+		label += " (" + t.DisplayName + ")"
+	}
+	return label
+}
+
+func drawLine(screen tcell.Screen, y, width int, segments []segment) {
+	x := 0
+	for _, s := range segments {
+		for _, r := range s.text {
+			if r == '\t' {
+				r = ' '
+			}
+			w := runewidth.RuneWidth(r)
+			if x+w > width {
+				return
+			}
+			screen.SetContent(x, y, r, nil, s.style)
+			x += w
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package pipelinerun
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+)
+
+// skipPlan is the tasks of a PipelineRun skipped by the new PipelineRun, and
+// the tasks which succeeded or may have succeeded but are rerun with the
+// reason.
+type skipPlan struct {
+	skipped        []string
+	rerun          map[string]string
+	droppedResults []string
+}
+
+func (s *skipPlan) print(out io.Writer, rerun *v1.PipelineRun) {
+	if len(s.skipped) == 0 {
+		fmt.Fprintf(out, "No succeeded task can be skipped, all the tasks are rerun\n")
+	} else {
+		fmt.Fprintf(out, "Skipping the succeeded tasks %s\n", strings.Join(s.skipped, ", "))
+	}
+	names := make([]string, 0, len(s.rerun))
+	for name := range s.rerun {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(out, "Rerunning the task %s: %s\n", name, s.rerun[name])
+	}
+	for _, name := range s.droppedResults {
+		fmt.Fprintf(out, "Warning: the pipeline result %s is dropped, it uses the results of skipped tasks\n", name)
+	}
+	if len(s.skipped) > 0 {
+		for _, w := range rerun.Spec.Workspaces {
+			if w.VolumeClaimTemplate != nil {
+				fmt.Fprintf(out, "Warning: the workspace %s is bound to a volumeClaimTemplate, the files of the skipped tasks are not in its new volume\n", w.Name)
+			}
+		}
+	}
+}
+
+// The references to the status and the reason of a task in the finally
+// tasks, the status of the skipped tasks is Succeeded.
+const (
+	taskStatusSucceeded = "Succeeded"
+
+	taskStatusPattern = `\$\(tasks\.%s\.status\)`
+	taskReasonPattern = `\$\(tasks\.%s\.reason\)`
+)
+
+// resultRefPattern matches the references to the results of a task, with the
+// name of the result and the element of an array or the key of an object.
+const resultRefPattern = `\$\(tasks\.%s\.results\.([a-zA-Z0-9_-]+)((?:\[[^\]]*\])|(?:\.[a-zA-Z0-9_.-]+))?\)`
+
+// skipSucceededTasks updates the spec of the new PipelineRun to skip the
+// tasks of the PipelineRun which succeeded: its pipeline spec is embedded
+// without them and their results used by the other tasks are pinned as
+// params.
+func skipSucceededTasks(pr *v1.PipelineRun, trs map[string]*v1.PipelineRunTaskRunStatus, rerun *v1.PipelineRun) (*skipPlan, error) {
+	state := newRunState(pr, trs)
+	switch state.Outcome {
+	case outcomeSucceeded:
+		return nil, fmt.Errorf("PipelineRun %s succeeded, there are no failed tasks to rerun", pr.Name)
+	case outcomePending, outcomeRunning:
+		return nil, fmt.Errorf("PipelineRun %s has not completed yet", pr.Name)
+	}
+	if pr.Status.PipelineSpec == nil {
+		return nil, fmt.Errorf("the pipeline of PipelineRun %s was not resolved, its tasks cannot be skipped", pr.Name)
+	}
+	spec := pr.Status.PipelineSpec.DeepCopy()
+	b, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	declared := map[string]bool{}
+	for _, p := range spec.Params {
+		declared[p.Name] = true
+	}
+	plan := &skipPlan{rerun: map[string]string{}}
+	skipped := map[string]bool{}
+	reasons := map[string]string{}
+	pinned := map[string]map[string]v1.ParamValue{}
+	for _, t := range state.Tasks {
+		if t.Finally {
+			continue
+		}
+		pt := pipelineTask(spec, t.Name)
+		if pt == nil {
+			continue
+		}
+		// the CustomRuns of the custom tasks are not read, whether they
+		// succeeded is unknown
+		if len(t.TaskRuns) == 0 && isCustomTask(pt) {
+			plan.rerun[t.Name] = "it is a custom task, it has no TaskRun"
+			continue
+		}
+		if t.Outcome != outcomeSucceeded {
+			continue
+		}
+		switch {
+		case pt.IsMatrixed():
+			plan.rerun[t.Name] = "it has a matrix"
+		case len(t.TaskRuns) == 0:
+			plan.rerun[t.Name] = "it has no TaskRun"
+		case len(t.TaskRuns) > 1:
+			plan.rerun[t.Name] = fmt.Sprintf("it has %d TaskRuns", len(t.TaskRuns))
+		}
+		if _, ok := plan.rerun[t.Name]; ok {
+			continue
+		}
+		results := map[string]v1.ParamValue{}
+		for _, r := range trs[t.TaskRuns[0]].Status.Results {
+			results[r.Name] = r.Value
+		}
+		used := map[string]v1.ParamValue{}
+		re := regexp.MustCompile(fmt.Sprintf(resultRefPattern, regexp.QuoteMeta(t.Name)))
+		for _, m := range re.FindAllStringSubmatch(string(b), -1) {
+			value, ok := results[m[1]]
+			if !ok {
+				plan.rerun[t.Name] = fmt.Sprintf("its result %s was not produced", m[1])
+				break
+			}
+			if declared[resultParam(t.Name, m[1])] {
+				plan.rerun[t.Name] = fmt.Sprintf("the param %s of its result %s already exists", resultParam(t.Name, m[1]), m[1])
+				break
+			}
+			used[m[1]] = value
+		}
+		if _, ok := plan.rerun[t.Name]; ok {
+			continue
+		}
+		skipped[t.Name], reasons[t.Name], pinned[t.Name] = true, t.Reason, used
+		plan.skipped = append(plan.skipped, t.Name)
+	}
+	if len(plan.skipped) == 0 {
+		return plan, nil
+	}
+	if len(plan.skipped) == len(spec.Tasks) {
+		return nil, fmt.Errorf("all the tasks of PipelineRun %s succeeded, only its finally tasks can be rerun with a new PipelineRun", pr.Name)
+	}
+
+	tasks := []v1.PipelineTask{}
+	for _, pt := range spec.Tasks {
+		if skipped[pt.Name] {
+			continue
+		}
+		// the tasks using the results of the skipped tasks still run after
+		// the dependencies of the skipped tasks
+		runAfter := append([]string{}, pt.RunAfter...)
+		b, err := json.Marshal(pt)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range plan.skipped {
+			if usesTasks(string(b), map[string]bool{name: true}) {
+				runAfter = append(runAfter, name)
+			}
+		}
+		pt.RunAfter = remainingDependencies(spec, runAfter, skipped, map[string]bool{})
+		tasks = append(tasks, pt)
+	}
+	spec.Tasks = tasks
+	results := []v1.PipelineResult{}
+	for _, r := range spec.Results {
+		rb, err := json.Marshal(r)
+		if err != nil {
+			return nil, err
+		}
+		if usesTasks(string(rb), skipped) {
+			plan.droppedResults = append(plan.droppedResults, r.Name)
+			continue
+		}
+		results = append(results, r)
+	}
+	spec.Results = results
+
+	// the references are replaced in the JSON of the spec, so that all the
+	// fields which can use the results are covered
+	b, err = json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+	replaced := string(b)
+	for _, name := range plan.skipped {
+		quoted := regexp.QuoteMeta(name)
+		replaced = regexp.MustCompile(fmt.Sprintf(resultRefPattern, quoted)).ReplaceAllString(replaced, fmt.Sprintf("$$(params.%s-$1$2)", name))
+		replaced = regexp.MustCompile(fmt.Sprintf(taskStatusPattern, quoted)).ReplaceAllLiteralString(replaced, taskStatusSucceeded)
+		replaced = regexp.MustCompile(fmt.Sprintf(taskReasonPattern, quoted)).ReplaceAllLiteralString(replaced, reasons[name])
+	}
+	spec = &v1.PipelineSpec{}
+	if err := json.Unmarshal([]byte(replaced), spec); err != nil {
+		return nil, err
+	}
+
+	for _, name := range plan.skipped {
+		results := make([]string, 0, len(pinned[name]))
+		for result := range pinned[name] {
+			results = append(results, result)
+		}
+		sort.Strings(results)
+		for _, result := range results {
+			value := pinned[name][result]
+			ps := v1.ParamSpec{
+				Name:        resultParam(name, result),
+				Type:        value.Type,
+				Description: fmt.Sprintf("result %s of the skipped task %s", result, name),
+			}
+			if value.Type == v1.ParamTypeObject {
+				ps.Properties = map[string]v1.PropertySpec{}
+				for k := range value.ObjectVal {
+					ps.Properties[k] = v1.PropertySpec{Type: v1.ParamTypeString}
+				}
+			}
+			spec.Params = append(spec.Params, ps)
+			rerun.Spec.Params = append(rerun.Spec.Params, v1.Param{Name: ps.Name, Value: value})
+		}
+	}
+
+	rerun.Spec.PipelineRef = nil
+	rerun.Spec.PipelineSpec = spec
+	taskRunSpecs := []v1.PipelineTaskRunSpec{}
+	for _, s := range rerun.Spec.TaskRunSpecs {
+		if !skipped[s.PipelineTaskName] {
+			taskRunSpecs = append(taskRunSpecs, s)
+		}
+	}
+	rerun.Spec.TaskRunSpecs = taskRunSpecs
+	return plan, nil
+}
+
+func pipelineTask(spec *v1.PipelineSpec, name string) *v1.PipelineTask {
+	for i := range spec.Tasks {
+		if spec.Tasks[i].Name == name {
+			return &spec.Tasks[i]
+		}
+	}
+	return nil
+}
+
+// resultParam returns the name of the param pinning the result of a skipped
+// task.
+func resultParam(task, result string) string {
+	return task + "-" + result
+}
+
+// remainingDependencies returns the tasks to run after, the skipped tasks
+// are replaced by their own dependencies so that the order of the tasks is
+// kept.
+func remainingDependencies(spec *v1.PipelineSpec, runAfter []string, skipped, seen map[string]bool) []string {
+	deps := []string{}
+	for _, name := range runAfter {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		if !skipped[name] {
+			deps = append(deps, name)
+			continue
+		}
+		pt := pipelineTask(spec, name)
+		if pt == nil {
+			continue
+		}
+		parents := append([]string{}, pt.RunAfter...)
+		b, err := json.Marshal(pt)
+		if err == nil {
+			for _, t := range spec.Tasks {
+				if usesTasks(string(b), map[string]bool{t.Name: true}) {
+					parents = append(parents, t.Name)
+				}
+			}
+		}
+		deps = append(deps, remainingDependencies(spec, parents, skipped, seen)...)
+	}
+	if len(deps) == 0 {
+		return nil
+	}
+	return deps
+}
+
+// usesTasks returns true when the JSON uses the results of one of the tasks.
+func usesTasks(b string, tasks map[string]bool) bool {
+	for name := range tasks {
+		if regexp.MustCompile(fmt.Sprintf(resultRefPattern, regexp.QuoteMeta(name))).MatchString(b) {
+			return true
+		}
+	}
+	return false
+}
+
+// isCustomTask returns whether the pipeline task runs a CustomRun.
+func isCustomTask(pt *v1.PipelineTask) bool {
+	return (pt.TaskRef != nil && pt.TaskRef.IsCustomTask()) || (pt.TaskSpec != nil && pt.TaskSpec.IsCustomTask())
+}
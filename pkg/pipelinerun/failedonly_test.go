@@ -0,0 +1,146 @@
+package pipelinerun
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	corev1 "k8s.io/api/core/v1"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+)
+
+func succeeded(status corev1.ConditionStatus, reason string) duckv1.Status {
+	return duckv1.Status{Conditions: duckv1.Conditions{{Type: apis.ConditionSucceeded, Status: status, Reason: reason}}}
+}
+
+func taskRunStatus(task string, status corev1.ConditionStatus, results ...v1.TaskRunResult) *v1.PipelineRunTaskRunStatus {
+	return &v1.PipelineRunTaskRunStatus{
+		PipelineTaskName: task,
+		Status: &v1.TaskRunStatus{
+			Status:              succeeded(status, ""),
+			TaskRunStatusFields: v1.TaskRunStatusFields{Results: results},
+		},
+	}
+}
+
+func TestSkipSucceededTasks(t *testing.T) {
+	ref := func(name string) *v1.TaskRef { return &v1.TaskRef{Name: name} }
+	pr := &v1.PipelineRun{
+		Status: v1.PipelineRunStatus{
+			Status: succeeded(corev1.ConditionFalse, "Failed"),
+			PipelineRunStatusFields: v1.PipelineRunStatusFields{PipelineSpec: &v1.PipelineSpec{
+				Tasks: []v1.PipelineTask{
+					{Name: "clone", TaskRef: ref("git-clone")},
+					{Name: "scan", TaskRef: ref("scan"), Matrix: &v1.Matrix{Params: v1.Params{{Name: "dir", Value: *v1.NewStructuredValues("a", "b")}}}},
+					{Name: "lint", TaskRef: ref("lint")},
+					{Name: "approve", TaskRef: &v1.TaskRef{APIVersion: "example.dev/v1", Kind: "Approval"}},
+					{Name: "notify", TaskRef: ref("notify")},
+					{
+						Name:     "build",
+						TaskRef:  ref("build"),
+						Params:   v1.Params{{Name: "revision", Value: *v1.NewStructuredValues("$(tasks.clone.results.commit)")}},
+						RunAfter: []string{"scan", "lint", "approve"},
+					},
+					{
+						Name:    "report",
+						TaskRef: ref("report"),
+						Params:  v1.Params{{Name: "id", Value: *v1.NewStructuredValues("$(tasks.notify.results.id)")}},
+					},
+				},
+			}},
+		},
+	}
+	pr.Name = "foo"
+	commit := v1.TaskRunResult{Name: "commit", Type: v1.ResultsTypeString, Value: *v1.NewStructuredValues("abc")}
+	trs := map[string]*v1.PipelineRunTaskRunStatus{
+		"foo-clone":    taskRunStatus("clone", corev1.ConditionTrue, commit),
+		"foo-scan-0":   taskRunStatus("scan", corev1.ConditionTrue),
+		"foo-scan-1":   taskRunStatus("scan", corev1.ConditionTrue),
+		"foo-lint-a":   taskRunStatus("lint", corev1.ConditionTrue),
+		"foo-lint-b":   taskRunStatus("lint", corev1.ConditionTrue),
+		"foo-notify":   taskRunStatus("notify", corev1.ConditionTrue),
+		"foo-build":    taskRunStatus("build", corev1.ConditionFalse),
+		"foo-report-0": taskRunStatus("report", corev1.ConditionFalse),
+	}
+
+	rerun := &v1.PipelineRun{Spec: v1.PipelineRunSpec{PipelineRef: &v1.PipelineRef{Name: "ci"}}}
+	plan, err := skipSucceededTasks(pr, trs, rerun)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := cmp.Diff([]string{"clone"}, plan.skipped); d != "" {
+		t.Errorf("skipped tasks (-want +got):\n%s", d)
+	}
+	wantRerun := map[string]string{
+		"scan":    "it has a matrix",
+		"lint":    "it has 2 TaskRuns",
+		"approve": "it is a custom task, it has no TaskRun",
+		"notify":  "its result id was not produced",
+	}
+	if d := cmp.Diff(wantRerun, plan.rerun); d != "" {
+		t.Errorf("rerun tasks (-want +got):\n%s", d)
+	}
+
+	if rerun.Spec.PipelineRef != nil {
+		t.Errorf("the pipeline reference was kept")
+	}
+	var build v1.PipelineTask
+	for _, pt := range rerun.Spec.PipelineSpec.Tasks {
+		if pt.Name == "clone" {
+			t.Errorf("the skipped task clone is in the pipeline")
+		}
+		if pt.Name == "build" {
+			build = pt
+		}
+	}
+	if got := build.Params[0].Value.StringVal; got != "$(params.clone-commit)" {
+		t.Errorf("the revision of build is %s, want the param of the result", got)
+	}
+	if d := cmp.Diff(v1.Params{{Name: "clone-commit", Value: *v1.NewStructuredValues("abc")}}, rerun.Spec.Params); d != "" {
+		t.Errorf("params (-want +got):\n%s", d)
+	}
+
+	out := &bytes.Buffer{}
+	plan.print(out, rerun)
+	want := `Skipping the succeeded tasks clone
+Rerunning the task approve: it is a custom task, it has no TaskRun
+Rerunning the task lint: it has 2 TaskRuns
+Rerunning the task notify: its result id was not produced
+Rerunning the task scan: it has a matrix
+`
+	if d := cmp.Diff(want, out.String()); d != "" {
+		t.Errorf("print (-want +got):\n%s", d)
+	}
+}
+
+func TestSkipSucceededTasksCompleted(t *testing.T) {
+	tests := []struct {
+		name   string
+		status duckv1.Status
+		want   string
+	}{{
+		name:   "succeeded",
+		status: succeeded(corev1.ConditionTrue, "Succeeded"),
+		want:   "PipelineRun foo succeeded, there are no failed tasks to rerun",
+	}, {
+		name:   "running",
+		status: succeeded(corev1.ConditionUnknown, "Running"),
+		want:   "PipelineRun foo has not completed yet",
+	}, {
+		name:   "not resolved",
+		status: succeeded(corev1.ConditionFalse, "CouldntGetPipeline"),
+		want:   "the pipeline of PipelineRun foo was not resolved, its tasks cannot be skipped",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pr := &v1.PipelineRun{Status: v1.PipelineRunStatus{Status: tt.status}}
+			pr.Name = "foo"
+			_, err := skipSucceededTasks(pr, nil, &v1.PipelineRun{})
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("skipSucceededTasks() error = %v, want %s", err, tt.want)
+			}
+		})
+	}
+}
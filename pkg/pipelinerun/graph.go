@@ -0,0 +1,74 @@
+package pipelinerun
+
+import (
+	"strings"
+
+	"github.com/openshift-pipelines/opc/pkg/pipeline"
+	"github.com/spf13/cobra"
+	"github.com/tektoncd/cli/pkg/cli"
+	"github.com/tektoncd/cli/pkg/formatted"
+	pipelinerunpkg "github.com/tektoncd/cli/pkg/pipelinerun"
+)
+
+type graphOptions struct {
+	Last   bool
+	Output string
+}
+
+// GraphCommand returns the command rendering the execution DAG of the
+// pipeline of a PipelineRun, colored by the outcome of its tasks.
+func GraphCommand(p cli.Params) *cobra.Command {
+	opts := &graphOptions{Output: pipeline.FormatASCII}
+	eg := `Render the graph of the PipelineRun named 'foo' in namespace 'bar' as an image with Graphviz:
+
+    opc pipelinerun graph foo -n bar -o dot | dot -Tsvg > foo.svg
+
+Render the graph of the last PipelineRun as a Mermaid flowchart:
+
+    opc pipelinerun graph --last -o mermaid
+`
+
+	c := &cobra.Command{
+		Use:               "graph",
+		Short:             "Render the execution graph of a PipelineRun",
+		ValidArgsFunction: formatted.ParentCompletion,
+		Long: `Render the execution graph of the pipeline of a PipelineRun as a Graphviz dot graph, a Mermaid flowchart
+or as text, with the tasks colored by their outcome: succeeded, failed, cancelled, skipped, running or pending.`,
+		Example: eg,
+		Args:    cobra.MaximumNArgs(1),
+		Annotations: map[string]string{
+			"commandType": "main",
+		},
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name, err := pipelineRunName(p, args, opts.Last)
+			if err != nil {
+				return err
+			}
+			cs, err := p.Clients()
+			if err != nil {
+				return err
+			}
+			pr, err := pipelinerunpkg.GetPipelineRun(pipelineRunGroupResource, cs, name, p.Namespace())
+			if err != nil {
+				return err
+			}
+			trs, err := pipelinerunpkg.GetTaskRunsWithStatus(pr, cs, p.Namespace())
+			if err != nil {
+				return err
+			}
+
+			g := pipeline.NewGraph(pr.Name, pipelineSpec(pr))
+			for _, t := range newRunState(pr, trs).Tasks {
+				if n := g.Node(t.Name); n != nil {
+					n.Outcome = t.Outcome
+				}
+			}
+			return pipeline.Render(cmd.OutOrStdout(), g, opts.Output)
+		},
+	}
+	c.Flags().BoolVarP(&opts.Last, "last", "L", false, "render the graph of the last PipelineRun")
+	c.Flags().StringVarP(&opts.Output, "output", "o", opts.Output, "output format, one of "+strings.Join([]string{pipeline.FormatDot, pipeline.FormatMermaid, pipeline.FormatASCII}, ", "))
+
+	return c
+}
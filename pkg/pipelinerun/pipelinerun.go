@@ -0,0 +1,116 @@
+// Package pipelinerun extends the pipelinerun commands of the Tekton CLI with
+// opc specific commands.
+package pipelinerun
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/openshift-pipelines/opc/pkg/pipeline"
+	"github.com/tektoncd/cli/pkg/cli"
+	"github.com/tektoncd/cli/pkg/options"
+	pipelinerunpkg "github.com/tektoncd/cli/pkg/pipelinerun"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+)
+
+var pipelineRunGroupResource = schema.GroupVersionResource{Group: "tekton.dev", Resource: "pipelineruns"}
+
+const (
+	outcomePending   = pipeline.OutcomePending
+	outcomeRunning   = pipeline.OutcomeRunning
+	outcomeSucceeded = pipeline.OutcomeSucceeded
+	outcomeFailed    = pipeline.OutcomeFailed
+	outcomeCancelled = pipeline.OutcomeCancelled
+	outcomeSkipped   = pipeline.OutcomeSkipped
+)
+
+var cancelledReasons = []string{"Cancelled", "PipelineRunCancelled", "TaskRunCancelled", "CancelledRunningFinally", "StoppedRunningFinally"}
+
+// runOutcome returns the outcome of a PipelineRun or a TaskRun from its
+// Succeeded condition.
+func runOutcome(conditions duckv1.Conditions) (string, string) {
+	for _, c := range conditions {
+		if c.Type != apis.ConditionSucceeded {
+			continue
+		}
+		switch c.Status {
+		case corev1.ConditionTrue:
+			return outcomeSucceeded, c.Reason
+		case corev1.ConditionFalse:
+			for _, r := range cancelledReasons {
+				if c.Reason == r {
+					return outcomeCancelled, c.Reason
+				}
+			}
+			return outcomeFailed, c.Reason
+		default:
+			if c.Reason == "Pending" || c.Reason == "PipelineRunPending" {
+				return outcomePending, c.Reason
+			}
+			return outcomeRunning, c.Reason
+		}
+	}
+	return outcomePending, ""
+}
+
+// elapsed returns the duration of a run rounded to the second, up to now when
+// the run has not completed yet.
+func elapsed(start, completion *metav1.Time, now time.Time) string {
+	if start == nil || start.IsZero() {
+		return "---"
+	}
+	end := now
+	if completion != nil && !completion.IsZero() {
+		end = completion.Time
+	}
+	return end.Sub(start.Time).Round(time.Second).String()
+}
+
+// pipelineRunName returns the PipelineRun given as argument, the last one
+// created with --last, or asks to select one of the last PipelineRuns.
+func pipelineRunName(p cli.Params, args []string, last bool) (string, error) {
+	if len(args) > 0 {
+		return args[0], nil
+	}
+	cs, err := p.Clients()
+	if err != nil {
+		return "", err
+	}
+	limit := 5
+	if last {
+		limit = 1
+	}
+	prs, err := pipelinerunpkg.GetAllPipelineRuns(pipelineRunGroupResource, metav1.ListOptions{}, cs, p.Namespace(), limit, p.Time())
+	if err != nil {
+		return "", err
+	}
+	if len(prs) == 0 {
+		return "", fmt.Errorf("no PipelineRuns found in namespace %s", p.Namespace())
+	}
+	if len(prs) == 1 || last {
+		return strings.Fields(prs[0])[0], nil
+	}
+	opts := options.NewDescribeOptions(p)
+	if err := opts.Ask(options.ResourceNamePipelineRun, prs); err != nil {
+		return "", err
+	}
+	return opts.PipelineRunName, nil
+}
+
+// pipelineSpec returns the resolved spec of the Pipeline of the PipelineRun,
+// the embedded spec until it is resolved.
+func pipelineSpec(pr *v1.PipelineRun) *v1.PipelineSpec {
+	switch {
+	case pr.Status.PipelineSpec != nil:
+		return pr.Status.PipelineSpec
+	case pr.Spec.PipelineSpec != nil:
+		return pr.Spec.PipelineSpec
+	}
+	return &v1.PipelineSpec{}
+}
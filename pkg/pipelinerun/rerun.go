@@ -0,0 +1,343 @@
+package pipelinerun
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/openshift-pipelines/opc/pkg/runs"
+	"github.com/spf13/cobra"
+	"github.com/tektoncd/cli/pkg/cli"
+	"github.com/tektoncd/cli/pkg/formatted"
+	"github.com/tektoncd/cli/pkg/options"
+	"github.com/tektoncd/cli/pkg/params"
+	pipelinerunpkg "github.com/tektoncd/cli/pkg/pipelinerun"
+	taskrunpkg "github.com/tektoncd/cli/pkg/taskrun"
+	"github.com/tektoncd/cli/pkg/workspaces"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+var taskRunGroupResource = schema.GroupVersionResource{Group: "tekton.dev", Resource: "taskruns"}
+
+type rerunOptions struct {
+	Last           bool
+	FailedOnly     bool
+	Params         []string
+	Workspaces     []string
+	ServiceAccount string
+	Timeout        time.Duration
+	DryRun         bool
+	Output         string
+}
+
+func (o *rerunOptions) validate() error {
+	switch strings.ToLower(o.Output) {
+	case "", "json", "yaml", "name":
+		return nil
+	}
+	return fmt.Errorf("invalid output format %q, must be one of json, yaml or name", o.Output)
+}
+
+func (o *rerunOptions) addFlags(c *cobra.Command, kind string) {
+	c.Flags().BoolVarP(&o.Last, "last", "L", false, "rerun the last "+kind)
+	c.Flags().StringArrayVarP(&o.Params, "param", "p", []string{}, "override the param as key=value for string type, or key=value1,value2,... for array type, or key=\"key1:value1, key2:value2\" for object type")
+	c.Flags().StringArrayVarP(&o.Workspaces, "workspace", "w", []string{}, "override one or more workspaces to map to the corresponding physical volumes")
+	c.Flags().StringVarP(&o.ServiceAccount, "serviceaccount", "s", "", "override the ServiceAccount of the "+kind)
+	c.Flags().DurationVar(&o.Timeout, "timeout", 0, "override the timeout of the "+kind)
+	c.Flags().BoolVar(&o.DryRun, "dry-run", false, "preview the "+kind+" without running it")
+	c.Flags().StringVarP(&o.Output, "output", "o", "", "format of the "+kind+" (yaml, json or name)")
+}
+
+// RerunCommand returns the command creating a new PipelineRun from a
+// PipelineRun of the cluster, optionally skipping the tasks which succeeded.
+func RerunCommand(p cli.Params) *cobra.Command {
+	opts := &rerunOptions{}
+	eg := `Rerun the PipelineRun named 'foo' in namespace 'bar':
+
+    opc pipelinerun rerun foo -n bar
+
+Rerun the last PipelineRun with another value of the revision param:
+
+    opc pipelinerun rerun --last -p revision=main
+
+Rerun only the tasks of the PipelineRun 'foo' which did not succeed, printing the PipelineRun first:
+
+    opc pipelinerun rerun foo --failed-only --dry-run -o yaml
+`
+
+	c := &cobra.Command{
+		Use:               "rerun",
+		Short:             "Create a new PipelineRun from a PipelineRun",
+		ValidArgsFunction: formatted.ParentCompletion,
+		Long: `Create a new PipelineRun with the spec of a PipelineRun: its embedded pipeline spec or its pipeline reference,
+resolvers included, its params, workspaces, pod template and task run specs. The params, workspaces, ServiceAccount
+and timeout can be overridden.
+
+With --failed-only, the new PipelineRun skips the tasks which succeeded: it embeds the resolved spec of the pipeline
+without them, and the results of the skipped tasks used by the other tasks are pinned as params of the PipelineRun.
+The tasks which succeeded are rerun when their results cannot be pinned: when they have a matrix, when they did not
+run in exactly one TaskRun or when one of their results used by the other tasks was not produced. The custom tasks
+and the finally tasks are always rerun and the pipeline results using the skipped tasks are dropped. The files written by the skipped tasks are only available to the rerun tasks when the
+workspaces are bound to the same volumes, not to volumeClaimTemplates.`,
+		Example: eg,
+		Args:    cobra.MaximumNArgs(1),
+		Annotations: map[string]string{
+			"commandType": "main",
+		},
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.validate(); err != nil {
+				return err
+			}
+			if len(args) > 0 && opts.Last {
+				return fmt.Errorf("a PipelineRun name cannot be given with --last")
+			}
+			name, err := pipelineRunName(p, args, opts.Last)
+			if err != nil {
+				return err
+			}
+			cs, err := p.Clients()
+			if err != nil {
+				return err
+			}
+			pr, err := pipelinerunpkg.GetPipelineRun(pipelineRunGroupResource, cs, name, p.Namespace())
+			if err != nil {
+				return err
+			}
+
+			rerun := &v1.PipelineRun{
+				ObjectMeta: runs.RerunObjectMeta(pr.ObjectMeta),
+				Spec:       *pr.Spec.DeepCopy(),
+			}
+			rerun.Spec.Status = ""
+			spec := pipelineSpec(pr)
+			if opts.FailedOnly {
+				trs, err := pipelinerunpkg.GetTaskRunsWithStatus(pr, cs, p.Namespace())
+				if err != nil {
+					return err
+				}
+				plan, err := skipSucceededTasks(pr, trs, rerun)
+				if err != nil {
+					return err
+				}
+				plan.print(cmd.ErrOrStderr(), rerun)
+				spec = rerun.Spec.PipelineSpec
+				if spec == nil {
+					spec = pipelineSpec(pr)
+				}
+			}
+			if opts.ServiceAccount != "" {
+				rerun.Spec.TaskRunTemplate.ServiceAccountName = opts.ServiceAccount
+			}
+			if opts.Timeout > 0 {
+				if rerun.Spec.Timeouts == nil {
+					rerun.Spec.Timeouts = &v1.TimeoutFields{}
+				}
+				rerun.Spec.Timeouts.Pipeline = &metav1.Duration{Duration: opts.Timeout}
+			}
+
+			// the params and workspaces are merged like "tkn pipeline start",
+			// which works on v1beta1 PipelineRuns
+			params.FilterParamsByType(runs.ParamSpecs(spec.Params, rerun.Spec.Params))
+			prv1beta1 := &v1beta1.PipelineRun{}
+			if err := prv1beta1.ConvertFrom(cmd.Context(), rerun); err != nil {
+				return err
+			}
+			prv1beta1.Spec.Params, err = params.MergeParam(prv1beta1.Spec.Params, opts.Params)
+			if err != nil {
+				return err
+			}
+			prv1beta1.Spec.Workspaces, err = workspaces.Merge(prv1beta1.Spec.Workspaces, opts.Workspaces, cs.HTTPClient)
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			if !opts.DryRun {
+				if prv1beta1, err = pipelinerunpkg.Create(cs, prv1beta1, metav1.CreateOptions{}, p.Namespace()); err != nil {
+					return err
+				}
+			}
+			created := &v1.PipelineRun{}
+			if err := prv1beta1.ConvertTo(cmd.Context(), created); err != nil {
+				return err
+			}
+			created.Kind, created.APIVersion = "PipelineRun", v1.SchemeGroupVersion.String()
+			if opts.DryRun || opts.Output != "" {
+				return printRun(out, opts.Output, created, &created.ObjectMeta)
+			}
+			_, err = fmt.Fprintf(out, "PipelineRun started: %s\n\nIn order to track the PipelineRun progress run:\nopc pipelinerun logs %s -f -n %s\n", created.Name, created.Name, created.Namespace)
+			return err
+		},
+	}
+	opts.addFlags(c, "PipelineRun")
+	c.Flags().BoolVar(&opts.FailedOnly, "failed-only", false, "skip the tasks which succeeded, pinning their results as params")
+
+	return c
+}
+
+// TaskRunRerunCommand returns the command creating a new TaskRun from a
+// TaskRun of the cluster.
+func TaskRunRerunCommand(p cli.Params) *cobra.Command {
+	opts := &rerunOptions{}
+	eg := `Rerun the TaskRun named 'foo' in namespace 'bar':
+
+    opc taskrun rerun foo -n bar
+
+Rerun the last TaskRun with another value of the image param:
+
+    opc taskrun rerun --last -p image=quay.io/myorg/app:latest
+`
+
+	c := &cobra.Command{
+		Use:               "rerun",
+		Short:             "Create a new TaskRun from a TaskRun",
+		ValidArgsFunction: formatted.ParentCompletion,
+		Long: `Create a new TaskRun with the spec of a TaskRun: its embedded task spec or its task reference, resolvers
+included, its params, workspaces, pod template, step and sidecar specs. The params, workspaces, ServiceAccount and
+timeout can be overridden. The TaskRuns of PipelineRuns are rerun on their own, outside of their PipelineRun.`,
+		Example: eg,
+		Args:    cobra.MaximumNArgs(1),
+		Annotations: map[string]string{
+			"commandType": "main",
+		},
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.validate(); err != nil {
+				return err
+			}
+			if len(args) > 0 && opts.Last {
+				return fmt.Errorf("a TaskRun name cannot be given with --last")
+			}
+			name, err := taskRunName(p, args, opts.Last)
+			if err != nil {
+				return err
+			}
+			cs, err := p.Clients()
+			if err != nil {
+				return err
+			}
+			tr, err := taskrunpkg.GetTaskRun(taskRunGroupResource, cs, name, p.Namespace())
+			if err != nil {
+				return err
+			}
+
+			rerun := &v1.TaskRun{
+				ObjectMeta: runs.RerunObjectMeta(tr.ObjectMeta),
+				Spec:       *tr.Spec.DeepCopy(),
+			}
+			rerun.Spec.Status, rerun.Spec.StatusMessage = "", ""
+			if opts.ServiceAccount != "" {
+				rerun.Spec.ServiceAccountName = opts.ServiceAccount
+			}
+			if opts.Timeout > 0 {
+				rerun.Spec.Timeout = &metav1.Duration{Duration: opts.Timeout}
+			}
+
+			spec := tr.Status.TaskSpec
+			if spec == nil {
+				spec = tr.Spec.TaskSpec
+			}
+			if spec == nil {
+				spec = &v1.TaskSpec{}
+			}
+			params.FilterParamsByType(runs.ParamSpecs(spec.Params, rerun.Spec.Params))
+			trv1beta1 := &v1beta1.TaskRun{}
+			if err := trv1beta1.ConvertFrom(cmd.Context(), rerun); err != nil {
+				return err
+			}
+			trv1beta1.Spec.Params, err = params.MergeParam(trv1beta1.Spec.Params, opts.Params)
+			if err != nil {
+				return err
+			}
+			trv1beta1.Spec.Workspaces, err = workspaces.Merge(trv1beta1.Spec.Workspaces, opts.Workspaces, cs.HTTPClient)
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			if !opts.DryRun {
+				if trv1beta1, err = taskrunpkg.Create(cs, trv1beta1, metav1.CreateOptions{}, p.Namespace()); err != nil {
+					return err
+				}
+			}
+			created := &v1.TaskRun{}
+			if err := trv1beta1.ConvertTo(cmd.Context(), created); err != nil {
+				return err
+			}
+			created.Kind, created.APIVersion = "TaskRun", v1.SchemeGroupVersion.String()
+			if opts.DryRun || opts.Output != "" {
+				return printRun(out, opts.Output, created, &created.ObjectMeta)
+			}
+			_, err = fmt.Fprintf(out, "TaskRun started: %s\n\nIn order to track the TaskRun progress run:\nopc taskrun logs %s -f -n %s\n", created.Name, created.Name, created.Namespace)
+			return err
+		},
+	}
+	opts.addFlags(c, "TaskRun")
+
+	return c
+}
+
+// taskRunName returns the TaskRun given as argument, the last one created
+// with --last, or asks to select one of the last TaskRuns.
+func taskRunName(p cli.Params, args []string, last bool) (string, error) {
+	if len(args) > 0 {
+		return args[0], nil
+	}
+	cs, err := p.Clients()
+	if err != nil {
+		return "", err
+	}
+	limit := 5
+	if last {
+		limit = 1
+	}
+	trs, err := taskrunpkg.GetAllTaskRuns(taskRunGroupResource, metav1.ListOptions{}, cs, p.Namespace(), limit, p.Time())
+	if err != nil {
+		return "", err
+	}
+	if len(trs) == 0 {
+		return "", fmt.Errorf("no TaskRuns found in namespace %s", p.Namespace())
+	}
+	if len(trs) == 1 || last {
+		return strings.Fields(trs[0])[0], nil
+	}
+	opts := options.NewDescribeOptions(p)
+	if err := opts.Ask(options.ResourceNameTaskRun, trs); err != nil {
+		return "", err
+	}
+	return opts.TaskrunName, nil
+}
+
+// printRun prints the run in the output format, its name or its generated
+// name when it is not created.
+func printRun(out io.Writer, output string, run interface{}, meta *metav1.ObjectMeta) error {
+	switch strings.ToLower(output) {
+	case "name":
+		name := meta.Name
+		if name == "" {
+			name = meta.GenerateName
+		}
+		_, err := fmt.Fprintln(out, name)
+		return err
+	case "json":
+		b, err := json.MarshalIndent(run, "", "\t")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(out, "%s\n", b)
+		return err
+	}
+	b, err := yaml.Marshal(run)
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(b)
+	return err
+}
@@ -0,0 +1,145 @@
+package pipelinerun
+
+import (
+	"sort"
+
+	"github.com/openshift-pipelines/opc/pkg/pipeline"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// runState is the progress of a PipelineRun shown by the watch command.
+type runState struct {
+	Name       string
+	Outcome    string
+	Reason     string
+	Start      *metav1.Time
+	Completion *metav1.Time
+	Tasks      []*taskState
+}
+
+// taskState is the progress of a pipeline task, aggregated over its TaskRuns
+// when the task has a matrix.
+type taskState struct {
+	Name        string
+	DisplayName string
+	Finally     bool
+	Level       int
+	TaskRuns    []string
+	Outcome     string
+	Reason      string
+	Step        string
+	Retries     int
+	Attempts    int
+	Start       *metav1.Time
+	Completion  *metav1.Time
+}
+
+func (s *runState) completed() bool {
+	return s.Completion != nil
+}
+
+// newRunState returns the progress of the PipelineRun from its status and the
+// status of its TaskRuns, the tasks are ordered by their level in the DAG of
+// the pipeline, the finally tasks last.
+func newRunState(pr *v1.PipelineRun, trs map[string]*v1.PipelineRunTaskRunStatus) *runState {
+	s := &runState{
+		Name:       pr.Name,
+		Start:      pr.Status.StartTime,
+		Completion: pr.Status.CompletionTime,
+	}
+	s.Outcome, s.Reason = runOutcome(pr.Status.Conditions)
+
+	spec := pipelineSpec(pr)
+	levels := pipeline.TaskLevels(spec.Tasks)
+	tasks := map[string]*taskState{}
+	for _, pt := range spec.Tasks {
+		t := &taskState{Name: pt.Name, DisplayName: pt.DisplayName, Level: levels[pt.Name], Retries: pt.Retries}
+		tasks[pt.Name] = t
+		s.Tasks = append(s.Tasks, t)
+	}
+	sort.SliceStable(s.Tasks, func(i, j int) bool {
+		return s.Tasks[i].Level < s.Tasks[j].Level
+	})
+	for _, pt := range spec.Finally {
+		t := &taskState{Name: pt.Name, DisplayName: pt.DisplayName, Finally: true, Retries: pt.Retries}
+		tasks[pt.Name] = t
+		s.Tasks = append(s.Tasks, t)
+	}
+
+	names := make([]string, 0, len(trs))
+	for name := range trs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		tr := trs[name]
+		t, ok := tasks[tr.PipelineTaskName]
+		if !ok {
+			// the spec of the pipeline is not resolved yet
+			t = &taskState{Name: tr.PipelineTaskName}
+			tasks[t.Name] = t
+			s.Tasks = append(s.Tasks, t)
+		}
+		t.TaskRuns = append(t.TaskRuns, name)
+		if tr.Status != nil {
+			t.add(tr.Status)
+		}
+	}
+
+	for _, skipped := range pr.Status.SkippedTasks {
+		if t, ok := tasks[skipped.Name]; ok {
+			t.Outcome, t.Reason = outcomeSkipped, string(skipped.Reason)
+		}
+	}
+	for _, t := range s.Tasks {
+		if t.Outcome == "" {
+			t.Outcome = outcomePending
+		}
+	}
+	return s
+}
+
+// outcomePriority orders the outcomes of the TaskRuns of a task, the outcome
+// of the task is the one with the highest priority.
+var outcomePriority = map[string]int{
+	outcomeSucceeded: 1,
+	outcomePending:   2,
+	outcomeRunning:   3,
+	outcomeCancelled: 4,
+	outcomeFailed:    5,
+}
+
+func (t *taskState) add(status *v1.TaskRunStatus) {
+	outcome, reason := runOutcome(status.Conditions)
+	if outcomePriority[outcome] > outcomePriority[t.Outcome] {
+		t.Outcome, t.Reason = outcome, reason
+	}
+	if attempts := len(status.RetriesStatus); attempts > t.Attempts {
+		t.Attempts = attempts
+	}
+	if status.StartTime != nil && (t.Start == nil || status.StartTime.Before(t.Start)) {
+		t.Start = status.StartTime
+	}
+	if status.CompletionTime != nil && (t.Completion == nil || t.Completion.Before(status.CompletionTime)) {
+		t.Completion = status.CompletionTime
+	}
+	if outcome == outcomeRunning && t.Step == "" {
+		t.Step = currentStep(status.Steps)
+	}
+}
+
+// currentStep returns the step running, or the first step waiting to run.
+func currentStep(steps []v1.StepState) string {
+	for _, step := range steps {
+		if step.Running != nil {
+			return step.Name
+		}
+	}
+	for _, step := range steps {
+		if step.Waiting != nil {
+			return step.Name
+		}
+	}
+	return ""
+}
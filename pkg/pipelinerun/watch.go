@@ -0,0 +1,206 @@
+package pipelinerun
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tektoncd/cli/pkg/cli"
+	"github.com/tektoncd/cli/pkg/formatted"
+	pipelinerunpkg "github.com/tektoncd/cli/pkg/pipelinerun"
+	"golang.org/x/term"
+)
+
+type watchOptions struct {
+	Last  bool
+	Plain bool
+}
+
+// WatchCommand returns the command showing the progress of a PipelineRun
+// until it completes.
+func WatchCommand(p cli.Params) *cobra.Command {
+	opts := &watchOptions{}
+	eg := `Watch the PipelineRun named 'foo' in namespace 'bar':
+
+    opc pipelinerun watch foo -n bar
+
+Watch the last PipelineRun and print the state transitions, like in a CI job:
+
+    opc pipelinerun watch --last --plain
+`
+
+	c := &cobra.Command{
+		Use:               "watch",
+		Short:             "Watch the progress of a PipelineRun",
+		ValidArgsFunction: formatted.ParentCompletion,
+		Long: `Watch the progress of a PipelineRun with a continuously updating view of its tasks, ordered by their
+level in the DAG of the pipeline with the finally tasks last, their status, duration, retries and current step.
+
+Use the up and down arrows (or j and k) to select a task and enter to show or hide its logs, q to quit.
+
+When the output is not a terminal, or with --plain, the state transitions of the PipelineRun and of its tasks are
+printed instead until the PipelineRun completes.`,
+		Example: eg,
+		Args:    cobra.MaximumNArgs(1),
+		Annotations: map[string]string{
+			"commandType": "main",
+		},
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name, err := pipelineRunName(p, args, opts.Last)
+			if err != nil {
+				return err
+			}
+			w := &watcher{p: p, name: name}
+
+			out := cmd.OutOrStdout()
+			f, ok := out.(*os.File)
+			// nolint
+			// this conversion is throwing error for golangci-lint G115
+			if opts.Plain || !ok || !term.IsTerminal(int(f.Fd())) {
+				return w.plain(cmd.Context(), out)
+			}
+			return newDashboard(w).run(cmd.Context())
+		},
+	}
+	c.Flags().BoolVarP(&opts.Last, "last", "L", false, "watch the last PipelineRun")
+	c.Flags().BoolVar(&opts.Plain, "plain", false, "print the state transitions instead of the live view")
+
+	return c
+}
+
+// watcher follows the status of a PipelineRun and of its TaskRuns.
+type watcher struct {
+	p    cli.Params
+	name string
+}
+
+func (w *watcher) state() (*runState, error) {
+	cs, err := w.p.Clients()
+	if err != nil {
+		return nil, err
+	}
+	pr, err := pipelinerunpkg.GetPipelineRun(pipelineRunGroupResource, cs, w.name, w.p.Namespace())
+	if err != nil {
+		return nil, err
+	}
+	trs, err := pipelinerunpkg.GetTaskRunsWithStatus(pr, cs, w.p.Namespace())
+	if err != nil {
+		return nil, err
+	}
+	return newRunState(pr, trs), nil
+}
+
+// watch sends the state of the PipelineRun, then again each time the tracker
+// of the PipelineRun reports a change until it completes or the context is
+// done. The errors are sent instead of the state.
+func (w *watcher) watch(ctx context.Context, send func(*runState, error)) {
+	s, err := w.state()
+	send(s, err)
+	if err != nil || s.completed() {
+		return
+	}
+	cs, err := w.p.Clients()
+	if err != nil {
+		send(nil, err)
+		return
+	}
+	// the tracker stops watching the PipelineRun once it has completed
+	events := pipelinerunpkg.NewTracker(w.name, w.p.Namespace(), cs).Monitor(nil)
+	if events == nil {
+		send(nil, fmt.Errorf("failed to watch PipelineRun %s", w.name))
+		return
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-events:
+			if ctx.Err() != nil {
+				return
+			}
+			send(w.state())
+			if !ok {
+				return
+			}
+		}
+	}
+}
+
+// plain prints the state transitions of the PipelineRun and of its tasks
+// until the PipelineRun completes.
+func (w *watcher) plain(ctx context.Context, out io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var prev *runState
+	var err error
+	w.watch(ctx, func(s *runState, serr error) {
+		if serr != nil {
+			err = serr
+			cancel()
+			return
+		}
+		printTransitions(out, prev, s, time.Now())
+		prev = s
+	})
+	return err
+}
+
+func printTransitions(out io.Writer, prev, s *runState, now time.Time) {
+	ts := now.Format(time.TimeOnly)
+	previous := map[string]*taskState{}
+	if prev != nil {
+		for _, t := range prev.Tasks {
+			previous[t.Name] = t
+		}
+	}
+	for _, t := range s.Tasks {
+		p, ok := previous[t.Name]
+		if ok && p.Outcome == t.Outcome && p.Step == t.Step && p.Attempts == t.Attempts {
+			continue
+		}
+		if !ok && prev != nil && t.Outcome == outcomePending {
+			continue
+		}
+		fmt.Fprintf(out, "%s task %s %s\n", ts, t.Name, taskSummary(t, now))
+	}
+	if prev == nil || prev.Outcome != s.Outcome || prev.completed() != s.completed() {
+		fmt.Fprintf(out, "%s pipelinerun %s %s\n", ts, s.Name, runSummary(s, now))
+	}
+}
+
+func taskSummary(t *taskState, now time.Time) string {
+	summary := t.Outcome + reasonSuffix(t.Outcome, t.Reason)
+	if t.Step != "" {
+		summary += fmt.Sprintf(", step %s", t.Step)
+	}
+	if t.Attempts > 0 {
+		summary += fmt.Sprintf(", retry %d/%d", t.Attempts, t.Retries)
+	}
+	if t.Completion != nil {
+		summary += fmt.Sprintf(" in %s", elapsed(t.Start, t.Completion, now))
+	}
+	return summary
+}
+
+func runSummary(s *runState, now time.Time) string {
+	summary := s.Outcome + reasonSuffix(s.Outcome, s.Reason)
+	if s.completed() {
+		summary += fmt.Sprintf(" in %s", elapsed(s.Start, s.Completion, now))
+	}
+	return summary
+}
+
+// reasonSuffix returns the reason of an outcome when it gives more details
+// than the outcome itself.
+func reasonSuffix(outcome, reason string) string {
+	if reason == "" || outcome == outcomeSucceeded || outcome == outcomeRunning || strings.EqualFold(reason, outcome) {
+		return ""
+	}
+	return fmt.Sprintf(" (%s)", reason)
+}
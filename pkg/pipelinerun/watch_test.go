@@ -0,0 +1,76 @@
+package pipelinerun
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPrintTransitions(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 1, 0, 0, time.UTC)
+	start := metav1.NewTime(now.Add(-time.Minute))
+	completion := metav1.NewTime(now)
+	tests := []struct {
+		name string
+		prev *runState
+		s    *runState
+		want string
+	}{{
+		name: "first state",
+		s: &runState{Name: "build", Outcome: outcomeRunning, Start: &start, Tasks: []*taskState{
+			{Name: "fetch", Outcome: outcomeRunning, Step: "clone"},
+			{Name: "test", Outcome: outcomePending},
+		}},
+		want: "10:01:00 task fetch running, step clone\n" +
+			"10:01:00 task test pending\n" +
+			"10:01:00 pipelinerun build running\n",
+	}, {
+		name: "unchanged",
+		prev: &runState{Name: "build", Outcome: outcomeRunning, Tasks: []*taskState{{Name: "fetch", Outcome: outcomeRunning, Step: "clone"}}},
+		s:    &runState{Name: "build", Outcome: outcomeRunning, Tasks: []*taskState{{Name: "fetch", Outcome: outcomeRunning, Step: "clone"}}},
+		want: "",
+	}, {
+		name: "completed",
+		prev: &runState{Name: "build", Outcome: outcomeRunning, Tasks: []*taskState{{Name: "fetch", Outcome: outcomeRunning, Step: "clone"}}},
+		s: &runState{Name: "build", Outcome: outcomeFailed, Reason: "Failed", Start: &start, Completion: &completion, Tasks: []*taskState{
+			{Name: "fetch", Outcome: outcomeFailed, Reason: "TaskRunTimeout", Start: &start, Completion: &completion},
+		}},
+		want: "10:01:00 task fetch failed (TaskRunTimeout) in 1m0s\n" +
+			"10:01:00 pipelinerun build failed in 1m0s\n",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			printTransitions(out, tt.prev, tt.s, now)
+			if d := cmp.Diff(tt.want, out.String()); d != "" {
+				t.Errorf("printTransitions() mismatch (-want +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestDashboardPostAfterClose(t *testing.T) {
+	screen := tcell.NewSimulationScreen("")
+	if err := screen.Init(); err != nil {
+		t.Fatal(err)
+	}
+	defer screen.Fini()
+	d := newDashboard(&watcher{name: "build"})
+	d.screen = screen
+
+	d.post(tcell.NewEventInterrupt("open"))
+	if ev, ok := screen.PollEvent().(*tcell.EventInterrupt); !ok || ev.Data() != "open" {
+		t.Fatalf("got event %v, want the posted interrupt", ev)
+	}
+
+	d.closed = true
+	d.post(tcell.NewEventInterrupt("closed"))
+	_ = screen.PostEvent(tcell.NewEventInterrupt("sentinel"))
+	if ev, ok := screen.PollEvent().(*tcell.EventInterrupt); !ok || ev.Data() != "sentinel" {
+		t.Errorf("got event %v posted after close", ev)
+	}
+}
@@ -0,0 +1,203 @@
+package results
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tektoncd/cli/pkg/cli"
+	tknoptions "github.com/tektoncd/cli/pkg/options"
+	"github.com/tektoncd/results/pkg/cli/client"
+	"github.com/tektoncd/results/pkg/cli/client/records"
+	"github.com/tektoncd/results/pkg/cli/common"
+	"github.com/tektoncd/results/pkg/cli/flags"
+	"github.com/tektoncd/results/pkg/cli/options"
+	pb "github.com/tektoncd/results/proto/v1alpha2/results_go_proto"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type deleteOptions struct {
+	UIDs  []string
+	Force bool
+}
+
+// deleteTarget is a record to delete, the whole result is deleted with all
+// its records when the record is the run the result was created for.
+type deleteTarget struct {
+	Record    string
+	Result    string
+	Kind      string
+	Namespace string
+	Name      string
+	UID       string
+	Created   *metav1.Time
+	Completed bool
+}
+
+// deleteRun is the part of a PipelineRun or a TaskRun used to describe the
+// deleted records.
+type deleteRun struct {
+	Kind     string            `json:"kind"`
+	Metadata metav1.ObjectMeta `json:"metadata"`
+	Status   struct {
+		CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	} `json:"status"`
+}
+
+// DeleteCommand returns the command deleting records from Results.
+func DeleteCommand(p common.Params) *cobra.Command {
+	opts := &deleteOptions{}
+	eg := `Delete the PipelineRun with the given UID and the records of its TaskRuns and logs:
+    opc results delete --uid 4a56a2a1-7b1e-4b4e-9d7a-0b4b3c2e6f1d -n foo
+
+Delete two records without asking for confirmation:
+    opc results delete --uid 4a56a2a1-7b1e-4b4e-9d7a-0b4b3c2e6f1d --uid 0b4b3c2e-9d7a-4b4e-7b1e-6f1d4a56a2a1 -f
+`
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Delete PipelineRun and TaskRun records from Results",
+		Long: `Delete the records of PipelineRuns and TaskRuns stored in Tekton Results by UID.
+
+When the record is the PipelineRun or the TaskRun the result was created for, the whole result is deleted with the
+records of the TaskRuns of the PipelineRun and the logs. Otherwise only the record of the TaskRun and its log are deleted.`,
+		Example: eg,
+		Annotations: map[string]string{
+			"commandType": "main",
+		},
+		Args:              cobra.NoArgs,
+		PersistentPreRunE: persistentPreRunE(p),
+		PreRunE: func(_ *cobra.Command, _ []string) error {
+			if len(opts.UIDs) == 0 {
+				return errors.New("at least one record must be given with --uid")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			targets := []*deleteTarget{}
+			for _, uid := range opts.UIDs {
+				record, err := getRecord(cmd.Context(), p, &options.DescribeOptions{UID: uid})
+				if err != nil {
+					return err
+				}
+				target, err := newDeleteTarget(record)
+				if err != nil {
+					return err
+				}
+				targets = append(targets, target)
+			}
+
+			names := []string{}
+			for _, t := range targets {
+				names = append(names, fmt.Sprintf("%s %s/%s", t.Kind, t.Namespace, t.Name))
+			}
+			s := &cli.Stream{In: cmd.InOrStdin(), Out: cmd.OutOrStdout(), Err: cmd.ErrOrStderr()}
+			deleteOpts := &tknoptions.DeleteOptions{Resource: "record", ForceDelete: opts.Force}
+			if err := deleteOpts.CheckOptions(s, names, p.Namespace()); err != nil {
+				return err
+			}
+
+			for _, t := range targets {
+				children, err := deleteRecords(cmd.Context(), p.RESTClient(), t)
+				if err != nil {
+					return fmt.Errorf("failed to delete %s %s/%s: %v", t.Kind, t.Namespace, t.Name, err)
+				}
+				if children > 0 {
+					fmt.Fprintf(s.Out, "Deleted %s %s/%s (%s) and the records of its %d TaskRuns\n", t.Kind, t.Namespace, t.Name, t.UID, children)
+					continue
+				}
+				fmt.Fprintf(s.Out, "Deleted %s %s/%s (%s)\n", t.Kind, t.Namespace, t.Name, t.UID)
+			}
+			return nil
+		},
+	}
+	flags.AddResultsOptions(cmd)
+
+	cmd.Flags().StringArrayVar(&opts.UIDs, "uid", []string{}, "UID of the PipelineRun or TaskRun record to delete, can be repeated")
+	cmd.Flags().BoolVarP(&opts.Force, "force", "f", false, "Whether to force deletion (default: false)")
+
+	return cmd
+}
+
+func newDeleteTarget(record *pb.Record) (*deleteTarget, error) {
+	run := &deleteRun{}
+	if err := json.Unmarshal(record.Data.Value, run); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal record %s: %w", record.Name, err)
+	}
+	t := &deleteTarget{
+		Record:    record.Name,
+		Kind:      run.Kind,
+		Namespace: run.Metadata.Namespace,
+		Name:      run.Metadata.Name,
+		UID:       string(run.Metadata.UID),
+		Completed: run.Status.CompletionTime != nil,
+	}
+	// the kind is not stored with the records listed without the whole run,
+	// the type of the record is <group>/<version>.<kind>
+	if t.Kind == "" && record.Data != nil {
+		t.Kind = record.Data.Type[strings.LastIndex(record.Data.Type, ".")+1:]
+	}
+	if !run.Metadata.CreationTimestamp.IsZero() {
+		t.Created = &run.Metadata.CreationTimestamp
+	}
+	// record names are <namespace>/results/<result>/records/<record>, the
+	// result is named after the run it was created for
+	parts := strings.Split(record.Name, "/")
+	if len(parts) == 5 && parts[2] == parts[4] {
+		t.Result = strings.Join(parts[:3], "/")
+	}
+	return t, nil
+}
+
+// deleteRecords deletes the result of the target when it is the run of the
+// result, or its record and its log otherwise. The number of the records of
+// the TaskRuns deleted with the result is returned.
+func deleteRecords(ctx context.Context, rc *client.RESTClient, t *deleteTarget) (int, error) {
+	if t.Result == "" {
+		if err := deleteResource(ctx, rc, t.Record); err != nil {
+			return 0, err
+		}
+		// the log of the TaskRun may not be stored
+		if err := deleteResource(ctx, rc, strings.Replace(t.Record, "/records/", "/logs/", 1)); err != nil && !isNotFound(err) {
+			return 0, err
+		}
+		return 0, nil
+	}
+
+	children := 0
+	req := &pb.ListRecordsRequest{
+		Parent:   t.Result,
+		Filter:   common.BuildFilterString(&options.ListOptions{ResourceType: common.ResourceTypeTaskRun}),
+		PageSize: 100,
+	}
+	recordClient := records.NewClient(rc)
+	for {
+		resp, err := recordClient.ListRecords(ctx, req, "records.name,next_page_token")
+		if err != nil {
+			return 0, err
+		}
+		for _, record := range resp.Records {
+			if record.Name != t.Record {
+				children++
+			}
+		}
+		if resp.NextPageToken == "" {
+			break
+		}
+		req.PageToken = resp.NextPageToken
+	}
+	return children, deleteResource(ctx, rc, t.Result)
+}
+
+func deleteResource(ctx context.Context, rc *client.RESTClient, name string) error {
+	_, err := rc.DoRequest(ctx, http.MethodDelete, rc.BuildURL("parents/"+name, nil), nil)
+	return err
+}
+
+func isNotFound(err error) bool {
+	var clientErr *client.Error
+	return errors.As(err, &clientErr) && clientErr.Code == http.StatusNotFound
+}
@@ -0,0 +1,117 @@
+package results
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	pb "github.com/tektoncd/results/proto/v1alpha2/results_go_proto"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// deleteRecordsFixture returns the records of the PipelineRun build with its
+// TaskRuns compile and test, and of the standalone TaskRun lint.
+func deleteRecordsFixture(t *testing.T) []*pb.Record {
+	created := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	pr := &v1.PipelineRun{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "tekton.dev/v1", Kind: "PipelineRun"},
+		ObjectMeta: metav1.ObjectMeta{Name: "build", Namespace: "ns", UID: "pr-uid"},
+	}
+	taskRun := func(name, uid string) *v1.TaskRun {
+		return &v1.TaskRun{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "tekton.dev/v1", Kind: "TaskRun"},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns", UID: types.UID(uid)},
+		}
+	}
+	return []*pb.Record{
+		runRecord(t, "tekton.dev/v1.PipelineRun", "pr-uid", pr, created),
+		runRecord(t, "tekton.dev/v1.TaskRun", "pr-uid", taskRun("build-compile", "compile-uid"), created),
+		runRecord(t, "tekton.dev/v1.TaskRun", "pr-uid", taskRun("build-test", "test-uid"), created),
+		runRecord(t, "tekton.dev/v1.TaskRun", "lint-uid", taskRun("lint", "lint-uid"), created),
+	}
+}
+
+func TestDeleteCommand(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		in          string
+		wantOut     string
+		wantErr     string
+		wantDeleted []string
+	}{{
+		name:        "top-level PipelineRun",
+		args:        []string{"--uid", "pr-uid", "-f"},
+		wantOut:     "Deleted PipelineRun ns/build (pr-uid) and the records of its 2 TaskRuns\n",
+		wantDeleted: []string{"ns/results/pr-uid"},
+	}, {
+		name:        "top-level TaskRun",
+		args:        []string{"--uid", "lint-uid", "-f"},
+		wantOut:     "Deleted TaskRun ns/lint (lint-uid)\n",
+		wantDeleted: []string{"ns/results/lint-uid"},
+	}, {
+		name:        "TaskRun of a PipelineRun",
+		args:        []string{"--uid", "compile-uid", "-f"},
+		wantOut:     "Deleted TaskRun ns/build-compile (compile-uid)\n",
+		wantDeleted: []string{"ns/results/pr-uid/records/compile-uid", "ns/results/pr-uid/logs/compile-uid"},
+	}, {
+		name:    "several UIDs",
+		args:    []string{"--uid", "test-uid", "--uid", "lint-uid", "-f"},
+		wantOut: "Deleted TaskRun ns/build-test (test-uid)\nDeleted TaskRun ns/lint (lint-uid)\n",
+		wantDeleted: []string{
+			"ns/results/pr-uid/records/test-uid", "ns/results/pr-uid/logs/test-uid",
+			"ns/results/lint-uid",
+		},
+	}, {
+		name:        "confirmed",
+		args:        []string{"--uid", "lint-uid"},
+		in:          "y\n",
+		wantOut:     `Are you sure you want to delete record(s) "TaskRun ns/lint" (y/n): Deleted TaskRun ns/lint (lint-uid)` + "\n",
+		wantDeleted: []string{"ns/results/lint-uid"},
+	}, {
+		name:    "declined",
+		args:    []string{"--uid", "pr-uid", "--uid", "lint-uid"},
+		in:      "n\n",
+		wantOut: `Are you sure you want to delete record(s) "PipelineRun ns/build", "TaskRun ns/lint" (y/n): `,
+		wantErr: `canceled deleting record(s) "PipelineRun ns/build", "TaskRun ns/lint"`,
+	}, {
+		name:    "unknown UID",
+		args:    []string{"--uid", "missing-uid", "-f"},
+		wantErr: "no record found with UID missing-uid",
+	}, {
+		name:    "no UID",
+		args:    []string{"-f"},
+		wantErr: "at least one record must be given with --uid",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, p := newFakeResults(t, 10, deleteRecordsFixture(t)...)
+			out := &bytes.Buffer{}
+			c := DeleteCommand(p)
+			c.SilenceUsage = true
+			c.SetIn(strings.NewReader(tt.in))
+			c.SetOut(out)
+			c.SetErr(&bytes.Buffer{})
+			c.SetArgs(append([]string{"-n", "ns"}, tt.args...))
+
+			err := c.Execute()
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("got error %v, want %s", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if d := cmp.Diff(tt.wantOut, out.String()); d != "" {
+				t.Errorf("output (-want +got):\n%s", d)
+			}
+			if d := cmp.Diff(tt.wantDeleted, fake.deleted); d != "" {
+				t.Errorf("deleted (-want +got):\n%s", d)
+			}
+		})
+	}
+}
@@ -0,0 +1,635 @@
+package results
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/cobra"
+	"github.com/tektoncd/cli/pkg/actions"
+	"github.com/tektoncd/cli/pkg/cli"
+	"github.com/tektoncd/cli/pkg/formatted"
+	prsort "github.com/tektoncd/cli/pkg/pipelinerun/sort"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"github.com/tektoncd/results/pkg/cli/common"
+	"github.com/tektoncd/results/pkg/cli/options"
+	pb "github.com/tektoncd/results/proto/v1alpha2/results_go_proto"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	sourceCluster = "cluster"
+	sourceResults = "results"
+)
+
+type diffOptions struct {
+	LastSuccess bool
+	LastFailure bool
+	Pipeline    string
+}
+
+// diffRun is a PipelineRun to compare, with its TaskRuns by pipeline task.
+type diffRun struct {
+	Source      string
+	PipelineRun *v1.PipelineRun
+	TaskRuns    map[string]*v1.TaskRun
+}
+
+// diffChange is a field with a different value in the two PipelineRuns, the
+// value is empty when the field is only set in one of them.
+type diffChange struct {
+	Field string
+	A     string
+	B     string
+}
+
+// PipelineRunDiffCommand returns the command comparing two PipelineRuns from
+// the cluster or from Results.
+func PipelineRunDiffCommand(p cli.Params) *cobra.Command {
+	opts := &diffOptions{}
+	eg := `Compare the PipelineRuns foo and bar in namespace baz:
+    opc pipelinerun diff foo bar -n baz
+
+Compare the last successful PipelineRun of the Pipeline of foo with foo:
+    opc pipelinerun diff foo --last-success
+
+Compare the last successful and the last failed PipelineRuns of the Pipeline build:
+    opc pipelinerun diff --last-success --last-failure --pipeline build
+`
+	cmd := &cobra.Command{
+		Use:   "diff [pipelinerun-a] [pipelinerun-b]",
+		Short: "Compare two PipelineRuns",
+		Long: `Compare the params, the resolved pipeline spec, the task refs, the image digests of the steps, the workspaces,
+the service account, the durations and the results of the tasks of two PipelineRuns.
+
+The PipelineRuns are given by name or UID, they are looked for in the cluster first and then in Tekton Results.
+With --last-success or --last-failure, the last successful or failed PipelineRun of the same Pipeline is compared
+with the given PipelineRun.`,
+		Annotations: map[string]string{
+			"commandType": "main",
+		},
+		Example: eg,
+		Args: func(_ *cobra.Command, args []string) error {
+			last := 0
+			if opts.LastSuccess {
+				last++
+			}
+			if opts.LastFailure {
+				last++
+			}
+			if len(args)+last != 2 {
+				return errors.New("requires two PipelineRuns, given as arguments or with --last-success and --last-failure")
+			}
+			if len(args) == 0 && opts.Pipeline == "" {
+				return errors.New("--pipeline is required when no PipelineRun is given")
+			}
+			return nil
+		},
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cs, err := p.Clients()
+			if err != nil {
+				return err
+			}
+			d := &differ{cmd: cmd, p: p, cs: cs}
+
+			runs := []*diffRun{}
+			for _, ref := range args {
+				r, err := d.load(ref)
+				if err != nil {
+					return err
+				}
+				runs = append(runs, r)
+			}
+
+			pipeline, exclude := opts.Pipeline, ""
+			if len(runs) > 0 {
+				pipeline = runs[0].PipelineRun.Labels[pipelineLabel]
+				exclude = string(runs[0].PipelineRun.UID)
+				if pipeline == "" {
+					return fmt.Errorf("PipelineRun %s does not run a Pipeline, --last-success and --last-failure cannot be used", runs[0].PipelineRun.Name)
+				}
+			}
+			// the last runs are the reference the other runs are compared to
+			for _, outcome := range []string{outcomeFailed, outcomeSucceeded} {
+				if (outcome == outcomeSucceeded && !opts.LastSuccess) || (outcome == outcomeFailed && !opts.LastFailure) {
+					continue
+				}
+				r, err := d.last(pipeline, outcome, exclude)
+				if err != nil {
+					return err
+				}
+				runs = append([]*diffRun{r}, runs...)
+			}
+
+			return printDiff(cmd.OutOrStdout(), runs[0], runs[1])
+		},
+	}
+	cmd.Flags().BoolVar(&opts.LastSuccess, "last-success", false, "compare with the last successful PipelineRun of the Pipeline")
+	cmd.Flags().BoolVar(&opts.LastFailure, "last-failure", false, "compare with the last failed PipelineRun of the Pipeline")
+	cmd.Flags().StringVar(&opts.Pipeline, "pipeline", "", "name of the Pipeline of the last PipelineRuns when no PipelineRun is given")
+
+	return cmd
+}
+
+// differ loads the PipelineRuns from the cluster or from Results, the client
+// of Results is only created when needed.
+type differ struct {
+	cmd *cobra.Command
+	p   cli.Params
+	cs  *cli.Clients
+	rp  *common.ResultsParams
+}
+
+func (d *differ) results() (*common.ResultsParams, error) {
+	if d.rp != nil {
+		return d.rp, nil
+	}
+	rp, err := resultsParams(d.cmd, d.p)
+	if err != nil {
+		return nil, err
+	}
+	d.rp = rp
+	return rp, nil
+}
+
+// load returns the PipelineRun with the given name or UID.
+func (d *differ) load(ref string) (*diffRun, error) {
+	ns := d.p.Namespace()
+	_, err := uuid.Parse(ref)
+	byUID := err == nil
+	if !byUID {
+		u, err := actions.GetUnstructured(pipelineRunGroupResource, d.cs, ref, ns, metav1.GetOptions{})
+		if err == nil {
+			pr := &v1.PipelineRun{}
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), pr); err != nil {
+				return nil, err
+			}
+			return d.clusterRun(pr)
+		}
+		if !apierrors.IsNotFound(err) {
+			return nil, err
+		}
+	} else {
+		prs := &v1.PipelineRunList{}
+		if err := actions.ListV1(pipelineRunGroupResource, d.cs, metav1.ListOptions{}, ns, prs); err != nil {
+			return nil, fmt.Errorf("failed to list PipelineRuns from namespace %s: %v", ns, err)
+		}
+		for i := range prs.Items {
+			if string(prs.Items[i].UID) == ref {
+				return d.clusterRun(&prs.Items[i])
+			}
+		}
+	}
+
+	rp, err := d.results()
+	if err != nil {
+		return nil, fmt.Errorf("PipelineRun %s not found in namespace %s and Tekton Results cannot be used: %v", ref, ns, err)
+	}
+	opts := &options.DescribeOptions{ResourceType: common.ResourceTypePipelineRun, ResourceName: ref}
+	if byUID {
+		opts = &options.DescribeOptions{ResourceType: common.ResourceTypePipelineRun, UID: ref}
+	}
+	record, err := getRecord(d.cmd.Context(), rp, opts)
+	if err != nil {
+		return nil, err
+	}
+	pr := &v1.PipelineRun{}
+	if err := json.Unmarshal(record.Data.Value, pr); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal PipelineRun data: %v", err)
+	}
+	return d.resultsRun(pr)
+}
+
+// last returns the most recent PipelineRun of the Pipeline with the given
+// outcome, from the cluster or from Results.
+func (d *differ) last(pipeline, outcome, exclude string) (*diffRun, error) {
+	ns := d.p.Namespace()
+	selector := pipelineLabel + "=" + pipeline
+	prs := &v1.PipelineRunList{}
+	if err := actions.ListV1(pipelineRunGroupResource, d.cs, metav1.ListOptions{LabelSelector: selector}, ns, prs); err != nil {
+		return nil, fmt.Errorf("failed to list PipelineRuns from namespace %s: %v", ns, err)
+	}
+	sources := map[string]string{}
+	for _, pr := range prs.Items {
+		sources[string(pr.UID)] = sourceCluster
+	}
+
+	rp, err := d.results()
+	var archived []*pb.Record
+	if err == nil {
+		archived, err = listArchived(d.cmd, rp.RESTClient(), ns, common.ResourceTypePipelineRun, selector, false, 100)
+	}
+	if err != nil {
+		fmt.Fprintf(d.cmd.ErrOrStderr(), "PipelineRuns archived in Tekton Results are not compared: %v\n", err)
+		archived = []*pb.Record{}
+	}
+	list, err := parseRecords(common.ResourceTypePipelineRun, archived, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, pr := range list.(*v1.PipelineRunList).Items {
+		if _, ok := sources[string(pr.UID)]; !ok {
+			sources[string(pr.UID)] = sourceResults
+			prs.Items = append(prs.Items, pr)
+		}
+	}
+
+	prsort.SortByStartTime(prs.Items)
+	for i := range prs.Items {
+		pr := &prs.Items[i]
+		if string(pr.UID) == exclude || runOutcome(pr.Status.Conditions) != outcome {
+			continue
+		}
+		if sources[string(pr.UID)] == sourceCluster {
+			return d.clusterRun(pr)
+		}
+		// the records are listed without their spec
+		rp, err := d.results()
+		if err != nil {
+			return nil, err
+		}
+		record, err := getRecord(d.cmd.Context(), rp, &options.DescribeOptions{ResourceType: common.ResourceTypePipelineRun, UID: string(pr.UID)})
+		if err != nil {
+			return nil, err
+		}
+		full := &v1.PipelineRun{}
+		if err := json.Unmarshal(record.Data.Value, full); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal PipelineRun data: %v", err)
+		}
+		return d.resultsRun(full)
+	}
+
+	kind := "successful"
+	if outcome == outcomeFailed {
+		kind = "failed"
+	}
+	return nil, fmt.Errorf("no %s PipelineRun of Pipeline %s found in namespace %s", kind, pipeline, ns)
+}
+
+// clusterRun returns the PipelineRun of the cluster with its TaskRuns, the
+// TaskRuns already pruned from the cluster are looked for in Results.
+func (d *differ) clusterRun(pr *v1.PipelineRun) (*diffRun, error) {
+	trs := &v1.TaskRunList{}
+	opts := metav1.ListOptions{LabelSelector: pipelineRunLabel + "=" + pr.Name}
+	if err := actions.ListV1(taskRunGroupResource, d.cs, opts, pr.Namespace, trs); err != nil {
+		return nil, fmt.Errorf("failed to list TaskRuns from namespace %s: %v", pr.Namespace, err)
+	}
+	r := &diffRun{Source: sourceCluster, PipelineRun: pr, TaskRuns: map[string]*v1.TaskRun{}}
+	for i := range trs.Items {
+		tr := &trs.Items[i]
+		if ownedBy(tr, string(pr.UID)) {
+			r.TaskRuns[tr.Labels[pipelineTaskLabel]] = tr
+		}
+	}
+	if len(r.TaskRuns) > 0 || len(pr.Status.ChildReferences) == 0 {
+		return r, nil
+	}
+	archived, err := d.resultsRun(pr)
+	if err != nil {
+		fmt.Fprintf(d.cmd.ErrOrStderr(), "TaskRuns of PipelineRun %s are not compared: %v\n", pr.Name, err)
+		return r, nil
+	}
+	r.TaskRuns = archived.TaskRuns
+	return r, nil
+}
+
+// resultsRun returns the PipelineRun of Results with its TaskRuns.
+func (d *differ) resultsRun(pr *v1.PipelineRun) (*diffRun, error) {
+	rp, err := d.results()
+	if err != nil {
+		return nil, err
+	}
+	children, err := getChildTaskRuns(d.cmd.Context(), rp, pr)
+	if err != nil {
+		return nil, err
+	}
+	r := &diffRun{Source: sourceResults, PipelineRun: pr, TaskRuns: map[string]*v1.TaskRun{}}
+	for _, child := range children {
+		r.TaskRuns[child.TaskRun.Labels[pipelineTaskLabel]] = child.TaskRun
+	}
+	return r, nil
+}
+
+// runOutcome returns the outcome of a run from its Succeeded condition.
+func runOutcome(conditions duckv1.Conditions) string {
+	for _, c := range conditions {
+		if c.Type != apis.ConditionSucceeded {
+			continue
+		}
+		switch c.Status {
+		case "True":
+			return outcomeSucceeded
+		case "False":
+			for _, r := range cancelledReasons {
+				if c.Reason == r {
+					return outcomeCancelled
+				}
+			}
+			return outcomeFailed
+		}
+	}
+	return outcomeRunning
+}
+
+func printDiff(out io.Writer, a, b *diffRun) error {
+	w := &diffWriter{out: out}
+	w.printf("A: %s\n", runSummary(a))
+	w.printf("B: %s\n", runSummary(b))
+
+	w.section("Params", diffFields(paramFields(a.PipelineRun), paramFields(b.PipelineRun)))
+	w.section("PipelineRun", diffFields(runFields(a.PipelineRun), runFields(b.PipelineRun)))
+	w.section("Tasks", diffFields(taskFields(a), taskFields(b)))
+
+	w.printf("\nDurations:\n")
+	tw := tabwriter.NewWriter(out, 0, 5, 3, ' ', tabwriter.TabIndent)
+	fmt.Fprintln(tw, "  TASK\tA\tB\tCHANGE")
+	fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\n", "(pipelinerun)",
+		formatted.Duration(a.PipelineRun.Status.StartTime, a.PipelineRun.Status.CompletionTime),
+		formatted.Duration(b.PipelineRun.Status.StartTime, b.PipelineRun.Status.CompletionTime),
+		durationChange(a.PipelineRun.Status.StartTime, a.PipelineRun.Status.CompletionTime, b.PipelineRun.Status.StartTime, b.PipelineRun.Status.CompletionTime))
+	for _, task := range taskNames(a, b) {
+		trA, trB := a.TaskRuns[task], b.TaskRuns[task]
+		var startA, endA, startB, endB *metav1.Time
+		if trA != nil {
+			startA, endA = trA.Status.StartTime, trA.Status.CompletionTime
+		}
+		if trB != nil {
+			startB, endB = trB.Status.StartTime, trB.Status.CompletionTime
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\n", task, formatted.Duration(startA, endA), formatted.Duration(startB, endB), durationChange(startA, endA, startB, endB))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	specA, err := specYAML(a.PipelineRun)
+	if err != nil {
+		return err
+	}
+	specB, err := specYAML(b.PipelineRun)
+	if err != nil {
+		return err
+	}
+	w.printf("\nResolved Pipeline spec:\n")
+	if specA == specB {
+		w.printf("  No differences\n")
+	} else {
+		diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(specA),
+			B:        difflib.SplitLines(specB),
+			FromFile: a.PipelineRun.Name,
+			ToFile:   b.PipelineRun.Name,
+			Context:  3,
+		})
+		if err != nil {
+			return err
+		}
+		w.printf("%s", diff)
+	}
+	return w.err
+}
+
+// diffWriter keeps the first error of the writes.
+type diffWriter struct {
+	out io.Writer
+	err error
+}
+
+func (w *diffWriter) printf(format string, a ...any) {
+	if w.err == nil {
+		_, w.err = fmt.Fprintf(w.out, format, a...)
+	}
+}
+
+func (w *diffWriter) section(title string, changes []diffChange) {
+	w.printf("\n%s:\n", title)
+	if len(changes) == 0 {
+		w.printf("  No differences\n")
+	}
+	for _, c := range changes {
+		switch {
+		case c.A == "":
+			w.printf("  + %s: %s\n", c.Field, c.B)
+		case c.B == "":
+			w.printf("  - %s: %s\n", c.Field, c.A)
+		default:
+			w.printf("  ~ %s: %s -> %s\n", c.Field, c.A, c.B)
+		}
+	}
+}
+
+func runSummary(r *diffRun) string {
+	pr := r.PipelineRun
+	return fmt.Sprintf("%s (%s, %s, started %s, duration %s)", pr.Name, r.Source,
+		formatted.Condition(pr.Status.Conditions), startTime(pr.Status.StartTime),
+		formatted.Duration(pr.Status.StartTime, pr.Status.CompletionTime))
+}
+
+func startTime(t *metav1.Time) string {
+	if t.IsZero() {
+		return "---"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// diffFields returns the fields with a different value, sorted by name.
+func diffFields(a, b map[string]string) []diffChange {
+	changes := []diffChange{}
+	for field, va := range a {
+		if vb := b[field]; va != vb {
+			changes = append(changes, diffChange{Field: field, A: va, B: vb})
+		}
+	}
+	for field, vb := range b {
+		if _, ok := a[field]; !ok {
+			changes = append(changes, diffChange{Field: field, B: vb})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+func paramFields(pr *v1.PipelineRun) map[string]string {
+	fields := map[string]string{}
+	for _, param := range pr.Spec.Params {
+		fields[param.Name] = paramValue(param.Value)
+	}
+	return fields
+}
+
+func paramValue(v v1.ParamValue) string {
+	if v.Type == v1.ParamTypeString || v.Type == "" {
+		return v.StringVal
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+func runFields(pr *v1.PipelineRun) map[string]string {
+	fields := map[string]string{}
+	switch {
+	case pr.Spec.PipelineRef != nil:
+		ref := pr.Spec.PipelineRef
+		fields["pipelineRef"] = refString(ref.Name, ref.Resolver, ref.Params)
+	case pr.Spec.PipelineSpec != nil:
+		fields["pipelineRef"] = "(embedded)"
+	}
+	if pr.Status.Provenance != nil {
+		fields["pipeline source"] = refSourceString(pr.Status.Provenance.RefSource)
+	}
+	fields["serviceAccountName"] = pr.Spec.TaskRunTemplate.ServiceAccountName
+	if pr.Spec.Timeouts != nil && pr.Spec.Timeouts.Pipeline != nil {
+		fields["timeouts.pipeline"] = pr.Spec.Timeouts.Pipeline.Duration.String()
+	}
+	for _, ws := range pr.Spec.Workspaces {
+		fields["workspace "+ws.Name] = workspaceString(ws)
+	}
+	for k, v := range fields {
+		if v == "" {
+			delete(fields, k)
+		}
+	}
+	return fields
+}
+
+// taskFields returns the refs, the step images, the results and the status
+// of the tasks of the PipelineRun.
+func taskFields(r *diffRun) map[string]string {
+	fields := map[string]string{}
+	if spec := r.PipelineRun.Status.PipelineSpec; spec != nil {
+		for _, pt := range append(append([]v1.PipelineTask{}, spec.Tasks...), spec.Finally...) {
+			switch {
+			case pt.TaskRef != nil:
+				fields[pt.Name+" taskRef"] = refString(pt.TaskRef.Name, pt.TaskRef.Resolver, pt.TaskRef.Params)
+			case pt.TaskSpec != nil:
+				fields[pt.Name+" taskRef"] = "(embedded)"
+			}
+		}
+	}
+	for task, tr := range r.TaskRuns {
+		fields[task+" status"] = formatted.Condition(tr.Status.Conditions)
+		if tr.Status.Provenance != nil {
+			if s := refSourceString(tr.Status.Provenance.RefSource); s != "" {
+				fields[task+" task source"] = s
+			}
+		}
+		for _, step := range tr.Status.Steps {
+			fields[fmt.Sprintf("%s step %s image", task, step.Name)] = step.ImageID
+		}
+		for _, res := range tr.Status.Results {
+			fields[fmt.Sprintf("%s result %s", task, res.Name)] = strings.TrimSpace(paramValue(res.Value))
+		}
+	}
+	return fields
+}
+
+func refString(name string, resolver v1.ResolverName, params v1.Params) string {
+	if resolver == "" {
+		return name
+	}
+	values := []string{}
+	for _, param := range params {
+		values = append(values, param.Name+"="+paramValue(param.Value))
+	}
+	sort.Strings(values)
+	return fmt.Sprintf("%s resolver (%s)", resolver, strings.Join(values, ", "))
+}
+
+func refSourceString(s *v1.RefSource) string {
+	if s == nil {
+		return ""
+	}
+	digests := []string{}
+	for alg, digest := range s.Digest {
+		digests = append(digests, alg+":"+digest)
+	}
+	sort.Strings(digests)
+	ref := s.URI
+	if s.EntryPoint != "" {
+		ref += " " + s.EntryPoint
+	}
+	if len(digests) > 0 {
+		ref += "@" + strings.Join(digests, ",")
+	}
+	return ref
+}
+
+func workspaceString(ws v1.WorkspaceBinding) string {
+	var binding string
+	switch {
+	case ws.PersistentVolumeClaim != nil:
+		binding = "persistentVolumeClaim " + ws.PersistentVolumeClaim.ClaimName
+	case ws.VolumeClaimTemplate != nil:
+		binding = "volumeClaimTemplate"
+	case ws.EmptyDir != nil:
+		binding = "emptyDir"
+	case ws.ConfigMap != nil:
+		binding = "configMap " + ws.ConfigMap.Name
+	case ws.Secret != nil:
+		binding = "secret " + ws.Secret.SecretName
+	case ws.Projected != nil:
+		binding = "projected"
+	case ws.CSI != nil:
+		binding = "csi " + ws.CSI.Driver
+	default:
+		binding = "(none)"
+	}
+	if ws.SubPath != "" {
+		binding += " subPath " + ws.SubPath
+	}
+	return binding
+}
+
+// taskNames returns the pipeline tasks of the two PipelineRuns, sorted by
+// name.
+func taskNames(a, b *diffRun) []string {
+	names := []string{}
+	for _, r := range []*diffRun{a, b} {
+		for task := range r.TaskRuns {
+			if !slices.Contains(names, task) {
+				names = append(names, task)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func durationChange(startA, endA, startB, endB *metav1.Time) string {
+	if startA.IsZero() || endA.IsZero() || startB.IsZero() || endB.IsZero() {
+		return "---"
+	}
+	delta := endB.Sub(startB.Time) - endA.Sub(startA.Time)
+	if delta >= 0 {
+		return "+" + delta.Round(time.Second).String()
+	}
+	return delta.Round(time.Second).String()
+}
+
+func specYAML(pr *v1.PipelineRun) (string, error) {
+	if pr.Status.PipelineSpec == nil {
+		return "", nil
+	}
+	b, err := yaml.Marshal(pr.Status.PipelineSpec)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
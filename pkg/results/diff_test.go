@@ -0,0 +1,387 @@
+package results
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/cobra"
+	"github.com/tektoncd/cli/pkg/cli"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	pb "github.com/tektoncd/results/proto/v1alpha2/results_go_proto"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+)
+
+func TestDiffFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields func(*diffRun) map[string]string
+		a, b   *diffRun
+		want   []diffChange
+	}{{
+		name:   "params",
+		fields: func(r *diffRun) map[string]string { return paramFields(r.PipelineRun) },
+		a: &diffRun{PipelineRun: &v1.PipelineRun{Spec: v1.PipelineRunSpec{Params: v1.Params{
+			{Name: "revision", Value: *v1.NewStructuredValues("main")},
+			{Name: "flags", Value: *v1.NewStructuredValues("-v", "-race")},
+			{Name: "same", Value: *v1.NewStructuredValues("x")},
+			{Name: "removed", Value: *v1.NewStructuredValues("y")},
+		}}}},
+		b: &diffRun{PipelineRun: &v1.PipelineRun{Spec: v1.PipelineRunSpec{Params: v1.Params{
+			{Name: "revision", Value: *v1.NewStructuredValues("v1.2.0")},
+			{Name: "flags", Value: *v1.NewStructuredValues("-v")},
+			{Name: "same", Value: *v1.NewStructuredValues("x")},
+			{Name: "added", Value: *v1.NewObject(map[string]string{"k": "v"})},
+		}}}},
+		want: []diffChange{
+			{Field: "added", B: `{"k":"v"}`},
+			{Field: "flags", A: `["-v","-race"]`, B: "-v"},
+			{Field: "removed", A: "y"},
+			{Field: "revision", A: "main", B: "v1.2.0"},
+		},
+	}, {
+		name:   "run fields",
+		fields: func(r *diffRun) map[string]string { return runFields(r.PipelineRun) },
+		a: &diffRun{PipelineRun: &v1.PipelineRun{
+			Spec: v1.PipelineRunSpec{
+				PipelineRef:     &v1.PipelineRef{Name: "build"},
+				TaskRunTemplate: v1.PipelineTaskRunTemplate{ServiceAccountName: "builder"},
+				Timeouts:        &v1.TimeoutFields{Pipeline: &metav1.Duration{Duration: time.Hour}},
+				Workspaces: []v1.WorkspaceBinding{
+					{Name: "source", PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "src"}},
+					{Name: "cache", EmptyDir: &corev1.EmptyDirVolumeSource{}},
+				},
+			},
+		}},
+		b: &diffRun{PipelineRun: &v1.PipelineRun{
+			Spec: v1.PipelineRunSpec{
+				PipelineRef: &v1.PipelineRef{Name: "build", ResolverRef: v1.ResolverRef{Resolver: "git", Params: v1.Params{
+					{Name: "url", Value: *v1.NewStructuredValues("https://example.com/repo.git")},
+					{Name: "revision", Value: *v1.NewStructuredValues("main")},
+				}}},
+				Workspaces: []v1.WorkspaceBinding{
+					{Name: "source", VolumeClaimTemplate: &corev1.PersistentVolumeClaim{}, SubPath: "app"},
+				},
+			},
+			Status: v1.PipelineRunStatus{PipelineRunStatusFields: v1.PipelineRunStatusFields{Provenance: &v1.Provenance{
+				RefSource: &v1.RefSource{URI: "git+https://example.com/repo.git", EntryPoint: "pipeline.yaml", Digest: map[string]string{"sha1": "abc"}},
+			}}},
+		}},
+		want: []diffChange{
+			{Field: "pipeline source", B: "git+https://example.com/repo.git pipeline.yaml@sha1:abc"},
+			{Field: "pipelineRef", A: "build", B: "git resolver (revision=main, url=https://example.com/repo.git)"},
+			{Field: "serviceAccountName", A: "builder"},
+			{Field: "timeouts.pipeline", A: "1h0m0s"},
+			{Field: "workspace cache", A: "emptyDir"},
+			{Field: "workspace source", A: "persistentVolumeClaim src", B: "volumeClaimTemplate subPath app"},
+		},
+	}, {
+		name:   "task refs, images and results",
+		fields: taskFields,
+		a: &diffRun{
+			PipelineRun: pipelineRunWithTasks(
+				v1.PipelineTask{Name: "compile", TaskRef: &v1.TaskRef{Name: "golang-build"}},
+				v1.PipelineTask{Name: "test", TaskSpec: &v1.EmbeddedTask{}},
+			),
+			TaskRuns: map[string]*v1.TaskRun{
+				"compile": taskRunStatus("True", []v1.StepState{{Name: "build", ImageID: "golang@sha256:1"}}, v1.TaskRunResult{Name: "digest", Value: *v1.NewStructuredValues("sha256:a\n")}),
+				"test":    taskRunStatus("True", nil),
+			},
+		},
+		b: &diffRun{
+			PipelineRun: pipelineRunWithTasks(
+				v1.PipelineTask{Name: "compile", TaskRef: &v1.TaskRef{Name: "golang-build", ResolverRef: v1.ResolverRef{Resolver: "bundles", Params: v1.Params{
+					{Name: "bundle", Value: *v1.NewStructuredValues("registry/tasks:v2")},
+				}}}},
+				v1.PipelineTask{Name: "test", TaskSpec: &v1.EmbeddedTask{}},
+			),
+			TaskRuns: map[string]*v1.TaskRun{
+				"compile": taskRunStatus("True", []v1.StepState{{Name: "build", ImageID: "golang@sha256:2"}}, v1.TaskRunResult{Name: "digest", Value: *v1.NewStructuredValues("sha256:b")}),
+				"test":    taskRunStatus("False", nil),
+			},
+		},
+		want: []diffChange{
+			{Field: "compile result digest", A: "sha256:a", B: "sha256:b"},
+			{Field: "compile step build image", A: "golang@sha256:1", B: "golang@sha256:2"},
+			{Field: "compile taskRef", A: "golang-build", B: "bundles resolver (bundle=registry/tasks:v2)"},
+			{Field: "test status", A: "Succeeded", B: "Failed"},
+		},
+	}, {
+		name:   "no differences",
+		fields: taskFields,
+		a:      &diffRun{PipelineRun: pipelineRunWithTasks(v1.PipelineTask{Name: "compile", TaskRef: &v1.TaskRef{Name: "golang-build"}})},
+		b:      &diffRun{PipelineRun: pipelineRunWithTasks(v1.PipelineTask{Name: "compile", TaskRef: &v1.TaskRef{Name: "golang-build"}})},
+		want:   []diffChange{},
+	}}
+	color.NoColor = true
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := diffFields(tt.fields(tt.a), tt.fields(tt.b))
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("diffFields() (-want +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func pipelineRunWithTasks(tasks ...v1.PipelineTask) *v1.PipelineRun {
+	pr := &v1.PipelineRun{}
+	pr.Status.PipelineSpec = &v1.PipelineSpec{Tasks: tasks}
+	return pr
+}
+
+func taskRunStatus(status corev1.ConditionStatus, steps []v1.StepState, results ...v1.TaskRunResult) *v1.TaskRun {
+	tr := &v1.TaskRun{}
+	tr.Status.Conditions = duckv1.Conditions{{Type: apis.ConditionSucceeded, Status: status}}
+	tr.Status.Steps = steps
+	tr.Status.Results = results
+	return tr
+}
+
+func TestPrintDiffSpec(t *testing.T) {
+	color.NoColor = true
+	start := metav1.NewTime(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC))
+	run := func(name, image string, duration time.Duration) *diffRun {
+		end := metav1.NewTime(start.Add(duration))
+		pr := pipelineRunWithTasks(
+			v1.PipelineTask{Name: "compile", TaskRef: &v1.TaskRef{Name: "golang-build"}},
+			v1.PipelineTask{Name: "push", TaskSpec: &v1.EmbeddedTask{TaskSpec: v1.TaskSpec{Steps: []v1.Step{{Name: "push", Image: image}}}}, RunAfter: []string{"compile"}},
+		)
+		pr.Name = name
+		pr.Status.Conditions = duckv1.Conditions{{Type: apis.ConditionSucceeded, Status: "True"}}
+		pr.Status.StartTime, pr.Status.CompletionTime = &start, &end
+		return &diffRun{Source: sourceCluster, PipelineRun: pr, TaskRuns: map[string]*v1.TaskRun{}}
+	}
+
+	out := &bytes.Buffer{}
+	if err := printDiff(out, run("build-1", "crane:v1", time.Minute), run("build-2", "crane:v2", 90*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	want := `A: build-1 (cluster, Succeeded, started 2024-05-01T10:00:00Z, duration 1m0s)
+B: build-2 (cluster, Succeeded, started 2024-05-01T10:00:00Z, duration 1m30s)
+
+Params:
+  No differences
+
+PipelineRun:
+  No differences
+
+Tasks:
+  No differences
+
+Durations:
+  TASK            A      B       CHANGE
+  (pipelinerun)   1m0s   1m30s   +30s
+
+Resolved Pipeline spec:
+--- build-1
++++ build-2
+@@ -10,6 +10,6 @@
+     spec: null
+     steps:
+     - computeResources: {}
+-      image: crane:v1
++      image: crane:v2
+       name: push
+ 
+`
+	if d := cmp.Diff(want, out.String()); d != "" {
+		t.Errorf("printDiff() (-want +got):\n%s", d)
+	}
+}
+
+// newFakeCluster returns the clients of a Kubernetes API server serving the
+// PipelineRuns and the TaskRuns of the namespace ns, filtered by their label
+// selector.
+func newFakeCluster(t *testing.T, prs []v1.PipelineRun, trs []v1.TaskRun) *cli.Clients {
+	t.Helper()
+	write := func(w http.ResponseWriter, v any) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(v); err != nil {
+			t.Error(err)
+		}
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		selector, err := labels.Parse(r.URL.Query().Get("labelSelector"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		switch r.URL.Path {
+		case "/api":
+			write(w, &metav1.APIVersions{TypeMeta: metav1.TypeMeta{Kind: "APIVersions"}})
+		case "/apis":
+			version := metav1.GroupVersionForDiscovery{GroupVersion: "tekton.dev/v1", Version: "v1"}
+			write(w, &metav1.APIGroupList{
+				TypeMeta: metav1.TypeMeta{Kind: "APIGroupList", APIVersion: "v1"},
+				Groups:   []metav1.APIGroup{{Name: "tekton.dev", Versions: []metav1.GroupVersionForDiscovery{version}, PreferredVersion: version}},
+			})
+		case "/apis/tekton.dev/v1":
+			write(w, &metav1.APIResourceList{
+				TypeMeta:     metav1.TypeMeta{Kind: "APIResourceList", APIVersion: "v1"},
+				GroupVersion: "tekton.dev/v1",
+				APIResources: []metav1.APIResource{
+					{Name: "pipelineruns", Namespaced: true, Kind: "PipelineRun", Verbs: metav1.Verbs{"get", "list"}},
+					{Name: "taskruns", Namespaced: true, Kind: "TaskRun", Verbs: metav1.Verbs{"get", "list"}},
+				},
+			})
+		case "/apis/tekton.dev/v1/namespaces/ns/pipelineruns":
+			list := &v1.PipelineRunList{TypeMeta: metav1.TypeMeta{APIVersion: "tekton.dev/v1", Kind: "PipelineRunList"}}
+			for _, pr := range prs {
+				if selector.Matches(labels.Set(pr.Labels)) {
+					list.Items = append(list.Items, pr)
+				}
+			}
+			write(w, list)
+		case "/apis/tekton.dev/v1/namespaces/ns/taskruns":
+			list := &v1.TaskRunList{TypeMeta: metav1.TypeMeta{APIVersion: "tekton.dev/v1", Kind: "TaskRunList"}}
+			for _, tr := range trs {
+				if selector.Matches(labels.Set(tr.Labels)) {
+					list.Items = append(list.Items, tr)
+				}
+			}
+			write(w, list)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := &rest.Config{Host: server.URL}
+	tekton, err := versioned.NewForConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dyn, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &cli.Clients{Tekton: tekton, Dynamic: dyn}
+}
+
+func diffPipelineRun(name, uid string, succeeded corev1.ConditionStatus, age time.Duration) v1.PipelineRun {
+	start := metav1.NewTime(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC).Add(-age))
+	pr := v1.PipelineRun{
+		TypeMeta: metav1.TypeMeta{APIVersion: "tekton.dev/v1", Kind: "PipelineRun"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "ns",
+			UID:       types.UID(uid),
+			Labels:    map[string]string{pipelineLabel: "build"},
+		},
+	}
+	pr.Status.StartTime = &start
+	pr.Status.Conditions = duckv1.Conditions{{Type: apis.ConditionSucceeded, Status: succeeded}}
+	return pr
+}
+
+func diffTaskRun(pr v1.PipelineRun, task string) v1.TaskRun {
+	return v1.TaskRun{
+		TypeMeta: metav1.TypeMeta{APIVersion: "tekton.dev/v1", Kind: "TaskRun"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pr.Name + "-" + task,
+			Namespace: "ns",
+			UID:       pr.UID + "-" + types.UID(task),
+			Labels:    map[string]string{pipelineRunLabel: pr.Name, pipelineRunUIDLabel: string(pr.UID), pipelineTaskLabel: task},
+		},
+	}
+}
+
+func TestDifferLast(t *testing.T) {
+	liveSucceeded := diffPipelineRun("build-3", "uid-3", "True", time.Hour)
+	liveFailed := diffPipelineRun("build-1", "uid-1", "False", 3*time.Hour)
+	archivedFailed := diffPipelineRun("build-2", "uid-2", "False", 2*time.Hour)
+	archivedSucceeded := diffPipelineRun("build-0", "uid-0", "True", 5*time.Hour)
+	cs := newFakeCluster(t,
+		[]v1.PipelineRun{liveSucceeded, liveFailed},
+		[]v1.TaskRun{diffTaskRun(liveSucceeded, "compile")})
+
+	record := func(pr v1.PipelineRun) *pb.Record {
+		return runRecord(t, "tekton.dev/v1.PipelineRun", string(pr.UID), &pr, pr.Status.StartTime.Time)
+	}
+	archivedTask := diffTaskRun(archivedFailed, "test")
+	_, rp := newFakeResults(t, 10,
+		record(liveSucceeded),
+		record(archivedFailed),
+		runRecord(t, "tekton.dev/v1.TaskRun", string(archivedFailed.UID), &archivedTask, archivedFailed.Status.StartTime.Time),
+		record(archivedSucceeded),
+	)
+
+	tests := []struct {
+		name     string
+		pipeline string
+		outcome  string
+		exclude  string
+		want     string
+		wantErr  string
+	}{{
+		name:     "last success in the cluster",
+		pipeline: "build",
+		outcome:  outcomeSucceeded,
+		want:     "build-3 cluster [compile]",
+	}, {
+		name:     "last failure archived in Results",
+		pipeline: "build",
+		outcome:  outcomeFailed,
+		want:     "build-2 results [test]",
+	}, {
+		name:     "compared run excluded",
+		pipeline: "build",
+		outcome:  outcomeSucceeded,
+		exclude:  "uid-3",
+		want:     "build-0 results []",
+	}, {
+		name:     "no run of the pipeline",
+		pipeline: "deploy",
+		outcome:  outcomeFailed,
+		wantErr:  "no failed PipelineRun of Pipeline deploy found in namespace ns",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &cli.TektonParams{}
+			p.SetNamespace("ns")
+			cmd := &cobra.Command{}
+			cmd.SetContext(context.Background())
+			errOut := &bytes.Buffer{}
+			cmd.SetErr(errOut)
+			d := &differ{cmd: cmd, p: p, cs: cs, rp: rp}
+
+			r, err := d.last(tt.pipeline, tt.outcome, tt.exclude)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("last() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			tasks := []string{}
+			for task := range r.TaskRuns {
+				tasks = append(tasks, task)
+			}
+			got := r.PipelineRun.Name + " " + r.Source + " [" + strings.Join(tasks, " ") + "]"
+			if got != tt.want {
+				t.Errorf("last() = %s, want %s", got, tt.want)
+			}
+			if errOut.Len() > 0 {
+				t.Errorf("unexpected warnings: %s", errOut.String())
+			}
+		})
+	}
+}
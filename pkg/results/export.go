@@ -0,0 +1,468 @@
+package results
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tektoncd/results/pkg/cli/client/logs"
+	"github.com/tektoncd/results/pkg/cli/client/records"
+	"github.com/tektoncd/results/pkg/cli/common"
+	"github.com/tektoncd/results/pkg/cli/flags"
+	"github.com/tektoncd/results/pkg/cli/options"
+	pb "github.com/tektoncd/results/proto/v1alpha2/results_go_proto"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	exportFormatDir    = "dir"
+	exportFormatTarGz  = "tar.gz"
+	exportFormatNDJSON = "ndjson"
+
+	manifestFile = "manifest.json"
+	recordFile   = "record.json"
+	logFile      = "log.txt"
+)
+
+// ExportManifest lists the records exported to an archive directory, it is
+// read again by the following exports to skip the records already exported.
+type ExportManifest struct {
+	Entries []ExportEntry `json:"entries"`
+}
+
+// ExportEntry is an exported PipelineRun or TaskRun record.
+type ExportEntry struct {
+	UID        string       `json:"uid"`
+	Record     string       `json:"record"`
+	Kind       string       `json:"kind"`
+	Namespace  string       `json:"namespace"`
+	Name       string       `json:"name"`
+	CreateTime *metav1.Time `json:"createTime,omitempty"`
+	// Path is the directory of the record and its log, in the archive
+	// directory or in the bundle.
+	Path string `json:"path"`
+	// Bundle is the tar.gz or NDJSON file holding the record, empty when the
+	// record is written in the archive directory.
+	Bundle     string      `json:"bundle,omitempty"`
+	Log        bool        `json:"log"`
+	ExportTime metav1.Time `json:"exportTime"`
+}
+
+type exportOptions struct {
+	AllNamespaces bool
+	Since         string
+	Until         string
+	Dir           string
+	Format        string
+	Types         []string
+	Limit         int32
+	NoLogs        bool
+}
+
+// exportWriter writes the exported records and their logs.
+type exportWriter interface {
+	Write(entry *ExportEntry, data, log []byte) error
+	Close() error
+}
+
+// exportRun is the part of a PipelineRun or a TaskRun used to describe the
+// record in the manifest.
+type exportRun struct {
+	Kind     string            `json:"kind"`
+	Metadata metav1.ObjectMeta `json:"metadata"`
+	Status   struct {
+		CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	} `json:"status"`
+}
+
+// ExportCommand returns the command exporting the PipelineRun and TaskRun
+// records and their logs to local files.
+func ExportCommand(p common.Params) *cobra.Command {
+	opts := &exportOptions{
+		Dir:    ".",
+		Format: exportFormatDir,
+		Types:  []string{common.ResourceTypePipelineRun, common.ResourceTypeTaskRun},
+		Limit:  100,
+	}
+	eg := `Export the runs of the last 30 days of all the namespaces to the archive directory:
+    opc results export --since 30d -A --dir ./archive
+
+Export the PipelineRuns of the namespace foo to a tar.gz bundle in the archive directory:
+    opc results export -n foo --type pipelinerun --format tar.gz --dir ./archive
+
+Export the runs without their logs as NDJSON:
+    opc results export --since 7d --format ndjson --no-logs --dir ./archive
+`
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export PipelineRun and TaskRun records and logs to local files",
+		Long: `Export the PipelineRun and TaskRun records stored in Tekton Results, with their logs, to a directory or to a tar.gz or NDJSON bundle.
+
+A manifest.json file in the archive directory lists the exported records, the records already listed are skipped
+so that running the same export again only exports the new records. Runs which have not completed yet are skipped.`,
+		Example: eg,
+		Annotations: map[string]string{
+			"commandType": "main",
+		},
+		Args:              cobra.NoArgs,
+		PersistentPreRunE: persistentPreRunE(p),
+		PreRunE: func(cmd *cobra.Command, _ []string) error {
+			if opts.AllNamespaces && cmd.Flags().Changed("namespace") {
+				return errors.New("cannot use --all-namespaces/-A and --namespace/-n together")
+			}
+			switch opts.Format {
+			case exportFormatDir, exportFormatTarGz, exportFormatNDJSON:
+			default:
+				return fmt.Errorf("invalid format %q, must be one of %s, %s or %s", opts.Format, exportFormatDir, exportFormatTarGz, exportFormatNDJSON)
+			}
+			for _, t := range opts.Types {
+				if t != common.ResourceTypePipelineRun && t != common.ResourceTypeTaskRun {
+					return fmt.Errorf("invalid type %q, must be %s or %s", t, common.ResourceTypePipelineRun, common.ResourceTypeTaskRun)
+				}
+			}
+			if opts.Limit < 5 || opts.Limit > 1000 {
+				return errors.New("limit should be between 5 and 1000")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return export(cmd, p, opts, time.Now())
+		},
+	}
+	flags.AddResultsOptions(cmd)
+
+	cmd.Flags().BoolVarP(&opts.AllNamespaces, "all-namespaces", "A", false, "Export the runs of all namespaces")
+	cmd.Flags().StringVar(&opts.Since, "since", "", "Only export runs created after this time (duration like 30d, 12h or RFC3339 timestamp)")
+	cmd.Flags().StringVar(&opts.Until, "until", "", "Only export runs created before this time (duration like 30d, 12h or RFC3339 timestamp)")
+	cmd.Flags().StringVar(&opts.Dir, "dir", opts.Dir, "Archive directory holding the exported runs and the manifest")
+	cmd.Flags().StringVar(&opts.Format, "format", opts.Format, "Format of the export, one of dir, tar.gz or ndjson")
+	cmd.Flags().StringSliceVar(&opts.Types, "type", opts.Types, "Types of runs to export, pipelinerun and/or taskrun")
+	cmd.Flags().Int32Var(&opts.Limit, "limit", opts.Limit, "Number of records fetched per page (must be between 5 and 1000)")
+	cmd.Flags().BoolVar(&opts.NoLogs, "no-logs", false, "Do not export the logs of the runs")
+
+	return cmd
+}
+
+func export(cmd *cobra.Command, p common.Params, opts *exportOptions, now time.Time) error {
+	if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+		return err
+	}
+	manifest, err := readManifest(opts.Dir)
+	if err != nil {
+		return err
+	}
+	exported := map[string]bool{}
+	for _, e := range manifest.Entries {
+		exported[e.UID] = true
+	}
+
+	var w exportWriter
+	bundle := bundleName(opts.Dir, opts.Format, now)
+	switch opts.Format {
+	case exportFormatTarGz:
+		w, err = newTarWriter(filepath.Join(opts.Dir, bundle))
+	case exportFormatNDJSON:
+		w, err = newNDJSONWriter(filepath.Join(opts.Dir, bundle))
+	default:
+		bundle = ""
+		w = &dirWriter{dir: opts.Dir}
+	}
+	if err != nil {
+		return err
+	}
+
+	parent := fmt.Sprintf("%s/results/-", p.Namespace())
+	if opts.AllNamespaces {
+		parent = common.AllNamespacesResultsParent
+	}
+
+	recordClient := records.NewClient(p.RESTClient())
+	logClient := logs.NewClient(p.RESTClient())
+	errOut := cmd.ErrOrStderr()
+	counts := map[string]int{}
+	skipped, running := 0, 0
+
+	exportErr := func() error {
+		for _, t := range opts.Types {
+			filter, err := BuildFilterString(&ListOptions{
+				ListOptions: options.ListOptions{ResourceType: t},
+				Since:       opts.Since,
+				Until:       opts.Until,
+			}, now)
+			if err != nil {
+				return err
+			}
+
+			req := &pb.ListRecordsRequest{
+				Parent:   parent,
+				Filter:   filter,
+				OrderBy:  "create_time desc",
+				PageSize: opts.Limit,
+			}
+			for {
+				resp, err := recordClient.ListRecords(cmd.Context(), req, "")
+				if err != nil {
+					return err
+				}
+
+				for _, record := range resp.Records {
+					if exported[record.Uid] {
+						skipped++
+						continue
+					}
+
+					run := &exportRun{}
+					if err := json.Unmarshal(record.Data.Value, run); err != nil {
+						return fmt.Errorf("failed to unmarshal record %s: %w", record.Name, err)
+					}
+					if run.Status.CompletionTime == nil {
+						running++
+						continue
+					}
+
+					entry := &ExportEntry{
+						UID:        record.Uid,
+						Record:     record.Name,
+						Kind:       run.Kind,
+						Namespace:  run.Metadata.Namespace,
+						Name:       run.Metadata.Name,
+						Path:       filepath.ToSlash(filepath.Join(run.Metadata.Namespace, t, run.Metadata.Name+"-"+record.Uid)),
+						Bundle:     bundle,
+						ExportTime: metav1.NewTime(now),
+					}
+					if record.CreateTime != nil {
+						entry.CreateTime = &metav1.Time{Time: record.CreateTime.AsTime()}
+					}
+
+					data := &bytes.Buffer{}
+					if err := json.Indent(data, record.Data.Value, "", "  "); err != nil {
+						return fmt.Errorf("failed to format record %s: %w", record.Name, err)
+					}
+
+					var log []byte
+					if !opts.NoLogs {
+						log, err = readLog(cmd, logClient, record.Name)
+						if err != nil {
+							fmt.Fprintf(errOut, "failed to get the log of %s %s/%s: %v\n", run.Kind, entry.Namespace, entry.Name, err)
+						}
+						entry.Log = err == nil
+					}
+
+					if err := w.Write(entry, data.Bytes(), log); err != nil {
+						return err
+					}
+					manifest.Entries = append(manifest.Entries, *entry)
+					exported[record.Uid] = true
+					counts[run.Kind]++
+				}
+
+				if resp.NextPageToken == "" {
+					break
+				}
+				req.PageToken = resp.NextPageToken
+			}
+		}
+		return nil
+	}()
+
+	// the manifest is saved even when the export fails half way, the records
+	// already written are then skipped by the next export
+	if err := w.Close(); err != nil {
+		return err
+	}
+	if err := writeManifest(opts.Dir, manifest); err != nil {
+		return err
+	}
+	if exportErr != nil {
+		return exportErr
+	}
+
+	total := counts["PipelineRun"] + counts["TaskRun"]
+	if total == 0 && bundle != "" {
+		if err := os.Remove(filepath.Join(opts.Dir, bundle)); err != nil {
+			return err
+		}
+	}
+	dest := opts.Dir
+	if bundle != "" && total > 0 {
+		dest = filepath.Join(opts.Dir, bundle)
+	}
+	_, err = fmt.Fprintf(cmd.OutOrStdout(), "Exported %d PipelineRuns and %d TaskRuns to %s (%d already exported, %d not completed)\n",
+		counts["PipelineRun"], counts["TaskRun"], dest, skipped, running)
+	return err
+}
+
+// bundleName returns the name of a new bundle in the archive directory, a
+// number is added to the names of the bundles exported in the same second.
+func bundleName(dir, format string, now time.Time) string {
+	base := "export-" + now.UTC().Format("20060102T150405Z")
+	name := base + "." + format
+	for i := 1; ; i++ {
+		if _, err := os.Lstat(filepath.Join(dir, name)); errors.Is(err, os.ErrNotExist) {
+			return name
+		}
+		name = fmt.Sprintf("%s-%d.%s", base, i, format)
+	}
+}
+
+// readLog returns the log of the record.
+func readLog(cmd *cobra.Command, c *logs.Client, name string) ([]byte, error) {
+	reader, err := c.GetLog(cmd.Context(), &pb.GetLogRequest{Name: name})
+	if err != nil {
+		return nil, err
+	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+	return io.ReadAll(reader)
+}
+
+func readManifest(dir string) (*ExportManifest, error) {
+	manifest := &ExportManifest{Entries: []ExportEntry{}}
+	b, err := os.ReadFile(filepath.Join(dir, manifestFile))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return manifest, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(b, manifest); err != nil {
+		return nil, fmt.Errorf("failed to read the manifest of %s: %w", dir, err)
+	}
+	return manifest, nil
+}
+
+// writeManifest replaces the manifest atomically, so that an interrupted
+// export does not lose the list of the records exported before.
+func writeManifest(dir string, manifest *ExportManifest) error {
+	b, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(dir, manifestFile+".tmp")
+	if err := os.WriteFile(tmp, append(b, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(dir, manifestFile))
+}
+
+// dirWriter writes each record and its log in a directory of the archive.
+type dirWriter struct {
+	dir string
+}
+
+func (w *dirWriter) Write(entry *ExportEntry, data, log []byte) error {
+	dir := filepath.Join(w.dir, filepath.FromSlash(entry.Path))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, recordFile), data, 0o644); err != nil {
+		return err
+	}
+	if !entry.Log {
+		return nil
+	}
+	return os.WriteFile(filepath.Join(dir, logFile), log, 0o644)
+}
+
+func (w *dirWriter) Close() error {
+	return nil
+}
+
+// tarWriter writes the records and their logs in a tar.gz bundle, with a
+// manifest of the records of the bundle.
+type tarWriter struct {
+	f       *os.File
+	gz      *gzip.Writer
+	tw      *tar.Writer
+	entries []ExportEntry
+}
+
+func newTarWriter(path string) (*tarWriter, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	gz := gzip.NewWriter(f)
+	return &tarWriter{f: f, gz: gz, tw: tar.NewWriter(gz), entries: []ExportEntry{}}, nil
+}
+
+func (w *tarWriter) Write(entry *ExportEntry, data, log []byte) error {
+	if err := w.add(entry.Path+"/"+recordFile, data); err != nil {
+		return err
+	}
+	if entry.Log {
+		if err := w.add(entry.Path+"/"+logFile, log); err != nil {
+			return err
+		}
+	}
+	w.entries = append(w.entries, *entry)
+	return nil
+}
+
+func (w *tarWriter) add(name string, data []byte) error {
+	if err := w.tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0o644,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}); err != nil {
+		return err
+	}
+	_, err := w.tw.Write(data)
+	return err
+}
+
+func (w *tarWriter) Close() error {
+	b, err := json.MarshalIndent(&ExportManifest{Entries: w.entries}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return errors.Join(w.add(manifestFile, append(b, '\n')), w.tw.Close(), w.gz.Close(), w.f.Close())
+}
+
+// ndjsonRecord is a line of a NDJSON bundle.
+type ndjsonRecord struct {
+	Entry  *ExportEntry    `json:"entry"`
+	Record json.RawMessage `json:"record"`
+	Log    *string         `json:"log,omitempty"`
+}
+
+// ndjsonWriter writes each record with its log on a line of a NDJSON bundle.
+type ndjsonWriter struct {
+	f   *os.File
+	enc *json.Encoder
+}
+
+func newNDJSONWriter(path string) (*ndjsonWriter, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &ndjsonWriter{f: f, enc: json.NewEncoder(f)}, nil
+}
+
+func (w *ndjsonWriter) Write(entry *ExportEntry, data, log []byte) error {
+	record := &bytes.Buffer{}
+	if err := json.Compact(record, data); err != nil {
+		return err
+	}
+	line := &ndjsonRecord{Entry: entry, Record: record.Bytes()}
+	if entry.Log {
+		s := string(log)
+		line.Log = &s
+	}
+	return w.enc.Encode(line)
+}
+
+func (w *ndjsonWriter) Close() error {
+	return w.f.Close()
+}
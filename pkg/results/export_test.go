@@ -0,0 +1,326 @@
+package results
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"github.com/tektoncd/results/pkg/cli/common"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// newExportResults returns the fake Results server of a completed PipelineRun
+// with its log, one of its TaskRuns whose log is not stored and a running
+// PipelineRun.
+func newExportResults(t *testing.T) (*fakeResults, *common.ResultsParams) {
+	t.Helper()
+	created := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	completed := metav1.NewTime(created.Add(time.Minute))
+	pr := &v1.PipelineRun{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "tekton.dev/v1", Kind: "PipelineRun"},
+		ObjectMeta: metav1.ObjectMeta{Name: "build", Namespace: "ns", UID: "pr-uid"},
+	}
+	pr.Status.CompletionTime = &completed
+	tr := &v1.TaskRun{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "tekton.dev/v1", Kind: "TaskRun"},
+		ObjectMeta: metav1.ObjectMeta{Name: "build-lint", Namespace: "ns", UID: "tr-uid"},
+	}
+	tr.Status.CompletionTime = &completed
+	running := &v1.PipelineRun{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "tekton.dev/v1", Kind: "PipelineRun"},
+		ObjectMeta: metav1.ObjectMeta{Name: "deploy", Namespace: "ns", UID: "running-uid"},
+	}
+
+	fake, p := newFakeResults(t, 10,
+		runRecord(t, "tekton.dev/v1.PipelineRun", "pr-uid", pr, created),
+		runRecord(t, "tekton.dev/v1.TaskRun", "pr-uid", tr, created),
+		runRecord(t, "tekton.dev/v1.PipelineRun", "running-uid", running, created),
+	)
+	fake.logs = map[string]string{"ns/results/pr-uid/records/pr-uid": "[build] done\n"}
+	return fake, p
+}
+
+func runExport(t *testing.T, p common.Params, args ...string) (string, string) {
+	t.Helper()
+	out, errOut := &bytes.Buffer{}, &bytes.Buffer{}
+	c := ExportCommand(p)
+	c.SetOut(out)
+	c.SetErr(errOut)
+	c.SetArgs(append([]string{"-n", "ns"}, args...))
+	if err := c.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	return out.String(), errOut.String()
+}
+
+// exportedUIDs returns the UIDs of the entries of the manifest of the
+// archive directory.
+func exportedUIDs(t *testing.T, dir string) []string {
+	t.Helper()
+	manifest, err := readManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uids := []string{}
+	for _, e := range manifest.Entries {
+		uids = append(uids, e.UID)
+	}
+	return uids
+}
+
+// bundle returns the path of the single bundle of the archive directory.
+func bundle(t *testing.T, dir, format string) string {
+	t.Helper()
+	matches, err := filepath.Glob(filepath.Join(dir, "export-*."+format))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 {
+		t.Fatalf("got the bundles %v, want one %s bundle", matches, format)
+	}
+	return matches[0]
+}
+
+func TestExportDir(t *testing.T) {
+	_, p := newExportResults(t)
+	dir := t.TempDir()
+
+	out, errOut := runExport(t, p, "--dir", dir)
+	if want := "Exported 1 PipelineRuns and 1 TaskRuns to " + dir + " (0 already exported, 1 not completed)\n"; out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+	if !strings.HasPrefix(errOut, "failed to get the log of TaskRun ns/build-lint: ") {
+		t.Errorf("missing log not reported: %q", errOut)
+	}
+
+	files := []string{}
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			rel, _ := filepath.Rel(dir, path)
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"manifest.json",
+		"ns/pipelinerun/build-pr-uid/log.txt",
+		"ns/pipelinerun/build-pr-uid/record.json",
+		"ns/taskrun/build-lint-tr-uid/record.json",
+	}
+	if d := cmp.Diff(want, files); d != "" {
+		t.Errorf("exported files (-want +got):\n%s", d)
+	}
+	log, err := os.ReadFile(filepath.Join(dir, "ns/pipelinerun/build-pr-uid/log.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(log) != "[build] done\n" {
+		t.Errorf("log = %q, want the log of the PipelineRun", log)
+	}
+
+	manifest, err := readManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for _, e := range manifest.Entries {
+		got = append(got, strings.Join([]string{e.UID, e.Kind, e.Name, e.Path, e.Bundle}, " "))
+		if e.Log != (e.Kind == "PipelineRun") {
+			t.Errorf("log of %s %s exported = %t", e.Kind, e.Name, e.Log)
+		}
+	}
+	wantEntries := []string{
+		"pr-uid PipelineRun build ns/pipelinerun/build-pr-uid ",
+		"tr-uid TaskRun build-lint ns/taskrun/build-lint-tr-uid ",
+	}
+	if d := cmp.Diff(wantEntries, got); d != "" {
+		t.Errorf("manifest entries (-want +got):\n%s", d)
+	}
+}
+
+func TestExportTarGz(t *testing.T) {
+	_, p := newExportResults(t)
+	dir := t.TempDir()
+
+	out, _ := runExport(t, p, "--dir", dir, "--format", "tar.gz")
+	path := bundle(t, dir, "tar.gz")
+	if want := "Exported 1 PipelineRuns and 1 TaskRuns to " + path + " (0 already exported, 1 not completed)\n"; out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	files := map[string]string{}
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[h.Name] = string(b)
+	}
+	names := []string{}
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	want := []string{
+		"manifest.json",
+		"ns/pipelinerun/build-pr-uid/log.txt",
+		"ns/pipelinerun/build-pr-uid/record.json",
+		"ns/taskrun/build-lint-tr-uid/record.json",
+	}
+	if d := cmp.Diff(want, names); d != "" {
+		t.Errorf("files of the bundle (-want +got):\n%s", d)
+	}
+	if got := files["ns/pipelinerun/build-pr-uid/log.txt"]; got != "[build] done\n" {
+		t.Errorf("log = %q, want the log of the PipelineRun", got)
+	}
+
+	// the manifest of the archive directory points to the bundle
+	manifest, err := readManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range manifest.Entries {
+		if e.Bundle != filepath.Base(path) {
+			t.Errorf("bundle of %s = %q, want %q", e.Name, e.Bundle, filepath.Base(path))
+		}
+	}
+	if d := cmp.Diff([]string{"pr-uid", "tr-uid"}, exportedUIDs(t, dir)); d != "" {
+		t.Errorf("manifest (-want +got):\n%s", d)
+	}
+}
+
+func TestExportNDJSON(t *testing.T) {
+	_, p := newExportResults(t)
+	dir := t.TempDir()
+
+	runExport(t, p, "--dir", dir, "--format", "ndjson")
+
+	f, err := os.Open(bundle(t, dir, "ndjson"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	got := []string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := &ndjsonRecord{}
+		if err := json.Unmarshal(scanner.Bytes(), line); err != nil {
+			t.Fatalf("invalid line %s: %v", scanner.Text(), err)
+		}
+		run := &exportRun{}
+		if err := json.Unmarshal(line.Record, run); err != nil {
+			t.Fatal(err)
+		}
+		log := "no log"
+		if line.Log != nil {
+			log = *line.Log
+		}
+		got = append(got, strings.Join([]string{line.Entry.UID, run.Kind, run.Metadata.Name, log}, " "))
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"pr-uid PipelineRun build [build] done\n",
+		"tr-uid TaskRun build-lint no log",
+	}
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("NDJSON lines (-want +got):\n%s", d)
+	}
+}
+
+func TestExportSkipsExportedRecords(t *testing.T) {
+	for _, format := range []string{"dir", "tar.gz", "ndjson"} {
+		t.Run(format, func(t *testing.T) {
+			fake, p := newExportResults(t)
+			dir := t.TempDir()
+
+			// the PipelineRun was exported before
+			manifest := &ExportManifest{Entries: []ExportEntry{{UID: "pr-uid", Kind: "PipelineRun", Namespace: "ns", Name: "build"}}}
+			if err := writeManifest(dir, manifest); err != nil {
+				t.Fatal(err)
+			}
+			out, _ := runExport(t, p, "--dir", dir, "--format", format)
+			if !strings.HasPrefix(out, "Exported 0 PipelineRuns and 1 TaskRuns to ") || !strings.HasSuffix(out, " (1 already exported, 1 not completed)\n") {
+				t.Errorf("unexpected output of the first export: %q", out)
+			}
+			if d := cmp.Diff([]string{"pr-uid", "tr-uid"}, exportedUIDs(t, dir)); d != "" {
+				t.Errorf("manifest (-want +got):\n%s", d)
+			}
+
+			// nothing is exported again and no empty bundle is left
+			fake.queries = nil
+			out, _ = runExport(t, p, "--dir", dir, "--format", format)
+			if want := "Exported 0 PipelineRuns and 0 TaskRuns to " + dir + " (2 already exported, 1 not completed)\n"; out != want {
+				t.Errorf("output = %q, want %q", out, want)
+			}
+			if d := cmp.Diff([]string{"pr-uid", "tr-uid"}, exportedUIDs(t, dir)); d != "" {
+				t.Errorf("manifest (-want +got):\n%s", d)
+			}
+			if format != "dir" {
+				bundle(t, dir, format)
+			}
+			if len(fake.queries) != 2 {
+				t.Errorf("got %d list requests, want one per type", len(fake.queries))
+			}
+		})
+	}
+}
+
+func TestExportNoLogs(t *testing.T) {
+	_, p := newExportResults(t)
+	dir := t.TempDir()
+
+	_, errOut := runExport(t, p, "--dir", dir, "--no-logs")
+	if errOut != "" {
+		t.Errorf("logs fetched with --no-logs: %q", errOut)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ns/pipelinerun/build-pr-uid/log.txt")); !os.IsNotExist(err) {
+		t.Errorf("log exported with --no-logs: %v", err)
+	}
+}
+
+func TestBundleName(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	for _, want := range []string{"export-20240501T100000Z.ndjson", "export-20240501T100000Z-1.ndjson", "export-20240501T100000Z-2.ndjson"} {
+		got := bundleName(dir, "ndjson", now)
+		if got != want {
+			t.Fatalf("bundleName() = %s, want %s", got, want)
+		}
+		if err := os.WriteFile(filepath.Join(dir, got), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package results
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tektoncd/results/pkg/cli/client"
+	"github.com/tektoncd/results/pkg/cli/common"
+	pb "github.com/tektoncd/results/proto/v1alpha2/results_go_proto"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"k8s.io/client-go/transport"
+)
+
+// fakeResults is a Results API server listing its records by pages and
+// applying the field masks of the requests. Only the parents, the data types,
+// the record names and the label equalities of the filters are evaluated, the
+// filters are recorded with the other queries.
+type fakeResults struct {
+	pageSize int
+	records  []*pb.Record
+	// logs are the logs of the records by record name, the log of the other
+	// records is not found.
+	logs map[string]string
+
+	mu      sync.Mutex
+	queries []url.Values
+	deleted []string
+}
+
+func newFakeResults(t *testing.T, pageSize int, records ...*pb.Record) (*fakeResults, *common.ResultsParams) {
+	t.Helper()
+	f := &fakeResults{pageSize: pageSize, records: records}
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc, err := client.NewRESTClient(&client.Config{URL: u, Timeout: 10 * time.Second, Transport: &transport.Config{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &common.ResultsParams{}
+	p.SetRESTClient(rc)
+	p.SetNamespace("ns")
+	return f, p
+}
+
+func (f *fakeResults) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name := strings.TrimPrefix(r.URL.Path, "/parents/")
+	if r.Method == http.MethodDelete {
+		f.deleted = append(f.deleted, name)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+	if strings.Contains(name, "/logs/") {
+		log, ok := f.logs[strings.Replace(name, "/logs/", "/records/", 1)]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(log))
+		return
+	}
+	if !strings.HasSuffix(name, "/records") {
+		for _, record := range f.records {
+			if record.Name == name {
+				b, _ := protojson.Marshal(record)
+				_, _ = w.Write(b)
+				return
+			}
+		}
+		http.NotFound(w, r)
+		return
+	}
+
+	query := r.URL.Query()
+	f.queries = append(f.queries, query)
+	parent := strings.Split(strings.TrimSuffix(name, "/records"), "/")
+	records := []*pb.Record{}
+	for _, record := range f.records {
+		parts := strings.Split(record.Name, "/")
+		if (parent[0] != "-" && parent[0] != parts[0]) || (parent[2] != "-" && parent[2] != parts[2]) {
+			continue
+		}
+		filter := query.Get("filter")
+		if strings.Contains(filter, "data_type") && !strings.Contains(filter, fmt.Sprintf("data_type==%q", record.Data.Type)) {
+			continue
+		}
+		if strings.Contains(filter, "name.endsWith") && !strings.Contains(filter, fmt.Sprintf("name.endsWith(\"records/%s\")", parts[len(parts)-1])) {
+			continue
+		}
+		if !matchLabels(record, filter) {
+			continue
+		}
+		records = append(records, record)
+	}
+	start, _ := strconv.Atoi(query.Get("page_token"))
+	size := f.pageSize
+	if s, _ := strconv.Atoi(query.Get("page_size")); s > 0 && s < size {
+		size = s
+	}
+	end := min(start+size, len(records))
+
+	resp := &pb.ListRecordsResponse{}
+	for _, record := range records[start:end] {
+		resp.Records = append(resp.Records, mask(record, query.Get("fields")))
+	}
+	if end < len(records) && (query.Get("fields") == "" || strings.Contains(query.Get("fields"), "next_page_token")) {
+		resp.NextPageToken = strconv.Itoa(end)
+	}
+	b, err := protojson.Marshal(resp)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_, _ = w.Write(b)
+}
+
+var labelFilter = regexp.MustCompile(`data\.metadata\.labels\[("[^"]*")\]==("[^"]*")`)
+
+// matchLabels returns true when the labels of the run of the record have the
+// values of the label equalities of the filter.
+func matchLabels(record *pb.Record, filter string) bool {
+	run := struct {
+		Metadata struct {
+			Labels map[string]string `json:"labels"`
+		} `json:"metadata"`
+	}{}
+	_ = json.Unmarshal(record.Data.Value, &run)
+	for _, m := range labelFilter.FindAllStringSubmatch(filter, -1) {
+		key, _ := strconv.Unquote(m[1])
+		value, _ := strconv.Unquote(m[2])
+		if run.Metadata.Labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// mask returns the fields of the record selected by the field mask.
+func mask(record *pb.Record, fields string) *pb.Record {
+	if fields == "" {
+		return record
+	}
+	out := &pb.Record{Data: &pb.Any{}}
+	value := map[string]json.RawMessage{}
+	_ = json.Unmarshal(record.Data.Value, &value)
+	masked := map[string]json.RawMessage{}
+	for _, field := range strings.Split(fields, ",") {
+		switch field {
+		case "records.name":
+			out.Name = record.Name
+		case "records.uid":
+			out.Uid = record.Uid
+		case "records.create_time":
+			out.CreateTime = record.CreateTime
+		case "records.data.type":
+			out.Data.Type = record.Data.Type
+		case "records.data", "records.data.value":
+			out.Data.Type = record.Data.Type
+			masked = value
+		default:
+			if key, ok := strings.CutPrefix(field, "records.data.value."); ok {
+				masked[key] = value[key]
+			}
+		}
+	}
+	out.Data.Value, _ = json.Marshal(masked)
+	return out
+}
+
+// runRecord returns the record of the run in the result of the parent run.
+func runRecord(t *testing.T, dataType, result string, run any, created time.Time) *pb.Record {
+	t.Helper()
+	b, err := json.Marshal(run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta := struct {
+		Metadata struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+			UID       string `json:"uid"`
+		} `json:"metadata"`
+	}{}
+	if err := json.Unmarshal(b, &meta); err != nil {
+		t.Fatal(err)
+	}
+	return &pb.Record{
+		Name:       meta.Metadata.Namespace + "/results/" + result + "/records/" + meta.Metadata.UID,
+		Uid:        meta.Metadata.UID,
+		Data:       &pb.Any{Type: dataType, Value: b},
+		CreateTime: timestamppb.New(created),
+	}
+}
@@ -0,0 +1,160 @@
+// Package results extends the Tekton Results CLI with opc specific commands.
+package results
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	opcflags "github.com/openshift-pipelines/opc/pkg/flags"
+	"github.com/tektoncd/results/pkg/cli/common"
+	"github.com/tektoncd/results/pkg/cli/options"
+)
+
+const (
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+	StatusRunning   = "running"
+
+	TimeFieldCreation   = "creation"
+	TimeFieldCompletion = "completion"
+
+	conditionStatus = "data.status.conditions[0].status"
+	conditionReason = "data.status.conditions[0].reason"
+)
+
+// cancelledReasons are the reasons of the Succeeded condition of cancelled
+// PipelineRuns and TaskRuns.
+var cancelledReasons = []string{"Cancelled", "TaskRunCancelled"}
+
+// ListOptions are the Results list options with the additional filters on
+// status, time range, annotations, reason and a raw CEL expression, and the
+// non interactive pagination options.
+type ListOptions struct {
+	options.ListOptions
+	Status     string
+	Since      string
+	Until      string
+	TimeField  string
+	Annotation string
+	Reason     string
+	Filter     string
+	AllPages   bool
+	PageToken  string
+	NoHeaders  bool
+}
+
+// Validate checks the values of the filter options.
+func (o *ListOptions) Validate() error {
+	switch o.Status {
+	case "", StatusSucceeded, StatusFailed, StatusCancelled, StatusRunning:
+	default:
+		return fmt.Errorf("invalid status %q, must be one of %s, %s, %s or %s", o.Status, StatusSucceeded, StatusFailed, StatusCancelled, StatusRunning)
+	}
+	switch o.TimeField {
+	case "", TimeFieldCreation, TimeFieldCompletion:
+	default:
+		return fmt.Errorf("invalid time field %q, must be one of %s or %s", o.TimeField, TimeFieldCreation, TimeFieldCompletion)
+	}
+	if o.Label != "" {
+		if err := common.ValidateLabels(o.Label); err != nil {
+			return err
+		}
+	}
+	if o.Annotation != "" {
+		if err := common.ValidateLabels(o.Annotation); err != nil {
+			return fmt.Errorf("invalid annotation: %w", err)
+		}
+	}
+	return nil
+}
+
+// BuildFilterString returns the CEL filter of the ListRecordsRequest, the
+// filter of the Results CLI ANDed with the additional filters.
+func BuildFilterString(opts *ListOptions, now time.Time) (string, error) {
+	filters := []string{}
+	if f := common.BuildFilterString(&opts.ListOptions); f != "" {
+		filters = append(filters, f)
+	}
+
+	switch opts.Status {
+	case StatusSucceeded:
+		filters = append(filters, fmt.Sprintf(`%s=="True"`, conditionStatus))
+	case StatusFailed:
+		filters = append(filters, fmt.Sprintf(`%s=="False" && %s`, conditionStatus, noneOf(conditionReason, cancelledReasons)))
+	case StatusCancelled:
+		filters = append(filters, fmt.Sprintf(`%s=="False" && %s`, conditionStatus, anyOf(conditionReason, cancelledReasons)))
+	case StatusRunning:
+		filters = append(filters, fmt.Sprintf(`%s=="Unknown"`, conditionStatus))
+	}
+
+	if opts.Reason != "" {
+		filters = append(filters, anyOf(conditionReason, strings.Split(opts.Reason, ",")))
+	}
+
+	since, err := opcflags.ParseTime(opts.Since, now)
+	if err != nil {
+		return "", err
+	}
+	until, err := opcflags.ParseTime(opts.Until, now)
+	if err != nil {
+		return "", err
+	}
+	if !since.IsZero() || !until.IsZero() {
+		filters = append(filters, timeRange(opts.TimeField, since, until))
+	}
+
+	if opts.Annotation != "" {
+		for _, pair := range strings.Split(opts.Annotation, ",") {
+			key, value, _ := strings.Cut(pair, "=")
+			filters = append(filters, fmt.Sprintf(`data.metadata.annotations[%s]==%s`, strconv.Quote(strings.TrimSpace(key)), strconv.Quote(strings.TrimSpace(value))))
+		}
+	}
+
+	if opts.Filter != "" {
+		filters = append(filters, "("+opts.Filter+")")
+	}
+
+	return strings.Join(filters, " && "), nil
+}
+
+// timeRange returns a CEL expression matching the records created or
+// completed between since and until, when they are set. The creation time is
+// the one of the record, the completion time is only in the completed runs.
+func timeRange(timeField string, since, until time.Time) string {
+	field, exprs := "create_time", []string{}
+	if timeField == TimeFieldCompletion {
+		field = "timestamp(data.status.completionTime)"
+		exprs = append(exprs, "has(data.status.completionTime)")
+	}
+	if !since.IsZero() {
+		exprs = append(exprs, fmt.Sprintf(`%s>=timestamp(%q)`, field, since.UTC().Format(time.RFC3339)))
+	}
+	if !until.IsZero() {
+		exprs = append(exprs, fmt.Sprintf(`%s<timestamp(%q)`, field, until.UTC().Format(time.RFC3339)))
+	}
+	return strings.Join(exprs, " && ")
+}
+
+// anyOf returns a CEL expression matching any of the values.
+func anyOf(field string, values []string) string {
+	exprs := make([]string, 0, len(values))
+	for _, v := range values {
+		exprs = append(exprs, fmt.Sprintf("%s==%s", field, strconv.Quote(strings.TrimSpace(v))))
+	}
+	if len(exprs) == 1 {
+		return exprs[0]
+	}
+	return "(" + strings.Join(exprs, " || ") + ")"
+}
+
+// noneOf returns a CEL expression matching none of the values.
+func noneOf(field string, values []string) string {
+	exprs := make([]string, 0, len(values))
+	for _, v := range values {
+		exprs = append(exprs, fmt.Sprintf("%s!=%s", field, strconv.Quote(strings.TrimSpace(v))))
+	}
+	return strings.Join(exprs, " && ")
+}
@@ -0,0 +1,126 @@
+package results
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tektoncd/results/pkg/cli/common"
+	"github.com/tektoncd/results/pkg/cli/options"
+)
+
+func TestBuildFilterString(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		opts    ListOptions
+		want    string
+		wantErr string
+	}{{
+		name: "no filter",
+		opts: ListOptions{},
+		want: "",
+	}, {
+		name: "pipelineruns with label",
+		opts: ListOptions{ListOptions: options.ListOptions{ResourceType: common.ResourceTypePipelineRun, Label: "app=foo"}},
+		want: `(data_type=="tekton.dev/v1.PipelineRun" || data_type=="tekton.dev/v1beta1.PipelineRun") && data.metadata.labels["app"]=="foo"`,
+	}, {
+		name: "succeeded",
+		opts: ListOptions{Status: StatusSucceeded},
+		want: `data.status.conditions[0].status=="True"`,
+	}, {
+		name: "failed",
+		opts: ListOptions{Status: StatusFailed},
+		want: `data.status.conditions[0].status=="False" && data.status.conditions[0].reason!="Cancelled" && data.status.conditions[0].reason!="TaskRunCancelled"`,
+	}, {
+		name: "cancelled",
+		opts: ListOptions{Status: StatusCancelled},
+		want: `data.status.conditions[0].status=="False" && (data.status.conditions[0].reason=="Cancelled" || data.status.conditions[0].reason=="TaskRunCancelled")`,
+	}, {
+		name: "running",
+		opts: ListOptions{Status: StatusRunning},
+		want: `data.status.conditions[0].status=="Unknown"`,
+	}, {
+		name: "reasons",
+		opts: ListOptions{Reason: "Failed, PipelineRunTimeout"},
+		want: `(data.status.conditions[0].reason=="Failed" || data.status.conditions[0].reason=="PipelineRunTimeout")`,
+	}, {
+		name: "created since duration",
+		opts: ListOptions{Since: "2d"},
+		want: `create_time>=timestamp("2024-05-08T12:00:00Z")`,
+	}, {
+		name: "created between timestamps",
+		opts: ListOptions{Since: "2024-05-01T00:00:00+02:00", Until: "2024-05-02T00:00:00Z", TimeField: TimeFieldCreation},
+		want: `create_time>=timestamp("2024-04-30T22:00:00Z") && create_time<timestamp("2024-05-02T00:00:00Z")`,
+	}, {
+		name: "completed until",
+		opts: ListOptions{Until: "12h", TimeField: TimeFieldCompletion},
+		want: `has(data.status.completionTime) && timestamp(data.status.completionTime)<timestamp("2024-05-10T00:00:00Z")`,
+	}, {
+		name: "annotations",
+		opts: ListOptions{Annotation: "team=a, env=prod"},
+		want: `data.metadata.annotations["team"]=="a" && data.metadata.annotations["env"]=="prod"`,
+	}, {
+		name: "raw filter",
+		opts: ListOptions{Status: StatusSucceeded, Filter: `data.spec.timeouts.pipeline=="1h" || true`},
+		want: `data.status.conditions[0].status=="True" && (data.spec.timeouts.pipeline=="1h" || true)`,
+	}, {
+		name:    "invalid since",
+		opts:    ListOptions{Since: "yesterday"},
+		wantErr: `invalid time "yesterday", expected a duration like 7d or 12h or an RFC3339 timestamp`,
+	}, {
+		name:    "invalid until",
+		opts:    ListOptions{Until: "1w"},
+		wantErr: `invalid time "1w", expected a duration like 7d or 12h or an RFC3339 timestamp`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BuildFilterString(&tt.opts, now)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("BuildFilterString() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("BuildFilterString() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    ListOptions
+		wantErr string
+	}{{
+		name: "valid",
+		opts: ListOptions{Status: StatusFailed, TimeField: TimeFieldCompletion, Annotation: "a=b"},
+	}, {
+		name:    "status",
+		opts:    ListOptions{Status: "done"},
+		wantErr: `invalid status "done", must be one of succeeded, failed, cancelled or running`,
+	}, {
+		name:    "time field",
+		opts:    ListOptions{TimeField: "start"},
+		wantErr: `invalid time field "start", must be one of creation or completion`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("Validate() error = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
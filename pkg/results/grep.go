@@ -0,0 +1,286 @@
+package results
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tektoncd/results/pkg/cli/client/logs"
+	"github.com/tektoncd/results/pkg/cli/client/records"
+	"github.com/tektoncd/results/pkg/cli/common"
+	"github.com/tektoncd/results/pkg/cli/flags"
+	"github.com/tektoncd/results/pkg/cli/options"
+	pb "github.com/tektoncd/results/proto/v1alpha2/results_go_proto"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// maxLogLineSize is the size of the longest log line which can be searched.
+const maxLogLineSize = 1024 * 1024
+
+// logPrefix matches the prefix added to each line of the stored logs, the
+// step name for a TaskRun or the task and step names for a PipelineRun.
+var logPrefix = regexp.MustCompile(`^\[([^\]]+)\] ?`)
+
+type grepOptions struct {
+	AllNamespaces bool
+	Pipeline      string
+	Since         string
+	Until         string
+	IgnoreCase    bool
+	FixedStrings  bool
+	MaxRecords    int
+	Concurrency   int
+	Limit         int32
+}
+
+// grepRun is the part of a TaskRun used to describe the matching lines.
+type grepRun struct {
+	Metadata metav1.ObjectMeta `json:"metadata"`
+	Status   struct {
+		StartTime      *metav1.Time `json:"startTime,omitempty"`
+		CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	} `json:"status"`
+}
+
+// grepMatch is a log line matching the pattern.
+type grepMatch struct {
+	Time time.Time
+	Task string
+	Step string
+	Line string
+}
+
+type grepResult struct {
+	matches []grepMatch
+	err     error
+}
+
+// LogsGrepCommand returns the command searching the logs of the TaskRuns
+// stored in Results.
+func LogsGrepCommand(p common.Params) *cobra.Command {
+	opts := &grepOptions{Since: "7d", MaxRecords: 500, Concurrency: 4, Limit: 50}
+	eg := `Search the logs of the last 7 days in the current namespace for an error:
+    opc results logs grep "connection reset by peer"
+
+Search the logs of the runs of the Pipeline build of the last 14 days in all namespaces:
+    opc results logs grep "timeout after [0-9]+s" --pipeline build --since 14d -A
+
+Search the logs ignoring the case, without interpreting the pattern as a regular expression:
+    opc results logs grep -i -F "error: exit status 1"
+`
+	cmd := &cobra.Command{
+		Use:   "grep <pattern>",
+		Short: "Search the stored logs of TaskRuns for a regular expression",
+		Long: `Search the logs of the TaskRuns stored in Tekton Results for a regular expression and print the matching lines,
+with the namespace and name of the TaskRun, the task and the step which printed them and their timestamp.
+
+The timestamp is the one at the start of the line when the logs were stored with timestamps, or the start time of
+the TaskRun otherwise. The logs of --concurrency TaskRuns are fetched at the same time, and at most --max-records
+TaskRuns are searched, the most recent first.`,
+		Example: eg,
+		Annotations: map[string]string{
+			"commandType": "main",
+		},
+		Args:              cobra.ExactArgs(1),
+		PersistentPreRunE: persistentPreRunE(p),
+		PreRunE: func(cmd *cobra.Command, _ []string) error {
+			if opts.AllNamespaces && cmd.Flags().Changed("namespace") {
+				return errors.New("cannot use --all-namespaces/-A and --namespace/-n together")
+			}
+			if opts.MaxRecords < 1 {
+				return errors.New("max-records should be greater than 0")
+			}
+			if opts.Concurrency < 1 {
+				return errors.New("concurrency should be greater than 0")
+			}
+			if opts.Limit < 5 || opts.Limit > 1000 {
+				return errors.New("limit should be between 5 and 1000")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			pattern := args[0]
+			if opts.FixedStrings {
+				pattern = regexp.QuoteMeta(pattern)
+			}
+			if opts.IgnoreCase {
+				pattern = "(?i)" + pattern
+			}
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("invalid pattern: %v", err)
+			}
+			return grepLogs(cmd, p, opts, re, time.Now())
+		},
+	}
+	flags.AddResultsOptions(cmd)
+
+	cmd.Flags().BoolVarP(&opts.AllNamespaces, "all-namespaces", "A", false, "Search the logs of the runs of all namespaces")
+	cmd.Flags().StringVar(&opts.Pipeline, "pipeline", "", "Only search the logs of the TaskRuns of this Pipeline")
+	cmd.Flags().StringVar(&opts.Since, "since", opts.Since, "Only search runs created after this time (duration like 30d, 12h or RFC3339 timestamp)")
+	cmd.Flags().StringVar(&opts.Until, "until", "", "Only search runs created before this time (duration like 30d, 12h or RFC3339 timestamp)")
+	cmd.Flags().BoolVarP(&opts.IgnoreCase, "ignore-case", "i", false, "Ignore the case of the pattern and of the logs")
+	cmd.Flags().BoolVarP(&opts.FixedStrings, "fixed-strings", "F", false, "Interpret the pattern as a fixed string instead of a regular expression")
+	cmd.Flags().IntVar(&opts.MaxRecords, "max-records", opts.MaxRecords, "Maximum number of TaskRuns whose logs are searched")
+	cmd.Flags().IntVar(&opts.Concurrency, "concurrency", opts.Concurrency, "Number of logs fetched at the same time")
+	cmd.Flags().Int32Var(&opts.Limit, "limit", opts.Limit, "Number of records fetched per page (must be between 5 and 1000)")
+
+	return cmd
+}
+
+func grepLogs(cmd *cobra.Command, p common.Params, opts *grepOptions, re *regexp.Regexp, now time.Time) error {
+	label := ""
+	if opts.Pipeline != "" {
+		label = pipelineLabel + "=" + opts.Pipeline
+	}
+	filter, err := BuildFilterString(&ListOptions{
+		ListOptions: options.ListOptions{ResourceType: common.ResourceTypeTaskRun, Label: label},
+		Since:       opts.Since,
+		Until:       opts.Until,
+	}, now)
+	if err != nil {
+		return err
+	}
+
+	parent := fmt.Sprintf("%s/results/-", p.Namespace())
+	if opts.AllNamespaces {
+		parent = common.AllNamespacesResultsParent
+	}
+	req := &pb.ListRecordsRequest{
+		Parent:   parent,
+		Filter:   filter,
+		OrderBy:  "create_time desc",
+		PageSize: opts.Limit,
+	}
+
+	recordClient := records.NewClient(p.RESTClient())
+	logClient := logs.NewClient(p.RESTClient())
+	out := cmd.OutOrStdout()
+	errOut := cmd.ErrOrStderr()
+	sem := make(chan struct{}, opts.Concurrency)
+	searched, matched, lines := 0, 0, 0
+	truncated := false
+
+	for {
+		resp, err := recordClient.ListRecords(cmd.Context(), req, common.ListFields)
+		if err != nil {
+			return err
+		}
+
+		runs := []*grepRun{}
+		names := []string{}
+		for _, record := range resp.Records {
+			if searched+len(runs) >= opts.MaxRecords {
+				truncated = true
+				break
+			}
+			run := &grepRun{}
+			if err := json.Unmarshal(record.Data.Value, run); err != nil {
+				return fmt.Errorf("failed to unmarshal record %s: %w", record.Name, err)
+			}
+			// the logs are only stored once the TaskRun has completed
+			if run.Status.CompletionTime == nil {
+				continue
+			}
+			runs = append(runs, run)
+			names = append(names, record.Name)
+		}
+
+		// the logs are fetched concurrently but printed in the order of the
+		// records, the most recent first
+		results := make([]chan grepResult, len(runs))
+		for i := range runs {
+			results[i] = make(chan grepResult, 1)
+			go func(i int) {
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				matches, err := grepLog(cmd, logClient, names[i], runs[i], re)
+				results[i] <- grepResult{matches: matches, err: err}
+			}(i)
+		}
+		for i, run := range runs {
+			res := <-results[i]
+			searched++
+			if res.err != nil {
+				fmt.Fprintf(errOut, "failed to get the log of TaskRun %s/%s: %v\n", run.Metadata.Namespace, run.Metadata.Name, res.err)
+				continue
+			}
+			if len(res.matches) > 0 {
+				matched++
+			}
+			for _, m := range res.matches {
+				lines++
+				if _, err := fmt.Fprintf(out, "%s %s/%s %s/%s: %s\n", m.Time.UTC().Format(time.RFC3339),
+					run.Metadata.Namespace, run.Metadata.Name, m.Task, m.Step, m.Line); err != nil {
+					return err
+				}
+			}
+		}
+
+		if resp.NextPageToken == "" || truncated {
+			break
+		}
+		req.PageToken = resp.NextPageToken
+	}
+
+	fmt.Fprintf(errOut, "%d matching lines in the logs of %d of %d TaskRuns\n", lines, matched, searched)
+	if truncated {
+		fmt.Fprintf(errOut, "Only the %d most recent TaskRuns were searched, use --max-records to search more\n", opts.MaxRecords)
+	}
+	return nil
+}
+
+// grepLog returns the lines of the log of the record matching the pattern.
+func grepLog(cmd *cobra.Command, c *logs.Client, name string, run *grepRun, re *regexp.Regexp) ([]grepMatch, error) {
+	reader, err := c.GetLog(cmd.Context(), &pb.GetLogRequest{Name: name})
+	if err != nil {
+		return nil, err
+	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	task := labelOr(run.Metadata.Labels, pipelineTaskLabel, labelOr(run.Metadata.Labels, taskLabel, "-"))
+	var start time.Time
+	if run.Status.StartTime != nil {
+		start = run.Status.StartTime.Time
+	}
+
+	matches := []grepMatch{}
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLogLineSize)
+	for scanner.Scan() {
+		m := parseLogLine(scanner.Text(), task, start)
+		if !re.MatchString(m.Line) {
+			continue
+		}
+		matches = append(matches, m)
+	}
+	return matches, scanner.Err()
+}
+
+// parseLogLine splits the step prefix and the timestamp from the stored log
+// line, the task and start time are used when the line doesn't have them.
+func parseLogLine(line, task string, start time.Time) grepMatch {
+	m := grepMatch{Time: start, Task: task, Step: "-", Line: line}
+	if prefix := logPrefix.FindStringSubmatch(line); prefix != nil {
+		m.Line = line[len(prefix[0]):]
+		m.Step = prefix[1]
+		// the logs of a PipelineRun are prefixed with "task : step"
+		if t, s, ok := strings.Cut(prefix[1], " : "); ok {
+			m.Task, m.Step = t, s
+		}
+	}
+	if ts, rest, ok := strings.Cut(m.Line, " "); ok {
+		if t, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+			m.Time, m.Line = t, rest
+		}
+	}
+	return m
+}
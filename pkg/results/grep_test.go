@@ -0,0 +1,58 @@
+package results
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseLogLine(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	logged := time.Date(2024, 5, 1, 10, 0, 5, 0, time.UTC)
+	tests := []struct {
+		name string
+		line string
+		want grepMatch
+	}{{
+		name: "plain line",
+		line: "error: failed",
+		want: grepMatch{Time: start, Task: "build", Step: "-", Line: "error: failed"},
+	}, {
+		name: "taskrun step prefix",
+		line: "[compile] error: failed",
+		want: grepMatch{Time: start, Task: "build", Step: "compile", Line: "error: failed"},
+	}, {
+		name: "pipelinerun task and step prefix",
+		line: "[test : unit] error: failed",
+		want: grepMatch{Time: start, Task: "test", Step: "unit", Line: "error: failed"},
+	}, {
+		name: "prefix and timestamp",
+		line: "[compile] 2024-05-01T10:00:05Z error: failed",
+		want: grepMatch{Time: logged, Task: "build", Step: "compile", Line: "error: failed"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseLogLine(tt.line, "build", start)
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("parseLogLine() mismatch (-want +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestParseLogLineAnchoredPattern(t *testing.T) {
+	re := regexp.MustCompile(`^error:`)
+	for _, line := range []string{
+		"[compile] 2024-05-01T10:00:05Z error: failed",
+		"[test : unit] error: failed",
+	} {
+		if m := parseLogLine(line, "build", time.Time{}); !re.MatchString(m.Line) {
+			t.Errorf("pattern %s doesn't match the message %q of %q", re, m.Line, line)
+		}
+	}
+	if m := parseLogLine("[compile] no error: here", "build", time.Time{}); re.MatchString(m.Line) {
+		t.Errorf("pattern %s matches the message %q", re, m.Line)
+	}
+}
@@ -0,0 +1,476 @@
+package results
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/tektoncd/cli/pkg/actions"
+	"github.com/tektoncd/cli/pkg/cli"
+	"github.com/tektoncd/cli/pkg/formatted"
+	prsort "github.com/tektoncd/cli/pkg/pipelinerun/sort"
+	trsort "github.com/tektoncd/cli/pkg/taskrun/sort"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"github.com/tektoncd/results/pkg/cli/client"
+	"github.com/tektoncd/results/pkg/cli/client/records"
+	resultspipelinerun "github.com/tektoncd/results/pkg/cli/cmd/pipelinerun"
+	resultstaskrun "github.com/tektoncd/results/pkg/cli/cmd/taskrun"
+	"github.com/tektoncd/results/pkg/cli/common"
+	"github.com/tektoncd/results/pkg/cli/common/prerun"
+	"github.com/tektoncd/results/pkg/cli/options"
+	pb "github.com/tektoncd/results/proto/v1alpha2/results_go_proto"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
+	cliopts "k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+// maxArchivedRuns is the number of archived runs listed without --limit.
+const maxArchivedRuns = 500
+
+var (
+	pipelineRunGroupResource = schema.GroupVersionResource{Group: "tekton.dev", Resource: "pipelineruns"}
+	taskRunGroupResource     = schema.GroupVersionResource{Group: "tekton.dev", Resource: "taskruns"}
+)
+
+const historyPipelineRunTemplate = `{{- $prl := len .PipelineRuns.Items -}}{{- if eq $prl 0 -}}
+No PipelineRuns found
+{{ else -}}
+{{- if not $.NoHeaders -}}
+{{- if $.AllNamespaces -}}
+NAMESPACE	NAME	STARTED	DURATION	STATUS	SOURCE
+{{ else -}}
+NAME	STARTED	DURATION	STATUS	SOURCE
+{{ end -}}
+{{- end -}}
+{{- range $_, $pr := .PipelineRuns.Items }}{{- if $pr }}{{- if $.AllNamespaces -}}
+{{ $pr.Namespace }}	{{ $pr.Name }}	{{ formatAge $pr.Status.StartTime $.Time }}	{{ formatDuration $pr.Status.StartTime $pr.Status.CompletionTime }}	{{ formatCondition $pr.Status.Conditions }}	{{ source $pr.UID }}
+{{ else -}}
+{{ $pr.Name }}	{{ formatAge $pr.Status.StartTime $.Time }}	{{ formatDuration $pr.Status.StartTime $pr.Status.CompletionTime }}	{{ formatCondition $pr.Status.Conditions }}	{{ source $pr.UID }}
+{{ end -}}{{- end -}}{{- end -}}
+{{- end -}}`
+
+const historyTaskRunTemplate = `{{- $trl := len .TaskRuns.Items -}}{{- if eq $trl 0 -}}
+No TaskRuns found
+{{ else -}}
+{{- if not $.NoHeaders -}}
+{{- if $.AllNamespaces -}}
+NAMESPACE	NAME	STARTED	DURATION	STATUS	SOURCE
+{{ else -}}
+NAME	STARTED	DURATION	STATUS	SOURCE
+{{ end -}}
+{{- end -}}
+{{- range $_, $tr := .TaskRuns.Items }}{{- if $tr }}{{- if $.AllNamespaces -}}
+{{ $tr.Namespace }}	{{ $tr.Name }}	{{ formatAge $tr.Status.StartTime $.Time }}	{{ formatDuration $tr.Status.StartTime $tr.Status.CompletionTime }}	{{ formatCondition $tr.Status.Conditions }}	{{ source $tr.UID }}
+{{ else -}}
+{{ $tr.Name }}	{{ formatAge $tr.Status.StartTime $.Time }}	{{ formatDuration $tr.Status.StartTime $tr.Status.CompletionTime }}	{{ formatCondition $tr.Status.Conditions }}	{{ source $tr.UID }}
+{{ end -}}{{- end -}}{{- end -}}
+{{- end -}}`
+
+// AddHistory adds the --history flag to the list, describe and logs
+// subcommands of the tkn pipelinerun or taskrun command, so that the runs
+// archived in Tekton Results are shown with the runs of the cluster.
+func AddHistory(parent *cobra.Command, p cli.Params, resourceType string) {
+	for _, c := range parent.Commands() {
+		switch c.Name() {
+		case "list":
+			addListHistory(c, p, resourceType)
+		case "describe", "logs":
+			addFallbackHistory(c, p, resourceType)
+		}
+	}
+}
+
+// addListHistory merges the runs of the cluster with the runs archived in
+// Results when --history is set.
+func addListHistory(c *cobra.Command, p cli.Params, resourceType string) {
+	runE := c.RunE
+	history := false
+	c.RunE = func(cmd *cobra.Command, args []string) error {
+		if !history {
+			return runE(cmd, args)
+		}
+		return listHistory(cmd, p, resourceType, args)
+	}
+	c.Flags().BoolVar(&history, "history", false, "also list the runs archived in Tekton Results, each run is marked as live or archived")
+}
+
+// addFallbackHistory falls back to the run archived in Results when the run
+// is not found in the cluster and --history is set.
+func addFallbackHistory(c *cobra.Command, p cli.Params, resourceType string) {
+	runE := c.RunE
+	history := false
+	c.RunE = func(cmd *cobra.Command, args []string) error {
+		if !history || len(args) == 0 {
+			return runE(cmd, args)
+		}
+
+		cs, err := p.Clients()
+		if err != nil {
+			return err
+		}
+		gr, kind := pipelineRunGroupResource, "PipelineRun"
+		if resourceType == common.ResourceTypeTaskRun {
+			gr, kind = taskRunGroupResource, "TaskRun"
+		}
+		_, err = actions.GetUnstructured(gr, cs, args[0], p.Namespace(), metav1.GetOptions{})
+		if err == nil {
+			return runE(cmd, args)
+		}
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+
+		fmt.Fprintf(cmd.ErrOrStderr(), "%s %s not found in namespace %s, showing the %s archived in Tekton Results\n", kind, args[0], p.Namespace(), kind)
+		output := ""
+		if f := cmd.LocalFlags().Lookup("output"); f != nil {
+			output = f.Value.String()
+		}
+		if cmd.Name() == "describe" && output != "" {
+			return printArchivedRun(cmd, p, resourceType, args[0], output)
+		}
+		return runResultsCommand(cmd, p, resourceType, cmd.Name(), args[0])
+	}
+	c.Flags().BoolVar(&history, "history", false, "use the run archived in Tekton Results when it is not found in the cluster")
+}
+
+// resultsParams returns the Results params with the cluster and namespace of
+// the tkn command and an initialized client.
+func resultsParams(cmd *cobra.Command, p cli.Params) (*common.ResultsParams, error) {
+	rp := &common.ResultsParams{}
+	if f := cmd.Flags().Lookup("kubeconfig"); f != nil {
+		rp.SetKubeConfigPath(f.Value.String())
+	}
+	if f := cmd.Flags().Lookup("context"); f != nil {
+		rp.SetKubeContext(f.Value.String())
+	}
+	rp.SetNamespace(p.Namespace())
+	restClient, err := prerun.InitClient(rp, cmd)
+	if err != nil {
+		return nil, err
+	}
+	rp.SetRESTClient(restClient)
+	return rp, nil
+}
+
+// runResultsCommand runs the describe or logs command of the Results CLI.
+func runResultsCommand(cmd *cobra.Command, p cli.Params, resourceType, name, run string) error {
+	rp := &common.ResultsParams{}
+	c := resultspipelinerun.Command(rp)
+	if resourceType == common.ResourceTypeTaskRun {
+		c = resultstaskrun.Command(rp)
+	}
+	args := []string{name, run, "--namespace", p.Namespace()}
+	for _, flag := range []string{"kubeconfig", "context"} {
+		if f := cmd.Flags().Lookup(flag); f != nil && f.Value.String() != "" {
+			args = append(args, "--"+flag, f.Value.String())
+		}
+	}
+	if resourceType == common.ResourceTypePipelineRun && name == "logs" {
+		for _, sub := range c.Commands() {
+			if sub.Name() == name {
+				c.RemoveCommand(sub)
+			}
+		}
+		c.AddCommand(PipelineRunLogsCommand(rp))
+		// the filters of the logs of the cluster apply to the stored logs
+		for _, flag := range []string{"task", "log-failed", "prefix", "long"} {
+			f := cmd.Flags().Lookup(flag)
+			if f == nil || !f.Changed {
+				continue
+			}
+			if sf, ok := f.Value.(pflag.SliceValue); ok {
+				for _, v := range sf.GetSlice() {
+					args = append(args, "--"+flag, v)
+				}
+				continue
+			}
+			args = append(args, "--"+flag+"="+f.Value.String())
+		}
+	}
+	c.SetArgs(args)
+	c.SetIn(cmd.InOrStdin())
+	c.SetOut(cmd.OutOrStdout())
+	c.SetErr(cmd.ErrOrStderr())
+	c.SilenceErrors = true
+	c.SilenceUsage = true
+	return c.ExecuteContext(cmd.Context())
+}
+
+// printArchivedRun prints the run archived in Results in the given output
+// format.
+func printArchivedRun(cmd *cobra.Command, p cli.Params, resourceType, name, output string) error {
+	rp, err := resultsParams(cmd, p)
+	if err != nil {
+		return err
+	}
+	record, err := getRecord(cmd.Context(), rp, &options.DescribeOptions{ResourceType: resourceType, ResourceName: name})
+	if err != nil {
+		return err
+	}
+	list, err := parseRecords(resourceType, []*pb.Record{record}, "")
+	if err != nil {
+		return err
+	}
+	var obj runtime.Object
+	switch l := list.(type) {
+	case *v1.PipelineRunList:
+		obj = &l.Items[0]
+	case *v1.TaskRunList:
+		obj = &l.Items[0]
+	}
+
+	f := cliopts.NewPrintFlags("")
+	f.OutputFormat = &output
+	printer, err := f.ToPrinter()
+	if err != nil {
+		return err
+	}
+	return printer.PrintObj(obj, cmd.OutOrStdout())
+}
+
+func listHistory(cmd *cobra.Command, p cli.Params, resourceType string, args []string) error {
+	flags := cmd.Flags()
+	limit, _ := flags.GetInt("limit")
+	selector, _ := flags.GetString("label")
+	reverse, _ := flags.GetBool("reverse")
+	allNamespaces, _ := flags.GetBool("all-namespaces")
+	noHeaders, _ := flags.GetBool("no-headers")
+	output, _ := flags.GetString("output")
+
+	if limit < 0 {
+		return fmt.Errorf("limit was %d but must be a positive number", limit)
+	}
+	if len(args) > 0 {
+		if selector != "" {
+			return fmt.Errorf("specifying a %s and labels are not compatible", map[string]string{common.ResourceTypePipelineRun: "Pipeline", common.ResourceTypeTaskRun: "Task"}[resourceType])
+		}
+		selector = pipelineLabel + "=" + args[0]
+		if resourceType == common.ResourceTypeTaskRun {
+			selector = taskLabel + "=" + args[0]
+		}
+	}
+
+	cs, err := p.Clients()
+	if err != nil {
+		return err
+	}
+	ns := p.Namespace()
+	if allNamespaces {
+		ns = ""
+	}
+
+	rp, err := resultsParams(cmd, p)
+	var archived []*pb.Record
+	if err == nil {
+		archived, err = listArchived(cmd, rp.RESTClient(), p.Namespace(), resourceType, selector, allNamespaces, limit)
+	}
+	if err != nil {
+		// the live runs are still listed when Results cannot be reached
+		fmt.Fprintf(cmd.ErrOrStderr(), "Runs archived in Tekton Results are not listed: %v\n", err)
+		archived = []*pb.Record{}
+	}
+	list, err := parseRecords(resourceType, archived, "")
+	if err != nil {
+		return err
+	}
+
+	var runs runtime.Object
+	sources := map[types.UID]string{}
+	switch l := list.(type) {
+	case *v1.PipelineRunList:
+		prs := &v1.PipelineRunList{}
+		if err := actions.ListV1(pipelineRunGroupResource, cs, metav1.ListOptions{LabelSelector: selector}, ns, prs); err != nil {
+			return fmt.Errorf("failed to list PipelineRuns from namespace %s: %v", p.Namespace(), err)
+		}
+		for _, pr := range prs.Items {
+			sources[pr.UID] = sourceCluster
+		}
+		for _, pr := range l.Items {
+			if _, ok := sources[pr.UID]; !ok {
+				sources[pr.UID] = sourceResults
+				prs.Items = append(prs.Items, pr)
+			}
+		}
+		if allNamespaces {
+			prsort.SortByNamespace(prs.Items)
+		} else {
+			prsort.SortByStartTime(prs.Items)
+		}
+		if limit > 0 && len(prs.Items) > limit {
+			prs.Items = prs.Items[:limit]
+		}
+		if reverse {
+			slices.Reverse(prs.Items)
+		}
+		prs.APIVersion, prs.Kind = v1.SchemeGroupVersion.String(), "PipelineRunList"
+		runs = prs
+	case *v1.TaskRunList:
+		trs := &v1.TaskRunList{}
+		if err := actions.ListV1(taskRunGroupResource, cs, metav1.ListOptions{LabelSelector: selector}, ns, trs); err != nil {
+			return fmt.Errorf("failed to list TaskRuns from namespace %s: %v", p.Namespace(), err)
+		}
+		for _, tr := range trs.Items {
+			sources[tr.UID] = sourceCluster
+		}
+		for _, tr := range l.Items {
+			if _, ok := sources[tr.UID]; !ok {
+				sources[tr.UID] = sourceResults
+				trs.Items = append(trs.Items, tr)
+			}
+		}
+		if allNamespaces {
+			trsort.SortByNamespace(trs.Items)
+		} else {
+			trsort.SortByStartTime(trs.Items)
+		}
+		if limit > 0 && len(trs.Items) > limit {
+			trs.Items = trs.Items[:limit]
+		}
+		if reverse {
+			slices.Reverse(trs.Items)
+		}
+		trs.APIVersion, trs.Kind = v1.SchemeGroupVersion.String(), "TaskRunList"
+		runs = trs
+	}
+
+	out := cmd.OutOrStdout()
+	if output != "" {
+		f := cliopts.NewPrintFlags("list")
+		f.OutputFormat = &output
+		if t := flags.Lookup("template"); t != nil {
+			tmpl := t.Value.String()
+			f.TemplatePrinterFlags.TemplateArgument = &tmpl
+		}
+		printer, err := listPrinter(f, output)
+		if err != nil {
+			return err
+		}
+		return printList(out, printer, output, runs, false, false)
+	}
+	return printHistory(out, runs, sources, p, allNamespaces, noHeaders)
+}
+
+// listArchived returns the records of the runs archived in Results matching
+// the label selector, at most limit records are returned, or maxArchivedRuns
+// records when it is not set.
+func listArchived(cmd *cobra.Command, rc *client.RESTClient, ns, resourceType, selector string, allNamespaces bool, limit int) ([]*pb.Record, error) {
+	filter, err := selectorFilter(selector)
+	if err != nil {
+		return nil, err
+	}
+	filters := []string{common.BuildFilterString(&options.ListOptions{ResourceType: resourceType})}
+	if filter != "" {
+		filters = append(filters, filter)
+	}
+
+	parent := fmt.Sprintf("%s/results/-", ns)
+	if allNamespaces {
+		parent = common.AllNamespacesResultsParent
+	}
+	req := &pb.ListRecordsRequest{
+		Parent:   parent,
+		Filter:   strings.Join(filters, " && "),
+		OrderBy:  "create_time desc",
+		PageSize: 100,
+	}
+
+	maxRuns := limit
+	if maxRuns <= 0 {
+		maxRuns = maxArchivedRuns
+	}
+	recordClient := records.NewClient(rc)
+	result := []*pb.Record{}
+	for {
+		resp, err := recordClient.ListRecords(cmd.Context(), req, common.ListFields)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, resp.Records...)
+		if len(result) >= maxRuns {
+			if limit <= 0 && (len(result) > maxRuns || resp.NextPageToken != "") {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Only the %d most recent runs archived in Tekton Results are listed, use --limit to list more\n", maxRuns)
+			}
+			return result[:maxRuns], nil
+		}
+		if resp.NextPageToken == "" {
+			return result, nil
+		}
+		req.PageToken = resp.NextPageToken
+	}
+}
+
+// selectorFilter converts a label selector to a CEL filter of the records.
+func selectorFilter(selector string) (string, error) {
+	if selector == "" {
+		return "", nil
+	}
+	s, err := labels.Parse(selector)
+	if err != nil {
+		return "", err
+	}
+	reqs, _ := s.Requirements()
+	filters := []string{}
+	for _, r := range reqs {
+		field := fmt.Sprintf("data.metadata.labels[%s]", strconv.Quote(r.Key()))
+		switch r.Operator() {
+		case selection.Equals, selection.DoubleEquals, selection.In:
+			filters = append(filters, anyOf(field, r.Values().List()))
+		case selection.NotEquals, selection.NotIn:
+			filters = append(filters, noneOf(field, r.Values().List()))
+		default:
+			return "", fmt.Errorf("label selector %q is not supported with --history, only '=', '==', '!=', 'in' and 'notin' are", r.String())
+		}
+	}
+	return strings.Join(filters, " && "), nil
+}
+
+func printHistory(out io.Writer, list runtime.Object, sources map[types.UID]string, p cli.Params, allNamespaces, noHeaders bool) error {
+	var data = struct {
+		PipelineRuns  *v1.PipelineRunList
+		TaskRuns      *v1.TaskRunList
+		Time          clockwork.Clock
+		AllNamespaces bool
+		NoHeaders     bool
+	}{
+		Time:          p.Time(),
+		AllNamespaces: allNamespaces,
+		NoHeaders:     noHeaders,
+	}
+
+	tmpl := historyPipelineRunTemplate
+	switch l := list.(type) {
+	case *v1.PipelineRunList:
+		data.PipelineRuns = l
+	case *v1.TaskRunList:
+		data.TaskRuns = l
+		tmpl = historyTaskRunTemplate
+	}
+
+	funcMap := template.FuncMap{
+		"formatAge":       formatted.Age,
+		"formatDuration":  formatted.Duration,
+		"formatCondition": formatted.Condition,
+		"source": func(uid types.UID) string {
+			return sources[uid]
+		},
+	}
+
+	w := tabwriter.NewWriter(out, 0, 5, 3, ' ', tabwriter.TabIndent)
+	t := template.Must(template.New("List Runs").Funcs(funcMap).Parse(tmpl))
+	if err := t.Execute(w, data); err != nil {
+		return err
+	}
+	return w.Flush()
+}
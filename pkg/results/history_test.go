@@ -0,0 +1,101 @@
+package results
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/tektoncd/results/pkg/cli/common"
+)
+
+func TestListArchived(t *testing.T) {
+	tests := []struct {
+		name    string
+		records int
+		limit   int
+		want    int
+		warning string
+	}{{
+		name:    "all the runs",
+		records: 150,
+		want:    150,
+	}, {
+		name:    "limit",
+		records: 150,
+		limit:   120,
+		want:    120,
+	}, {
+		name:    "default bound",
+		records: maxArchivedRuns + 50,
+		want:    maxArchivedRuns,
+		warning: "Only the 500 most recent runs archived in Tekton Results are listed, use --limit to list more\n",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, p := newFakeResults(t, 100, pipelineRunRecords(t, tt.records)...)
+			errOut := &bytes.Buffer{}
+			cmd := &cobra.Command{}
+			cmd.SetContext(context.Background())
+			cmd.SetErr(errOut)
+
+			got, err := listArchived(cmd, p.RESTClient(), "ns", common.ResourceTypePipelineRun, "app=foo", false, tt.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != tt.want {
+				t.Errorf("listArchived() returned %d records, want %d", len(got), tt.want)
+			}
+			if errOut.String() != tt.warning {
+				t.Errorf("warning = %q, want %q", errOut.String(), tt.warning)
+			}
+			if pages := (tt.want + 99) / 100; len(fake.queries) != pages {
+				t.Errorf("got %d list requests, want %d", len(fake.queries), pages)
+			}
+			want := `(data_type=="tekton.dev/v1.PipelineRun" || data_type=="tekton.dev/v1beta1.PipelineRun") && data.metadata.labels["app"]=="foo"`
+			if got := fake.queries[0].Get("filter"); got != want {
+				t.Errorf("filter = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestSelectorFilter(t *testing.T) {
+	tests := []struct {
+		selector string
+		want     string
+		wantErr  string
+	}{{
+		selector: "",
+		want:     "",
+	}, {
+		selector: "app=foo,env!=prod",
+		want:     `data.metadata.labels["app"]=="foo" && data.metadata.labels["env"]!="prod"`,
+	}, {
+		selector: "env in (dev, qa)",
+		want:     `(data.metadata.labels["env"]=="dev" || data.metadata.labels["env"]=="qa")`,
+	}, {
+		selector: "env notin (prod)",
+		want:     `data.metadata.labels["env"]!="prod"`,
+	}, {
+		selector: "app",
+		wantErr:  `label selector "app" is not supported with --history, only '=', '==', '!=', 'in' and 'notin' are`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			got, err := selectorFilter(tt.selector)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("selectorFilter() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("selectorFilter() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
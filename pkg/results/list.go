@@ -0,0 +1,377 @@
+package results
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/spf13/cobra"
+	"github.com/tektoncd/cli/pkg/formatted"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"github.com/tektoncd/results/pkg/cli/client/records"
+	"github.com/tektoncd/results/pkg/cli/common"
+	"github.com/tektoncd/results/pkg/cli/options"
+	pb "github.com/tektoncd/results/proto/v1alpha2/results_go_proto"
+	"golang.org/x/term"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	cliopts "k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+)
+
+const prListTemplate = `{{- $prl := len .PipelineRuns.Items -}}{{- if eq $prl 0 -}}
+No PipelineRuns found
+{{ else -}}
+{{- if not $.NoHeaders -}}
+{{- if $.AllNamespaces -}}
+NAMESPACE	NAME	UID	STARTED	DURATION	STATUS
+{{ else -}}
+NAME	UID	STARTED	DURATION	STATUS
+{{ end -}}
+{{- end -}}
+{{- range $_, $pr := .PipelineRuns.Items }}{{- if $pr }}{{- if $.AllNamespaces -}}
+{{ $pr.Namespace }}	{{ $pr.Name }}	{{ $pr.UID }}	{{ formatAge $pr.Status.StartTime $.Time }}	{{ formatDuration $pr.Status.StartTime $pr.Status.CompletionTime }}	{{ formatCondition $pr.Status.Conditions }}
+{{ else -}}
+{{ $pr.Name }}	{{ $pr.UID }}	{{ formatAge $pr.Status.StartTime $.Time }}	{{ formatDuration $pr.Status.StartTime $pr.Status.CompletionTime }}	{{ formatCondition $pr.Status.Conditions }}
+{{ end -}}{{- end -}}{{- end -}}
+{{- end -}}`
+
+const trListTemplate = `{{- $trl := len .TaskRuns.Items -}}{{- if eq $trl 0 -}}
+No TaskRuns found
+{{ else -}}
+{{- if not $.NoHeaders -}}
+{{- if $.AllNamespaces -}}
+NAMESPACE	NAME	UID	STARTED	DURATION	STATUS
+{{ else -}}
+NAME	UID	STARTED	DURATION	STATUS
+{{ end -}}
+{{- end -}}
+{{- range $_, $tr := .TaskRuns.Items }}{{- if $tr }}{{- if $.AllNamespaces -}}
+{{ $tr.Namespace }}	{{ $tr.Name }}	{{ $tr.UID }}	{{ formatAge $tr.Status.StartTime $.Time }}	{{ formatDuration $tr.Status.StartTime $tr.Status.CompletionTime }}	{{ formatCondition $tr.Status.Conditions }}
+{{ else -}}
+{{ $tr.Name }}	{{ $tr.UID }}	{{ formatAge $tr.Status.StartTime $.Time }}	{{ formatDuration $tr.Status.StartTime $tr.Status.CompletionTime }}	{{ formatCondition $tr.Status.Conditions }}
+{{ end -}}{{- end -}}{{- end -}}
+{{- end -}}`
+
+// PipelineRunListCommand returns the Results pipelinerun list command with
+// the additional filters.
+func PipelineRunListCommand(p common.Params) *cobra.Command {
+	eg := `List the PipelineRuns of the Pipeline foo which failed in the last 7 days:
+    opc results pipelinerun list foo --status failed --since 7d
+
+List the PipelineRuns completed in a time range:
+    opc results pipelinerun list --time-field completion --since 2024-05-01T00:00:00Z --until 2024-06-01T00:00:00Z
+
+List the PipelineRuns with an annotation:
+    opc results pipelinerun list --annotation pipelinesascode.tekton.dev/event-type=pull_request
+
+List the PipelineRuns which timed out:
+    opc results pipelinerun list --reason PipelineRunTimeout
+
+List the PipelineRuns matching a CEL expression:
+    opc results pipelinerun list --filter 'data.spec.taskRunTemplate.serviceAccountName=="builder"'
+`
+	return listCommand(p, common.ResourceTypePipelineRun, "list [pipeline-name]", "List PipelineRuns in a namespace", eg)
+}
+
+// TaskRunListCommand returns the Results taskrun list command with the
+// additional filters.
+func TaskRunListCommand(p common.Params) *cobra.Command {
+	eg := `List the TaskRuns of the Task foo which failed in the last 7 days:
+    opc results taskrun list foo --status failed --since 7d
+
+List the TaskRuns of a PipelineRun which were cancelled:
+    opc results taskrun list --pipelinerun my-pipeline-run --status cancelled
+
+List the TaskRuns matching a CEL expression:
+    opc results taskrun list --filter 'data.metadata.labels["tekton.dev/pipelineTask"]=="build"'
+`
+	return listCommand(p, common.ResourceTypeTaskRun, "list [task-name]", "List TaskRuns in a namespace", eg)
+}
+
+func listCommand(p common.Params, resourceType, use, short, eg string) *cobra.Command {
+	opts := &ListOptions{
+		ListOptions: options.ListOptions{
+			Limit:        50,
+			SinglePage:   true,
+			ResourceType: resourceType,
+		},
+	}
+	f := cliopts.NewPrintFlags("list")
+	kind := "PipelineRuns"
+	if resourceType == common.ResourceTypeTaskRun {
+		kind = "TaskRuns"
+	}
+
+	cmd := &cobra.Command{
+		Use:     use,
+		Aliases: []string{"ls"},
+		Short:   short,
+		Annotations: map[string]string{
+			"commandType": "main",
+		},
+		Example: eg,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if opts.AllPages && cmd.Flags().Changed("single-page") && opts.SinglePage {
+				return errors.New("cannot use --all-pages and --single-page together")
+			}
+			allNs, _ := cmd.Flags().GetBool("all-namespaces")
+			nsSet := cmd.Flags().Changed("namespace")
+			if allNs && nsSet {
+				return errors.New("cannot use --all-namespaces/-A and --namespace/-n together")
+			}
+			opts.Client = p.RESTClient()
+			if opts.Limit < 5 || opts.Limit > 1000 {
+				return errors.New("limit should be between 5 and 1000")
+			}
+			if len(args) > 0 {
+				opts.ResourceName = args[0]
+			}
+			return opts.Validate()
+		},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			filter, err := BuildFilterString(opts, time.Now())
+			if err != nil {
+				return err
+			}
+
+			output, err := cmd.LocalFlags().GetString("output")
+			if err != nil {
+				return fmt.Errorf("output option not set properly: %v", err)
+			}
+
+			parent := fmt.Sprintf("%s/results/-", p.Namespace())
+			if opts.AllNamespaces {
+				parent = common.AllNamespacesResultsParent
+			}
+
+			req := &pb.ListRecordsRequest{
+				Parent:    parent,
+				Filter:    filter,
+				OrderBy:   "create_time desc",
+				PageSize:  opts.Limit,
+				PageToken: opts.PageToken,
+			}
+
+			// structured output prints the full records
+			fields := common.ListFields
+			if output != "" {
+				fields = common.NameUIDAndDataField + ",next_page_token"
+			}
+			// the printer is shared by the pages so that the YAML documents
+			// are separated
+			printer, err := listPrinter(f, output)
+			if err != nil {
+				return err
+			}
+
+			// prompting for the next page would hang without a terminal,
+			// all the pages are streamed instead
+			allPages := opts.AllPages || (!opts.SinglePage && !term.IsTerminal(int(os.Stdin.Fd())))
+
+			recordClient := records.NewClient(opts.Client)
+			out := cmd.OutOrStdout()
+			// the rows of all the pages are aligned as a single table, which
+			// is flushed once the last page is printed
+			var table *tabwriter.Writer
+			if output == "" && allPages {
+				table = tabwriter.NewWriter(out, 0, 5, 3, ' ', tabwriter.TabIndent)
+				out = table
+			}
+			reader := bufio.NewReader(os.Stdin)
+			for page := 0; ; page++ {
+				resp, err := recordClient.ListRecords(cmd.Context(), req, fields)
+				if err != nil {
+					return err
+				}
+
+				list, err := parseRecords(resourceType, resp.Records, resp.NextPageToken)
+				if err != nil {
+					return err
+				}
+				if page == 0 || len(resp.Records) > 0 {
+					if err := printList(out, printer, output, list, opts.AllNamespaces, opts.NoHeaders || page > 0); err != nil {
+						return err
+					}
+				}
+
+				if resp.NextPageToken == "" {
+					if table != nil {
+						return table.Flush()
+					}
+					break
+				}
+				if allPages {
+					req.PageToken = resp.NextPageToken
+					continue
+				}
+				if opts.SinglePage || output != "" {
+					_, err := fmt.Fprintf(cmd.ErrOrStderr(), "More %s available, use --page-token %s to get the next page\n", kind, resp.NextPageToken)
+					return err
+				}
+
+				// Interactive pagination
+				if _, err := fmt.Fprintf(out, "\nPress 'n' for next page, 'q' to quit: "); err != nil {
+					return err
+				}
+				input, err := reader.ReadString('\n')
+				if err != nil {
+					return err
+				}
+
+				switch strings.TrimSpace(strings.ToLower(input)) {
+				case "n":
+					req.PageToken = resp.NextPageToken
+				case "q":
+					return nil
+				default:
+					_, err := fmt.Fprintf(out, "Invalid input. Exiting pagination.\n")
+					return err
+				}
+			}
+
+			return nil
+		},
+	}
+	f.AddFlags(cmd)
+
+	cmd.Flags().Int32VarP(&opts.Limit, "limit", "", 50, fmt.Sprintf("Maximum number of %s to return (must be between 5 and 1000 and defaults to 50)", kind))
+	cmd.Flags().BoolVarP(&opts.AllNamespaces, "all-namespaces", "A", false, fmt.Sprintf("List %s from all namespaces", kind))
+	cmd.Flags().StringVarP(&opts.Label, "label", "L", "", "Filter by label (format: key=value,key2=value2)")
+	cmd.Flags().BoolVar(&opts.SinglePage, "single-page", true, "Return only a single page of results")
+	cmd.Flags().BoolVar(&opts.AllPages, "all-pages", false, "Stream all the pages of results without prompting")
+	cmd.Flags().StringVar(&opts.PageToken, "page-token", "", "Token of the page to start from, as printed when more results are available")
+	cmd.Flags().BoolVar(&opts.NoHeaders, "no-headers", false, "Do not print column headers with output")
+	if resourceType == common.ResourceTypeTaskRun {
+		cmd.Flags().StringVarP(&opts.PipelineRun, "pipelinerun", "", "", "Filter TaskRuns by PipelineRun name. Note that multiple PipelineRuns can have the same name, so this will return TaskRuns from all PipelineRuns with the matching name.")
+	}
+	cmd.Flags().StringVar(&opts.Status, "status", "", "Filter by status, one of succeeded, failed, cancelled or running")
+	cmd.Flags().StringVar(&opts.Since, "since", "", "Only list runs after this time (duration like 7d, 12h or RFC3339 timestamp)")
+	cmd.Flags().StringVar(&opts.Until, "until", "", "Only list runs before this time (duration like 7d, 12h or RFC3339 timestamp)")
+	cmd.Flags().StringVar(&opts.TimeField, "time-field", TimeFieldCreation, "Time used by --since and --until, one of creation or completion")
+	cmd.Flags().StringVar(&opts.Annotation, "annotation", "", "Filter by annotation (format: key=value,key2=value2)")
+	cmd.Flags().StringVar(&opts.Reason, "reason", "", "Filter by reason of the Succeeded condition (format: reason1,reason2)")
+	cmd.Flags().StringVar(&opts.Filter, "filter", "", "CEL expression ANDed with the filters generated from the other flags")
+
+	return cmd
+}
+
+// parseRecords converts the records to a PipelineRunList or a TaskRunList,
+// the token of the next page is kept in the list metadata.
+func parseRecords(resourceType string, records []*pb.Record, nextPageToken string) (runtime.Object, error) {
+	if resourceType == common.ResourceTypeTaskRun {
+		trs := &v1.TaskRunList{
+			TypeMeta: metav1.TypeMeta{APIVersion: v1.SchemeGroupVersion.String(), Kind: "TaskRunList"},
+			ListMeta: metav1.ListMeta{Continue: nextPageToken},
+			Items:    []v1.TaskRun{},
+		}
+		for _, record := range records {
+			var tr v1.TaskRun
+			if err := json.Unmarshal(record.Data.Value, &tr); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal JSON: %w", err)
+			}
+			trs.Items = append(trs.Items, tr)
+		}
+		return trs, nil
+	}
+
+	prs := &v1.PipelineRunList{
+		TypeMeta: metav1.TypeMeta{APIVersion: v1.SchemeGroupVersion.String(), Kind: "PipelineRunList"},
+		ListMeta: metav1.ListMeta{Continue: nextPageToken},
+		Items:    []v1.PipelineRun{},
+	}
+	for _, record := range records {
+		var pr v1.PipelineRun
+		if err := json.Unmarshal(record.Data.Value, &pr); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal JSON: %w", err)
+		}
+		prs.Items = append(prs.Items, pr)
+	}
+	return prs, nil
+}
+
+// listPrinter returns the printer of the output format, nil when the runs
+// are printed as a table or by name.
+func listPrinter(f *cliopts.PrintFlags, output string) (printers.ResourcePrinter, error) {
+	if output == "" || output == "name" {
+		return nil, nil
+	}
+	return f.ToPrinter()
+}
+
+// printList prints a page of runs in the given output format with the
+// printer, or as a table when the format is empty. The table is flushed by
+// the caller when out is a tabwriter.
+func printList(out io.Writer, printer printers.ResourcePrinter, output string, list runtime.Object, allNamespaces, noHeaders bool) error {
+	switch {
+	case output == "name":
+		switch l := list.(type) {
+		case *v1.PipelineRunList:
+			for _, pr := range l.Items {
+				if _, err := fmt.Fprintf(out, "pipelinerun.%s/%s\n", v1.SchemeGroupVersion.Group, pr.Name); err != nil {
+					return err
+				}
+			}
+		case *v1.TaskRunList:
+			for _, tr := range l.Items {
+				if _, err := fmt.Fprintf(out, "taskrun.%s/%s\n", v1.SchemeGroupVersion.Group, tr.Name); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	case output != "":
+		return printer.PrintObj(list, out)
+	}
+	return printFormatted(out, list, clockwork.NewRealClock(), allNamespaces, noHeaders)
+}
+
+func printFormatted(out io.Writer, list runtime.Object, c clockwork.Clock, allNamespaces, noHeaders bool) error {
+	var data = struct {
+		PipelineRuns  *v1.PipelineRunList
+		TaskRuns      *v1.TaskRunList
+		Time          clockwork.Clock
+		AllNamespaces bool
+		NoHeaders     bool
+	}{
+		Time:          c,
+		AllNamespaces: allNamespaces,
+		NoHeaders:     noHeaders,
+	}
+
+	tmpl := prListTemplate
+	switch l := list.(type) {
+	case *v1.PipelineRunList:
+		data.PipelineRuns = l
+	case *v1.TaskRunList:
+		data.TaskRuns = l
+		tmpl = trListTemplate
+	}
+
+	funcMap := template.FuncMap{
+		"formatAge":       common.FormatAge,
+		"formatDuration":  formatted.Duration,
+		"formatCondition": formatted.Condition,
+	}
+
+	w, stream := out.(*tabwriter.Writer)
+	if !stream {
+		w = tabwriter.NewWriter(out, 0, 5, 3, ' ', tabwriter.TabIndent)
+	}
+	t := template.Must(template.New("List Runs").Funcs(funcMap).Parse(tmpl))
+	if err := t.Execute(w, data); err != nil {
+		return err
+	}
+	if stream {
+		return nil
+	}
+	return w.Flush()
+}
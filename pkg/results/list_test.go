@@ -0,0 +1,114 @@
+package results
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	pb "github.com/tektoncd/results/proto/v1alpha2/results_go_proto"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func pipelineRunRecords(t *testing.T, n int) []*pb.Record {
+	created := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	records := []*pb.Record{}
+	for i := range n {
+		pr := &v1.PipelineRun{
+			TypeMeta: metav1.TypeMeta{APIVersion: "tekton.dev/v1", Kind: "PipelineRun"},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              fmt.Sprintf("build-%d", i),
+				Namespace:         "ns",
+				UID:               types.UID(fmt.Sprintf("uid-%d", i)),
+				CreationTimestamp: metav1.NewTime(created),
+				Labels:            map[string]string{"app": "foo"},
+			},
+		}
+		records = append(records, runRecord(t, "tekton.dev/v1.PipelineRun", string(pr.UID), pr, created))
+	}
+	return records
+}
+
+func TestListAllPagesStructured(t *testing.T) {
+	for _, output := range []string{"yaml", "json", "name"} {
+		t.Run(output, func(t *testing.T) {
+			fake, p := newFakeResults(t, 2, pipelineRunRecords(t, 5)...)
+			out := &bytes.Buffer{}
+			c := PipelineRunListCommand(p)
+			c.SetOut(out)
+			c.SetArgs([]string{"-o", output, "--all-pages", "--limit", "5"})
+			if err := c.Execute(); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(fake.queries) != 3 {
+				t.Fatalf("got %d list requests, want 3", len(fake.queries))
+			}
+			for i := range 5 {
+				if !strings.Contains(out.String(), fmt.Sprintf("build-%d", i)) {
+					t.Errorf("build-%d missing from the output:\n%s", i, out.String())
+				}
+			}
+			if output == "yaml" {
+				if got := strings.Count(out.String(), "\n---\n"); got != 2 {
+					t.Errorf("got %d YAML document separators, want 2:\n%s", got, out.String())
+				}
+			}
+		})
+	}
+}
+
+func TestListSinglePageStructured(t *testing.T) {
+	_, p := newFakeResults(t, 2, pipelineRunRecords(t, 3)...)
+	out, errOut := &bytes.Buffer{}, &bytes.Buffer{}
+	c := PipelineRunListCommand(p)
+	c.SetOut(out)
+	c.SetErr(errOut)
+	c.SetArgs([]string{"-o", "name", "--single-page"})
+	if err := c.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "pipelinerun.tekton.dev/build-0\npipelinerun.tekton.dev/build-1\n"
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+	if !strings.Contains(errOut.String(), "use --page-token 2 to get the next page") {
+		t.Errorf("next page hint missing: %q", errOut.String())
+	}
+}
+
+func TestListAllPagesTable(t *testing.T) {
+	records := []*pb.Record{}
+	for i, name := range []string{"a", "build", "release-candidate", "b", "deploy-to-production"} {
+		pr := &v1.PipelineRun{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns", UID: types.UID(fmt.Sprintf("uid-%d", i))},
+		}
+		records = append(records, runRecord(t, "tekton.dev/v1.PipelineRun", string(pr.UID), pr, time.Now()))
+	}
+	fake, p := newFakeResults(t, 2, records...)
+	out := &bytes.Buffer{}
+	c := PipelineRunListCommand(p)
+	c.SetOut(out)
+	c.SetArgs([]string{"--all-pages", "--limit", "5"})
+	if err := c.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(fake.queries) != 3 {
+		t.Fatalf("got %d list requests, want 3", len(fake.queries))
+	}
+	want := `NAME                   UID     STARTED   DURATION   STATUS
+a                      uid-0             ---        ---
+build                  uid-1             ---        ---
+release-candidate      uid-2             ---        ---
+b                      uid-3             ---        ---
+deploy-to-production   uid-4             ---        ---
+`
+	if out.String() != want {
+		t.Errorf("output = \n%s\nwant the rows of all the pages aligned:\n%s", out.String(), want)
+	}
+}
@@ -0,0 +1,194 @@
+package results
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"github.com/tektoncd/cli/pkg/cli"
+	tknlog "github.com/tektoncd/cli/pkg/log"
+	taskrunpkg "github.com/tektoncd/cli/pkg/taskrun"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"github.com/tektoncd/results/pkg/cli/client/logs"
+	"github.com/tektoncd/results/pkg/cli/common"
+	"github.com/tektoncd/results/pkg/cli/options"
+	pb "github.com/tektoncd/results/proto/v1alpha2/results_go_proto"
+)
+
+type logsOptions struct {
+	UID       string
+	Tasks     []string
+	Failed    bool
+	Prefixing bool
+	Long      bool
+}
+
+// PipelineRunLogsCommand returns the command printing the logs of the
+// TaskRuns of a PipelineRun stored in Results, the same way the logs of a
+// PipelineRun of the cluster are printed.
+func PipelineRunLogsCommand(p common.Params) *cobra.Command {
+	opts := &logsOptions{Prefixing: true}
+	eg := `Get logs for a PipelineRun named 'foo' in the current namespace:
+    opc results pipelinerun logs foo
+
+Get logs for a PipelineRun by UID if there are multiple PipelineRuns with the same name:
+    opc results pipelinerun logs --uid 12345678-1234-1234-1234-1234567890ab
+
+Get logs of the tasks build and test of a PipelineRun named 'foo':
+    opc results pipelinerun logs foo --task build --task test
+
+Get logs of the failed tasks of a PipelineRun named 'foo' without prefixing the lines:
+    opc results pipelinerun logs foo --log-failed --prefix=false
+`
+	cmd := &cobra.Command{
+		Use:   "logs [pipelinerun-name]",
+		Short: "Get logs for a PipelineRun",
+		Long: `Get logs for a PipelineRun by name or UID. If --uid is provided, the PipelineRun name is optional.
+
+If multiple PipelineRuns match the given name, the logs for the most recent one are returned.
+Use --uid to target a specific PipelineRun when needed.
+
+The logs of the TaskRuns of the PipelineRun are printed in the order of the pipeline tasks, each line is prefixed
+with the name of the task and of the step.
+
+NOTE:
+Logs are not supported for the system namespace or for the default namespace used by LokiStack.
+Logs are only available for completed TaskRuns. Running TaskRuns do not have logs available yet.`,
+		Annotations: map[string]string{
+			"commandType": "main",
+		},
+		Example: eg,
+		Args: func(_ *cobra.Command, args []string) error {
+			if opts.UID != "" {
+				return nil
+			}
+			if len(args) != 1 {
+				return fmt.Errorf("requires exactly one argument when --uid is not provided")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			describeOpts := &options.DescribeOptions{
+				ResourceType: common.ResourceTypePipelineRun,
+				UID:          opts.UID,
+			}
+			if len(args) > 0 {
+				describeOpts.ResourceName = args[0]
+			}
+			record, err := getRecord(cmd.Context(), p, describeOpts)
+			if err != nil {
+				return err
+			}
+			pr := &v1.PipelineRun{}
+			if err := json.Unmarshal(record.Data.Value, pr); err != nil {
+				return fmt.Errorf("failed to parse PipelineRun data: %v", err)
+			}
+
+			children, err := getChildTaskRuns(cmd.Context(), p, pr)
+			if err != nil {
+				return err
+			}
+			runs, records := orderedTaskRuns(pr, children, opts)
+
+			s := &cli.Stream{In: cmd.InOrStdin(), Out: cmd.OutOrStdout(), Err: cmd.ErrOrStderr()}
+			if len(runs) == 0 {
+				if pr.Status.CompletionTime == nil {
+					fmt.Fprintln(s.Out, "Logs are not available for running PipelineRuns. Please wait for the PipelineRun to complete before retrieving logs.")
+					return nil
+				}
+				return fmt.Errorf("no TaskRun logs found for PipelineRun %s", pr.Name)
+			}
+
+			logC := make(chan tknlog.Log)
+			errC := make(chan error)
+			go func() {
+				defer close(logC)
+				defer close(errC)
+				c := logs.NewClient(p.RESTClient())
+				for _, run := range runs {
+					if err := readTaskRunLog(cmd, c, records[run.Name], run, logC); err != nil {
+						errC <- fmt.Errorf("failed to get logs for task %s : %v", run.Task, err)
+					}
+				}
+			}()
+			tknlog.NewWriter(tknlog.LogTypePipeline, opts.Prefixing).WithDisplayName(opts.Long).Write(s, logC, errC)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&opts.UID, "uid", "", "UID of the PipelineRun to get logs for")
+	// -t is the deprecated shorthand of --authtoken in the Results CLI
+	cmd.Flags().StringSliceVar(&opts.Tasks, "task", []string{}, "show logs for mentioned Tasks only")
+	cmd.Flags().BoolVar(&opts.Failed, "log-failed", false, "show logs for failed tasks only")
+	cmd.Flags().BoolVar(&opts.Prefixing, "prefix", opts.Prefixing, "prefix each log line with the log source (task name and step name)")
+	cmd.Flags().BoolVar(&opts.Long, "long", false, "show logs with task display name (display name and step name)")
+
+	return cmd
+}
+
+// orderedTaskRuns returns the TaskRuns to print in the order of the pipeline
+// tasks, with the names of their records, filtered like the logs of the
+// PipelineRuns of the cluster.
+func orderedTaskRuns(pr *v1.PipelineRun, children []childTaskRun, opts *logsOptions) ([]taskrunpkg.Run, map[string]string) {
+	var tasks []v1.PipelineTask
+	switch {
+	case pr.Status.PipelineSpec != nil:
+		tasks = append(append(tasks, pr.Status.PipelineSpec.Tasks...), pr.Status.PipelineSpec.Finally...)
+	case pr.Spec.PipelineSpec != nil:
+		tasks = append(append(tasks, pr.Spec.PipelineSpec.Tasks...), pr.Spec.PipelineSpec.Finally...)
+	}
+	if len(tasks) == 0 {
+		// without the pipeline spec the TaskRuns are sorted by time only
+		for _, child := range children {
+			tasks = append(tasks, v1.PipelineTask{Name: child.TaskRun.Labels[pipelineTaskLabel]})
+		}
+	}
+
+	records := map[string]string{}
+	trsMap := map[string]*v1.PipelineRunTaskRunStatus{}
+	for _, child := range children {
+		tr := child.TaskRun
+		records[tr.Name] = child.Record
+		trsMap[tr.Name] = &v1.PipelineRunTaskRunStatus{
+			PipelineTaskName: tr.Labels[pipelineTaskLabel],
+			Status:           &tr.Status,
+		}
+	}
+
+	runs := taskrunpkg.SortTasksBySpecOrder(tasks, trsMap)
+	runs = taskrunpkg.Filter(runs, opts.Tasks)
+	return taskrunpkg.FilterByStatus(runs, trsMap, opts.Failed), records
+}
+
+// readTaskRunLog sends the lines of the stored log of the TaskRun, the lines
+// are prefixed with the name of the step when the log is stored.
+func readTaskRunLog(cmd *cobra.Command, c *logs.Client, name string, run taskrunpkg.Run, logC chan<- tknlog.Log) error {
+	reader, err := c.GetLog(cmd.Context(), &pb.GetLogRequest{Name: name})
+	if err != nil {
+		return err
+	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	step := ""
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLogLineSize)
+	for scanner.Scan() {
+		line := scanner.Text()
+		// the lines without prefix continue the log of the previous step
+		if prefix := logPrefix.FindStringSubmatch(line); prefix != nil {
+			if step != "" && prefix[1] != step {
+				logC <- tknlog.Log{Task: run.Task, TaskDisplayName: run.DisplayName, Step: step, Log: "EOFLOG"}
+			}
+			step, line = prefix[1], line[len(prefix[0]):]
+		}
+		logC <- tknlog.Log{Task: run.Task, TaskDisplayName: run.DisplayName, Step: step, Log: line}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	logC <- tknlog.Log{Task: run.Task, TaskDisplayName: run.DisplayName, Step: step, Log: "EOFLOG"}
+	return nil
+}
@@ -0,0 +1,27 @@
+package results
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/tektoncd/results/pkg/cli/common"
+	"github.com/tektoncd/results/pkg/cli/common/prerun"
+	"github.com/tektoncd/results/pkg/cli/flags"
+)
+
+// persistentPreRunE initializes the params and the REST client of the
+// commands added at the top level of the Results CLI, as the pipelinerun and
+// taskrun commands do for their subcommands.
+func persistentPreRunE(p common.Params) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, _ []string) error {
+		if err := flags.InitParams(p, cmd); err != nil {
+			return err
+		}
+		if p.RESTClient() == nil {
+			restClient, err := prerun.InitClient(p, cmd)
+			if err != nil {
+				return err
+			}
+			p.SetRESTClient(restClient)
+		}
+		return nil
+	}
+}
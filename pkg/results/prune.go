@@ -0,0 +1,185 @@
+package results
+
+import (
+	"errors"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	opcflags "github.com/openshift-pipelines/opc/pkg/flags"
+	"github.com/spf13/cobra"
+	"github.com/tektoncd/cli/pkg/cli"
+	"github.com/tektoncd/cli/pkg/formatted"
+	tknoptions "github.com/tektoncd/cli/pkg/options"
+	"github.com/tektoncd/results/pkg/cli/client/records"
+	"github.com/tektoncd/results/pkg/cli/common"
+	"github.com/tektoncd/results/pkg/cli/flags"
+	"github.com/tektoncd/results/pkg/cli/options"
+	pb "github.com/tektoncd/results/proto/v1alpha2/results_go_proto"
+)
+
+// pruneFields are the fields of the records listed to find the runs to
+// delete, the type gives the kind of the run.
+const pruneFields = common.ListFields + ",records.data.type"
+
+type pruneOptions struct {
+	OlderThan string
+	DryRun    bool
+	Force     bool
+	Limit     int32
+}
+
+// PruneCommand returns the command deleting the results of the runs older
+// than a retention period from Results.
+func PruneCommand(p common.Params) *cobra.Command {
+	opts := &pruneOptions{Limit: 100}
+	eg := `Show the runs of namespace foo created more than 90 days ago which would be deleted:
+    opc results prune --older-than 90d -n foo --dry-run
+
+Delete all the runs of the deleted namespace foo without asking for confirmation:
+    opc results prune --older-than 0d -n foo -f
+`
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Delete the PipelineRun and TaskRun records older than a retention period",
+		Long: `Delete the results of the PipelineRuns and of the standalone TaskRuns of a namespace created before the
+retention period given with --older-than, with the records of the TaskRuns of the PipelineRuns and the logs.
+
+Runs which have not completed yet are never deleted.`,
+		Example: eg,
+		Annotations: map[string]string{
+			"commandType": "main",
+		},
+		Args:              cobra.NoArgs,
+		PersistentPreRunE: persistentPreRunE(p),
+		PreRunE: func(_ *cobra.Command, _ []string) error {
+			if opts.OlderThan == "" {
+				return errors.New("--older-than is required")
+			}
+			if opts.Limit < 5 || opts.Limit > 1000 {
+				return errors.New("limit should be between 5 and 1000")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			now := time.Now()
+			cutoff, err := opcflags.ParseTime(opts.OlderThan, now)
+			if err != nil {
+				return err
+			}
+			targets, running, err := pruneTargets(cmd, p, opts, now)
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			if len(targets) == 0 {
+				_, err := fmt.Fprintf(out, "No runs created before %s found in namespace %s\n", cutoff.UTC().Format(time.RFC3339), p.Namespace())
+				return err
+			}
+			if opts.DryRun {
+				clock := clockwork.NewRealClock()
+				w := tabwriter.NewWriter(out, 0, 5, 3, ' ', tabwriter.TabIndent)
+				fmt.Fprintln(w, "KIND\tNAME\tUID\tCREATED")
+				for _, t := range targets {
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", t.Kind, t.Name, t.UID, formatted.Age(t.Created, clock))
+				}
+				if err := w.Flush(); err != nil {
+					return err
+				}
+				_, err := fmt.Fprintf(out, "%d runs would be deleted (%d not completed are kept)\n", len(targets), running)
+				return err
+			}
+
+			s := &cli.Stream{In: cmd.InOrStdin(), Out: out, Err: cmd.ErrOrStderr()}
+			if !opts.Force {
+				fmt.Fprintf(s.Out, "Are you sure you want to delete the %d runs created before %s in namespace %q (y/n): ", len(targets), cutoff.UTC().Format(time.RFC3339), p.Namespace())
+				deleteOpts := &tknoptions.DeleteOptions{Resource: "run"}
+				if err := deleteOpts.TakeInput(s, fmt.Sprintf("in namespace %q", p.Namespace())); err != nil {
+					return err
+				}
+			}
+
+			counts := map[string]int{}
+			children, failed := 0, 0
+			for _, t := range targets {
+				n, err := deleteRecords(cmd.Context(), p.RESTClient(), t)
+				if err != nil {
+					failed++
+					fmt.Fprintf(s.Err, "failed to delete %s %s/%s: %v\n", t.Kind, t.Namespace, t.Name, err)
+					continue
+				}
+				counts[t.Kind]++
+				children += n
+			}
+			fmt.Fprintf(out, "Deleted %d PipelineRuns with the records of their %d TaskRuns and %d TaskRuns from namespace %s (%d not completed are kept)\n",
+				counts["PipelineRun"], children, counts["TaskRun"], p.Namespace(), running)
+			if failed > 0 {
+				return fmt.Errorf("failed to delete %d runs", failed)
+			}
+			return nil
+		},
+	}
+	flags.AddResultsOptions(cmd)
+
+	cmd.Flags().StringVar(&opts.OlderThan, "older-than", "", "Delete the runs created before this time (duration like 90d, 12h or RFC3339 timestamp)")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "Only list the runs which would be deleted")
+	cmd.Flags().BoolVarP(&opts.Force, "force", "f", false, "Whether to force deletion (default: false)")
+	cmd.Flags().Int32Var(&opts.Limit, "limit", opts.Limit, "Number of records fetched per page (must be between 5 and 1000)")
+
+	return cmd
+}
+
+// pruneTargets returns the runs to delete, the records of the TaskRuns of a
+// PipelineRun are deleted with its result. The number of the runs kept as
+// they have not completed is returned too.
+func pruneTargets(cmd *cobra.Command, p common.Params, opts *pruneOptions, now time.Time) ([]*deleteTarget, int, error) {
+	recordClient := records.NewClient(p.RESTClient())
+	targets := []*deleteTarget{}
+	seen := map[string]bool{}
+	running := 0
+	for _, resourceType := range []string{common.ResourceTypePipelineRun, common.ResourceTypeTaskRun} {
+		filter, err := BuildFilterString(&ListOptions{
+			ListOptions: options.ListOptions{ResourceType: resourceType},
+			Until:       opts.OlderThan,
+		}, now)
+		if err != nil {
+			return nil, 0, err
+		}
+		req := &pb.ListRecordsRequest{
+			Parent:   fmt.Sprintf("%s/results/-", p.Namespace()),
+			Filter:   filter,
+			OrderBy:  "create_time asc",
+			PageSize: opts.Limit,
+		}
+		// the records are deleted once they are all listed, so that the
+		// pages do not move while listing them
+		for {
+			resp, err := recordClient.ListRecords(cmd.Context(), req, pruneFields)
+			if err != nil {
+				return nil, 0, err
+			}
+			for _, record := range resp.Records {
+				t, err := newDeleteTarget(record)
+				if err != nil {
+					return nil, 0, err
+				}
+				if t.Result == "" || seen[t.Result] {
+					continue
+				}
+				seen[t.Result] = true
+				if !t.Completed {
+					running++
+					continue
+				}
+				targets = append(targets, t)
+			}
+			if resp.NextPageToken == "" {
+				break
+			}
+			req.PageToken = resp.NextPageToken
+		}
+	}
+	return targets, running, nil
+}
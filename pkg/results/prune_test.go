@@ -0,0 +1,66 @@
+package results
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	pb "github.com/tektoncd/results/proto/v1alpha2/results_go_proto"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func pruneRecords(t *testing.T) []*pb.Record {
+	created := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	completed := metav1.NewTime(created.Add(time.Minute))
+	pr := &v1.PipelineRun{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "tekton.dev/v1", Kind: "PipelineRun"},
+		ObjectMeta: metav1.ObjectMeta{Name: "build", Namespace: "ns", UID: "pr-uid", CreationTimestamp: metav1.NewTime(created)},
+	}
+	pr.Status.CompletionTime = &completed
+	tr := &v1.TaskRun{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "tekton.dev/v1", Kind: "TaskRun"},
+		ObjectMeta: metav1.ObjectMeta{Name: "lint", Namespace: "ns", UID: "tr-uid", CreationTimestamp: metav1.NewTime(created)},
+	}
+	tr.Status.CompletionTime = &completed
+	return []*pb.Record{
+		runRecord(t, "tekton.dev/v1.PipelineRun", "pr-uid", pr, created),
+		runRecord(t, "tekton.dev/v1.TaskRun", "tr-uid", tr, created),
+	}
+}
+
+func TestPruneDryRunKind(t *testing.T) {
+	_, p := newFakeResults(t, 10, pruneRecords(t)...)
+	out := &bytes.Buffer{}
+	c := PruneCommand(p)
+	c.SetOut(out)
+	c.SetArgs([]string{"--older-than", "1d", "-n", "ns", "--dry-run"})
+	if err := c.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"PipelineRun   build", "TaskRun       lint", "2 runs would be deleted"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("%q missing from the output:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestPruneCountsKinds(t *testing.T) {
+	fake, p := newFakeResults(t, 10, pruneRecords(t)...)
+	out := &bytes.Buffer{}
+	c := PruneCommand(p)
+	c.SetOut(out)
+	c.SetArgs([]string{"--older-than", "1d", "-n", "ns", "-f"})
+	if err := c.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(out.String(), "Deleted 1 PipelineRuns") || !strings.Contains(out.String(), "and 1 TaskRuns from namespace ns") {
+		t.Errorf("unexpected output: %s", out.String())
+	}
+	if len(fake.deleted) != 2 {
+		t.Errorf("got %d deleted results, want 2: %v", len(fake.deleted), fake.deleted)
+	}
+}
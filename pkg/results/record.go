@@ -0,0 +1,126 @@
+package results
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"github.com/tektoncd/results/pkg/cli/client/records"
+	"github.com/tektoncd/results/pkg/cli/common"
+	"github.com/tektoncd/results/pkg/cli/options"
+	pb "github.com/tektoncd/results/proto/v1alpha2/results_go_proto"
+)
+
+const (
+	pipelineRunLabel    = "tekton.dev/pipelineRun"
+	pipelineRunUIDLabel = "tekton.dev/pipelineRunUID"
+)
+
+// childTaskRun is the record of a TaskRun of a PipelineRun.
+type childTaskRun struct {
+	Record  string
+	TaskRun *v1.TaskRun
+}
+
+// getRecord returns the record of the run with the given UID, or the most
+// recent record of the runs with the given name, the same way the describe
+// commands of the Results CLI find it. Any record is returned by UID when the
+// resource type is not set.
+func getRecord(ctx context.Context, p common.Params, opts *options.DescribeOptions) (*pb.Record, error) {
+	kind := "PipelineRun"
+	switch opts.ResourceType {
+	case common.ResourceTypeTaskRun:
+		kind = "TaskRun"
+	case "":
+		kind = "record"
+	}
+
+	recordClient := records.NewClient(p.RESTClient())
+	parent := fmt.Sprintf("%s/results/-", p.Namespace())
+	if opts.UID != "" {
+		if record, err := recordClient.GetRecord(ctx, p.Namespace(), opts.UID); err == nil {
+			return record, nil
+		}
+		resp, err := recordClient.ListRecords(ctx, &pb.ListRecordsRequest{
+			Parent:   parent,
+			Filter:   fmt.Sprintf(`name.endsWith("records/%s")`, opts.UID),
+			OrderBy:  "create_time desc",
+			PageSize: 5,
+		}, "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to find %s: %v", kind, err)
+		}
+		if len(resp.Records) == 0 {
+			return nil, fmt.Errorf("no %s found with UID %s", kind, opts.UID)
+		}
+		return resp.Records[0], nil
+	}
+
+	resp, err := recordClient.ListRecords(ctx, &pb.ListRecordsRequest{
+		Parent:   parent,
+		Filter:   common.BuildFilterString(opts),
+		OrderBy:  "create_time desc",
+		PageSize: 5,
+	}, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to find %s: %v", kind, err)
+	}
+	if len(resp.Records) == 0 {
+		return nil, fmt.Errorf("no %s found with name %s", kind, opts.ResourceName)
+	}
+	return resp.Records[0], nil
+}
+
+// getChildTaskRuns returns the records of the TaskRuns of the PipelineRun,
+// found by their tekton.dev/pipelineRun label and their owner UID.
+func getChildTaskRuns(ctx context.Context, p common.Params, pr *v1.PipelineRun) ([]childTaskRun, error) {
+	filter := fmt.Sprintf("%s && data.metadata.labels[%s]==%s",
+		common.BuildFilterString(&options.ListOptions{ResourceType: common.ResourceTypeTaskRun}),
+		strconv.Quote(pipelineRunLabel), strconv.Quote(pr.Name))
+	req := &pb.ListRecordsRequest{
+		Parent:   fmt.Sprintf("%s/results/-", pr.Namespace),
+		Filter:   filter,
+		OrderBy:  "create_time asc",
+		PageSize: 100,
+	}
+
+	recordClient := records.NewClient(p.RESTClient())
+	children := []childTaskRun{}
+	for {
+		resp, err := recordClient.ListRecords(ctx, req, common.ListFields)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range resp.Records {
+			tr := &v1.TaskRun{}
+			if err := json.Unmarshal(record.Data.Value, tr); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal record %s: %w", record.Name, err)
+			}
+			// PipelineRuns with the same name may have been run before
+			if ownedBy(tr, string(pr.UID)) {
+				children = append(children, childTaskRun{Record: record.Name, TaskRun: tr})
+			}
+		}
+		if resp.NextPageToken == "" {
+			return children, nil
+		}
+		req.PageToken = resp.NextPageToken
+	}
+}
+
+// ownedBy returns true when the TaskRun was created for the PipelineRun with
+// the UID, as given by its tekton.dev/pipelineRunUID label or its owner
+// references.
+func ownedBy(tr *v1.TaskRun, uid string) bool {
+	if uid != "" && tr.Labels[pipelineRunUIDLabel] == uid {
+		return true
+	}
+	for _, ref := range tr.OwnerReferences {
+		if string(ref.UID) == uid {
+			return true
+		}
+	}
+	return false
+}
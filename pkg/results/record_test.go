@@ -0,0 +1,42 @@
+package results
+
+import (
+	"testing"
+
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestOwnedBy(t *testing.T) {
+	tests := []struct {
+		name string
+		meta metav1.ObjectMeta
+		want bool
+	}{{
+		name: "owner reference",
+		meta: metav1.ObjectMeta{OwnerReferences: []metav1.OwnerReference{{Kind: "PipelineRun", UID: "pr-uid"}}},
+		want: true,
+	}, {
+		name: "pipelinerun uid label",
+		meta: metav1.ObjectMeta{Labels: map[string]string{pipelineRunUIDLabel: "pr-uid"}},
+		want: true,
+	}, {
+		name: "other owner",
+		meta: metav1.ObjectMeta{
+			Labels:          map[string]string{pipelineRunUIDLabel: "old-uid"},
+			OwnerReferences: []metav1.OwnerReference{{Kind: "PipelineRun", UID: "old-uid"}},
+		},
+		want: false,
+	}, {
+		name: "no owner",
+		meta: metav1.ObjectMeta{Labels: map[string]string{pipelineRunLabel: "build"}},
+		want: false,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ownedBy(&v1.TaskRun{ObjectMeta: tt.meta}, "pr-uid"); got != tt.want {
+				t.Errorf("ownedBy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
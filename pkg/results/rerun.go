@@ -0,0 +1,233 @@
+package results
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/openshift-pipelines/opc/pkg/runs"
+	"github.com/spf13/cobra"
+	"github.com/tektoncd/cli/pkg/cli"
+	tknexport "github.com/tektoncd/cli/pkg/export"
+	"github.com/tektoncd/cli/pkg/params"
+	"github.com/tektoncd/cli/pkg/pipelinerun"
+	"github.com/tektoncd/cli/pkg/workspaces"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	"github.com/tektoncd/results/pkg/cli/common"
+	"github.com/tektoncd/results/pkg/cli/options"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+)
+
+type rerunOptions struct {
+	UID        string
+	Params     []string
+	Workspaces []string
+	DryRun     bool
+	Output     string
+}
+
+// PipelineRunRerunCommand returns the command creating a new PipelineRun from
+// the record of a PipelineRun stored in Results.
+func PipelineRunRerunCommand(p common.Params, tp cli.Params) *cobra.Command {
+	opts := &rerunOptions{}
+	eg := `Rerun the most recent PipelineRun named foo in namespace bar:
+    opc results pipelinerun rerun foo -n bar
+
+Rerun a PipelineRun by UID with another value of the revision param:
+    opc results pipelinerun rerun --uid 4a56a2a1-7b1e-4b4e-9d7a-0b4b3c2e6f1d -p revision=main
+
+Print the PipelineRun which would be created:
+    opc results pipelinerun rerun foo --dry-run -o yaml
+`
+	cmd := &cobra.Command{
+		Use:   "rerun [pipelinerun-name]",
+		Short: "Create a new PipelineRun from a PipelineRun stored in Results",
+		Long: `Create a new PipelineRun from a PipelineRun stored in Tekton Results, the PipelineRun does not need to exist in the cluster anymore.
+
+If multiple PipelineRuns match the given name, the most recent one is used.
+Use --uid to target a specific PipelineRun when needed.`,
+		Annotations: map[string]string{
+			"commandType": "main",
+		},
+		Example: eg,
+		Args: func(_ *cobra.Command, args []string) error {
+			if opts.UID != "" {
+				return nil
+			}
+			if len(args) != 1 {
+				return fmt.Errorf("requires exactly one argument when --uid is not provided")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch strings.ToLower(opts.Output) {
+			case "", "json", "yaml", "name":
+			default:
+				return fmt.Errorf("invalid output format %q, must be one of json, yaml or name", opts.Output)
+			}
+
+			describeOpts := &options.DescribeOptions{
+				ResourceType: common.ResourceTypePipelineRun,
+				UID:          opts.UID,
+			}
+			if len(args) > 0 {
+				describeOpts.ResourceName = args[0]
+			}
+			record, err := getRecord(cmd.Context(), p, describeOpts)
+			if err != nil {
+				return err
+			}
+
+			tp.SetKubeConfigPath(p.KubeConfigPath())
+			tp.SetKubeContext(p.KubeContext())
+			tp.SetNamespace(p.Namespace())
+			cs, err := tp.Clients()
+			if err != nil {
+				return err
+			}
+
+			pr, err := rerunPipelineRun(cmd.Context(), record.Data.Value, opts, cs)
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			if opts.DryRun {
+				return printRerun(cmd.Context(), out, opts.Output, pr)
+			}
+
+			created, err := pipelinerun.Create(cs, pr, metav1.CreateOptions{}, p.Namespace())
+			if err != nil {
+				return err
+			}
+			if opts.Output != "" {
+				return printRerun(cmd.Context(), out, opts.Output, created)
+			}
+			_, err = fmt.Fprintf(out, "PipelineRun started: %s\n\nIn order to track the PipelineRun progress run:\nopc pipelinerun logs %s -f -n %s\n", created.Name, created.Name, created.Namespace)
+			return err
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.UID, "uid", "", "", "UID of the PipelineRun to rerun")
+	cmd.Flags().StringArrayVarP(&opts.Params, "param", "p", []string{}, "override the param as key=value for string type, or key=value1,value2,... for array type, or key=\"key1:value1, key2:value2\" for object type")
+	cmd.Flags().StringArrayVarP(&opts.Workspaces, "workspace", "w", []string{}, "override one or more workspaces to map to the corresponding physical volumes")
+	cmd.Flags().BoolVarP(&opts.DryRun, "dry-run", "", false, "preview PipelineRun without running it")
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", "", "format of PipelineRun (yaml, json or name)")
+
+	return cmd
+}
+
+// rerunPipelineRun returns the PipelineRun to create from the stored one,
+// with the fields set by the cluster removed and the params and workspaces
+// overridden.
+func rerunPipelineRun(ctx context.Context, data []byte, opts *rerunOptions, cs *cli.Clients) (*v1beta1.PipelineRun, error) {
+	u := &unstructured.Unstructured{}
+	if err := json.Unmarshal(data, &u.Object); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal PipelineRun data: %v", err)
+	}
+
+	// the resolved pipeline spec is only in the status, it gives the types of
+	// the params to override
+	specs, err := paramSpecs(u)
+	if err != nil {
+		return nil, err
+	}
+	params.FilterParamsByType(specs)
+
+	if err := tknexport.RemoveFieldForExport(u); err != nil {
+		return nil, err
+	}
+	meta := runs.RerunObjectMeta(metav1.ObjectMeta{
+		Name:         u.GetName(),
+		GenerateName: u.GetGenerateName(),
+		Labels:       u.GetLabels(),
+		Annotations:  u.GetAnnotations(),
+	})
+	u.SetName("")
+	u.SetGenerateName(meta.GenerateName)
+	u.SetLabels(meta.Labels)
+	u.SetAnnotations(meta.Annotations)
+
+	pr := &v1beta1.PipelineRun{}
+	if u.GetAPIVersion() == v1beta1.SchemeGroupVersion.String() {
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, pr); err != nil {
+			return nil, err
+		}
+	} else {
+		prv1 := &v1.PipelineRun{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, prv1); err != nil {
+			return nil, err
+		}
+		if err := pr.ConvertFrom(ctx, prv1); err != nil {
+			return nil, err
+		}
+	}
+
+	pr.Spec.Params, err = params.MergeParam(pr.Spec.Params, opts.Params)
+	if err != nil {
+		return nil, err
+	}
+	pr.Spec.Workspaces, err = workspaces.Merge(pr.Spec.Workspaces, opts.Workspaces, cs.HTTPClient)
+	if err != nil {
+		return nil, err
+	}
+	return pr, nil
+}
+
+// paramSpecs returns the param specs of the pipeline of the stored
+// PipelineRun, from its status or its embedded spec, or from the values of
+// its params when the pipeline spec is unknown.
+func paramSpecs(u *unstructured.Unstructured) ([]v1beta1.ParamSpec, error) {
+	pr := &v1.PipelineRun{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, pr); err != nil {
+		return nil, err
+	}
+	var specs v1.ParamSpecs
+	switch {
+	case pr.Status.PipelineSpec != nil:
+		specs = pr.Status.PipelineSpec.Params
+	case pr.Spec.PipelineSpec != nil:
+		specs = pr.Spec.PipelineSpec.Params
+	}
+	return runs.ParamSpecs(specs, pr.Spec.Params), nil
+}
+
+// printRerun prints the PipelineRun as a v1 PipelineRun.
+func printRerun(ctx context.Context, out io.Writer, output string, pr *v1beta1.PipelineRun) error {
+	if strings.ToLower(output) == "name" {
+		name := pr.Name
+		if name == "" {
+			name = pr.GenerateName
+		}
+		_, err := fmt.Fprintln(out, name)
+		return err
+	}
+
+	prv1 := &v1.PipelineRun{}
+	if err := pr.ConvertTo(ctx, prv1); err != nil {
+		return err
+	}
+	prv1.Kind = "PipelineRun"
+	prv1.APIVersion = v1.SchemeGroupVersion.String()
+
+	if strings.ToLower(output) == "json" {
+		b, err := json.MarshalIndent(prv1, "", "\t")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(out, "%s\n", b)
+		return err
+	}
+	b, err := yaml.Marshal(prv1)
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(b)
+	return err
+}
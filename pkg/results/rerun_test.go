@@ -0,0 +1,61 @@
+package results
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tektoncd/cli/pkg/cli"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+)
+
+func TestRerunPipelineRun(t *testing.T) {
+	data := `{
+  "apiVersion": "tekton.dev/v1",
+  "kind": "PipelineRun",
+  "metadata": {
+    "name": "build-abcde",
+    "namespace": "default",
+    "uid": "1234",
+    "resourceVersion": "42",
+    "labels": {"app": "web", "tekton.dev/pipeline": "build"},
+    "annotations": {
+      "owner": "team",
+      "results.tekton.dev/record": "default/results/1234/records/1234",
+      "chains.tekton.dev/signed": "true",
+      "pipelinesascode.tekton.dev/sha": "abc",
+      "kubectl.kubernetes.io/last-applied-configuration": "{}"
+    }
+  },
+  "spec": {
+    "pipelineRef": {"name": "build"},
+    "params": [{"name": "revision", "value": "main"}, {"name": "flags", "value": ["-v"]}]
+  },
+  "status": {
+    "pipelineSpec": {
+      "params": [{"name": "revision", "type": "string"}, {"name": "flags", "type": "array"}]
+    }
+  }
+}`
+	opts := &rerunOptions{Params: []string{"revision=dev", "flags=-x,-y"}}
+	pr, err := rerunPipelineRun(context.Background(), []byte(data), opts, &cli.Clients{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pr.Name != "" || pr.GenerateName != "build-abcde-" || pr.UID != "" || pr.ResourceVersion != "" {
+		t.Errorf("unexpected metadata: name %q, generateName %q, uid %q, resourceVersion %q", pr.Name, pr.GenerateName, pr.UID, pr.ResourceVersion)
+	}
+	if d := cmp.Diff(map[string]string{"app": "web"}, pr.Labels); d != "" {
+		t.Errorf("labels (-want +got):\n%s", d)
+	}
+	if d := cmp.Diff(map[string]string{"owner": "team"}, pr.Annotations); d != "" {
+		t.Errorf("annotations (-want +got):\n%s", d)
+	}
+	want := v1beta1.Params{
+		{Name: "flags", Value: *v1beta1.NewStructuredValues("-x", "-y")},
+		{Name: "revision", Value: *v1beta1.NewStructuredValues("dev")},
+	}
+	if d := cmp.Diff(want, pr.Spec.Params); d != "" {
+		t.Errorf("params (-want +got):\n%s", d)
+	}
+}
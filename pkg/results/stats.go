@@ -0,0 +1,525 @@
+package results
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	opcflags "github.com/openshift-pipelines/opc/pkg/flags"
+	"github.com/spf13/cobra"
+	"github.com/tektoncd/results/pkg/cli/client/records"
+	"github.com/tektoncd/results/pkg/cli/common"
+	"github.com/tektoncd/results/pkg/cli/flags"
+	"github.com/tektoncd/results/pkg/cli/options"
+	pb "github.com/tektoncd/results/proto/v1alpha2/results_go_proto"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	pipelineLabel     = "tekton.dev/pipeline"
+	pipelineTaskLabel = "tekton.dev/pipelineTask"
+	taskLabel         = "tekton.dev/task"
+
+	outcomeSucceeded = "succeeded"
+	outcomeFailed    = "failed"
+	outcomeCancelled = "cancelled"
+	outcomeRunning   = "running"
+)
+
+// sparks are the characters of the daily trend, from the lowest to the
+// highest success rate.
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// Stats are the statistics of the PipelineRuns and TaskRuns of a time range.
+type Stats struct {
+	Since     time.Time    `json:"since"`
+	Until     time.Time    `json:"until"`
+	Pipelines []*RunsStats `json:"pipelines"`
+	Tasks     []*RunsStats `json:"tasks"`
+}
+
+// RunsStats are the statistics of the runs of a Pipeline, or of a Task of a
+// Pipeline.
+type RunsStats struct {
+	Namespace string `json:"namespace"`
+	Pipeline  string `json:"pipeline"`
+	Task      string `json:"task,omitempty"`
+	Runs      int    `json:"runs"`
+	Succeeded int    `json:"succeeded"`
+	Failed    int    `json:"failed"`
+	Cancelled int    `json:"cancelled"`
+	Running   int    `json:"running"`
+	// Retried is the number of TaskRuns which were retried.
+	Retried int `json:"retried,omitempty"`
+	// SuccessRate is the ratio of succeeded runs among the succeeded and
+	// failed runs.
+	SuccessRate float64 `json:"successRate"`
+	MeanSeconds float64 `json:"meanSeconds"`
+	P95Seconds  float64 `json:"p95Seconds"`
+	// Flakiness is the ratio of consecutive completed runs with a different
+	// outcome, 0 when the runs always fail or always succeed and 1 when they
+	// alternate.
+	Flakiness      float64        `json:"flakiness"`
+	FailureReasons map[string]int `json:"failureReasons,omitempty"`
+	Daily          []*DailyStats  `json:"daily,omitempty"`
+
+	samples []runSample
+}
+
+// DailyStats are the number of runs of a day.
+type DailyStats struct {
+	Date      string `json:"date"`
+	Runs      int    `json:"runs"`
+	Succeeded int    `json:"succeeded"`
+	Failed    int    `json:"failed"`
+}
+
+type runSample struct {
+	start    time.Time
+	duration time.Duration
+	outcome  string
+	reason   string
+	retried  bool
+}
+
+// statsRun is the part of a PipelineRun or a TaskRun used for the statistics.
+type statsRun struct {
+	Metadata metav1.ObjectMeta `json:"metadata"`
+	Status   struct {
+		StartTime      *metav1.Time `json:"startTime,omitempty"`
+		CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+		Conditions     []struct {
+			Type   string `json:"type"`
+			Status string `json:"status"`
+			Reason string `json:"reason"`
+		} `json:"conditions,omitempty"`
+		RetriesStatus []json.RawMessage `json:"retriesStatus,omitempty"`
+	} `json:"status"`
+}
+
+type statsOptions struct {
+	AllNamespaces bool
+	Since         string
+	Until         string
+	Output        string
+	Limit         int32
+	Top           int
+}
+
+// StatsCommand returns the command computing the success rate, duration and
+// flakiness of the Pipelines and their Tasks from the records in Results.
+func StatsCommand(p common.Params) *cobra.Command {
+	opts := &statsOptions{Since: "30d", Limit: 100, Top: 10}
+	eg := `Show the statistics of the Pipelines of the last 30 days in the current namespace:
+    opc results stats
+
+Show the statistics of the Pipeline foo of the last 7 days:
+    opc results stats foo --since 7d
+
+Export the statistics of all the namespaces as CSV:
+    opc results stats -A -o csv
+`
+	cmd := &cobra.Command{
+		Use:   "stats [pipeline-name]",
+		Short: "Show success rate, duration and flakiness of Pipelines and Tasks",
+		Long: `Show the success rate, the mean and 95th percentile durations, the failure reasons and the flakiness of the Pipelines
+and of their Tasks, computed from the PipelineRuns and TaskRuns stored in Tekton Results.
+
+The success rate ignores the cancelled and running runs. The flakiness is the ratio of consecutive completed runs
+with a different outcome. The trend shows the success rate of each day, a dot is shown for the days without runs.`,
+		Example: eg,
+		Annotations: map[string]string{
+			"commandType": "main",
+		},
+		Args:              cobra.MaximumNArgs(1),
+		PersistentPreRunE: persistentPreRunE(p),
+		PreRunE: func(cmd *cobra.Command, _ []string) error {
+			if opts.AllNamespaces && cmd.Flags().Changed("namespace") {
+				return errors.New("cannot use --all-namespaces/-A and --namespace/-n together")
+			}
+			switch opts.Output {
+			case "", "json", "csv":
+			default:
+				return fmt.Errorf("invalid output format %q, must be one of json or csv", opts.Output)
+			}
+			if opts.Limit < 5 || opts.Limit > 1000 {
+				return errors.New("limit should be between 5 and 1000")
+			}
+			// all the records would be read without a time range
+			if opts.Since == "" {
+				return errors.New("the start of the time range must be given with --since (i.e: --since 90d)")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			pipeline := ""
+			if len(args) > 0 {
+				pipeline = args[0]
+			}
+			now := time.Now()
+			stats, err := collectStats(cmd, p, opts, pipeline, now)
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			switch opts.Output {
+			case "json":
+				enc := json.NewEncoder(out)
+				enc.SetIndent("", "  ")
+				return enc.Encode(stats)
+			case "csv":
+				return printStatsCSV(out, stats)
+			}
+			return printStats(out, stats, opts)
+		},
+	}
+	flags.AddResultsOptions(cmd)
+
+	cmd.Flags().BoolVarP(&opts.AllNamespaces, "all-namespaces", "A", false, "Compute the statistics of all namespaces")
+	cmd.Flags().StringVar(&opts.Since, "since", opts.Since, "Only use runs created after this time (duration like 30d, 12h or RFC3339 timestamp)")
+	cmd.Flags().StringVar(&opts.Until, "until", "", "Only use runs created before this time (duration like 30d, 12h or RFC3339 timestamp)")
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", "", "Output format, one of json or csv (a table is printed when not set)")
+	cmd.Flags().Int32Var(&opts.Limit, "limit", opts.Limit, "Number of records fetched per page (must be between 5 and 1000)")
+	cmd.Flags().IntVar(&opts.Top, "top", opts.Top, "Number of the slowest Tasks to show in the table, 0 to show all of them")
+
+	return cmd
+}
+
+func collectStats(cmd *cobra.Command, p common.Params, opts *statsOptions, pipeline string, now time.Time) (*Stats, error) {
+	since, err := opcflags.ParseTime(opts.Since, now)
+	if err != nil {
+		return nil, err
+	}
+	until, err := opcflags.ParseTime(opts.Until, now)
+	if err != nil {
+		return nil, err
+	}
+	if until.IsZero() {
+		until = now
+	}
+	stats := &Stats{Since: since, Until: until, Pipelines: []*RunsStats{}, Tasks: []*RunsStats{}}
+
+	parent := fmt.Sprintf("%s/results/-", p.Namespace())
+	if opts.AllNamespaces {
+		parent = common.AllNamespacesResultsParent
+	}
+	label := ""
+	if pipeline != "" {
+		label = pipelineLabel + "=" + pipeline
+	}
+
+	recordClient := records.NewClient(p.RESTClient())
+	pipelines := map[string]*RunsStats{}
+	tasks := map[string]*RunsStats{}
+	for _, resourceType := range []string{common.ResourceTypePipelineRun, common.ResourceTypeTaskRun} {
+		filter, err := BuildFilterString(&ListOptions{
+			ListOptions: options.ListOptions{ResourceType: resourceType, Label: label},
+			Since:       opts.Since,
+			Until:       opts.Until,
+		}, now)
+		if err != nil {
+			return nil, err
+		}
+
+		req := &pb.ListRecordsRequest{
+			Parent:   parent,
+			Filter:   filter,
+			OrderBy:  "create_time desc",
+			PageSize: opts.Limit,
+		}
+		for {
+			resp, err := recordClient.ListRecords(cmd.Context(), req, common.ListFields)
+			if err != nil {
+				return nil, err
+			}
+			for _, record := range resp.Records {
+				run := &statsRun{}
+				if err := json.Unmarshal(record.Data.Value, run); err != nil {
+					return nil, fmt.Errorf("failed to unmarshal record %s: %w", record.Name, err)
+				}
+				if run.Status.StartTime == nil {
+					continue
+				}
+
+				labels := run.Metadata.Labels
+				key := []string{run.Metadata.Namespace, labelOr(labels, pipelineLabel, "-")}
+				group := pipelines
+				if resourceType == common.ResourceTypeTaskRun {
+					key = append(key, labelOr(labels, pipelineTaskLabel, labelOr(labels, taskLabel, "-")))
+					group = tasks
+				}
+				s, ok := group[strings.Join(key, "/")]
+				if !ok {
+					s = &RunsStats{Namespace: key[0], Pipeline: key[1]}
+					if len(key) > 2 {
+						s.Task = key[2]
+					}
+					group[strings.Join(key, "/")] = s
+				}
+				s.samples = append(s.samples, newRunSample(run))
+			}
+
+			if resp.NextPageToken == "" {
+				break
+			}
+			req.PageToken = resp.NextPageToken
+		}
+	}
+
+	for _, s := range pipelines {
+		s.compute(stats.Since, stats.Until)
+		stats.Pipelines = append(stats.Pipelines, s)
+	}
+	for _, s := range tasks {
+		s.compute(stats.Since, stats.Until)
+		stats.Tasks = append(stats.Tasks, s)
+	}
+	sort.Slice(stats.Pipelines, func(i, j int) bool {
+		a, b := stats.Pipelines[i], stats.Pipelines[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Pipeline < b.Pipeline
+	})
+	// the slowest tasks first
+	sort.Slice(stats.Tasks, func(i, j int) bool {
+		a, b := stats.Tasks[i], stats.Tasks[j]
+		if a.P95Seconds != b.P95Seconds {
+			return a.P95Seconds > b.P95Seconds
+		}
+		return a.Namespace+"/"+a.Pipeline+"/"+a.Task < b.Namespace+"/"+b.Pipeline+"/"+b.Task
+	})
+	return stats, nil
+}
+
+func labelOr(labels map[string]string, key, def string) string {
+	if v := labels[key]; v != "" {
+		return v
+	}
+	return def
+}
+
+func newRunSample(run *statsRun) runSample {
+	s := runSample{
+		start:   run.Status.StartTime.Time,
+		outcome: outcomeRunning,
+		retried: len(run.Status.RetriesStatus) > 0,
+	}
+	for _, c := range run.Status.Conditions {
+		if c.Type != "Succeeded" {
+			continue
+		}
+		s.reason = c.Reason
+		switch c.Status {
+		case "True":
+			s.outcome = outcomeSucceeded
+		case "False":
+			s.outcome = outcomeFailed
+			for _, r := range cancelledReasons {
+				if c.Reason == r {
+					s.outcome = outcomeCancelled
+				}
+			}
+		}
+	}
+	if run.Status.CompletionTime != nil && s.outcome != outcomeRunning {
+		s.duration = run.Status.CompletionTime.Sub(s.start)
+	}
+	return s
+}
+
+// compute aggregates the samples of the runs.
+func (s *RunsStats) compute(since, until time.Time) {
+	sort.Slice(s.samples, func(i, j int) bool { return s.samples[i].start.Before(s.samples[j].start) })
+
+	days := map[string]*DailyStats{}
+	for d := since.UTC().Truncate(24 * time.Hour); !d.After(until.UTC()); d = d.Add(24 * time.Hour) {
+		day := &DailyStats{Date: d.Format(time.DateOnly)}
+		days[day.Date] = day
+		s.Daily = append(s.Daily, day)
+	}
+
+	durations := []time.Duration{}
+	var total time.Duration
+	previous, flips := "", 0
+	for _, r := range s.samples {
+		s.Runs++
+		day := days[r.start.UTC().Format(time.DateOnly)]
+		if day != nil {
+			day.Runs++
+		}
+		if r.retried {
+			s.Retried++
+		}
+		switch r.outcome {
+		case outcomeSucceeded:
+			s.Succeeded++
+			if day != nil {
+				day.Succeeded++
+			}
+		case outcomeFailed:
+			s.Failed++
+			if day != nil {
+				day.Failed++
+			}
+			if s.FailureReasons == nil {
+				s.FailureReasons = map[string]int{}
+			}
+			s.FailureReasons[r.reason]++
+		case outcomeCancelled:
+			s.Cancelled++
+		default:
+			s.Running++
+		}
+
+		if r.outcome == outcomeSucceeded || r.outcome == outcomeFailed {
+			durations = append(durations, r.duration)
+			total += r.duration
+			if previous != "" && previous != r.outcome {
+				flips++
+			}
+			previous = r.outcome
+		}
+	}
+
+	completed := len(durations)
+	if completed == 0 {
+		return
+	}
+	s.SuccessRate = float64(s.Succeeded) / float64(completed)
+	s.MeanSeconds = (total / time.Duration(completed)).Seconds()
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	s.P95Seconds = durations[int(math.Ceil(0.95*float64(completed)))-1].Seconds()
+	if completed > 1 {
+		s.Flakiness = float64(flips) / float64(completed-1)
+	}
+}
+
+// trend returns the success rate of each day as a sparkline.
+func (s *RunsStats) trend() string {
+	b := strings.Builder{}
+	for _, d := range s.Daily {
+		completed := d.Succeeded + d.Failed
+		if completed == 0 {
+			b.WriteRune('·')
+			continue
+		}
+		i := int(float64(d.Succeeded) / float64(completed) * float64(len(sparks)-1))
+		b.WriteRune(sparks[i])
+	}
+	return b.String()
+}
+
+func (s *RunsStats) completed() bool {
+	return s.Succeeded+s.Failed > 0
+}
+
+func printStats(out io.Writer, stats *Stats, opts *statsOptions) error {
+	if len(stats.Pipelines) == 0 && len(stats.Tasks) == 0 {
+		_, err := fmt.Fprintln(out, "No PipelineRuns found")
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 5, 3, ' ', tabwriter.TabIndent)
+	ns := func(s *RunsStats) string {
+		if opts.AllNamespaces {
+			return s.Namespace + "\t"
+		}
+		return ""
+	}
+	nsHeader := ""
+	if opts.AllNamespaces {
+		nsHeader = "NAMESPACE\t"
+	}
+
+	fmt.Fprintf(w, "%sPIPELINE\tRUNS\tSUCCEEDED\tFAILED\tCANCELLED\tSUCCESS RATE\tMEAN\tP95\tFLAKINESS\tTREND (%s - %s)\n",
+		nsHeader, stats.Since.Format(time.DateOnly), stats.Until.Format(time.DateOnly))
+	for _, s := range stats.Pipelines {
+		fmt.Fprintf(w, "%s%s\t%d\t%d\t%d\t%d\t%s\t%s\t%s\t%s\t%s\n", ns(s), s.Pipeline, s.Runs, s.Succeeded, s.Failed, s.Cancelled,
+			formatRate(s, s.SuccessRate), formatSeconds(s, s.MeanSeconds), formatSeconds(s, s.P95Seconds), formatRate(s, s.Flakiness), s.trend())
+	}
+
+	reasons := false
+	for _, s := range stats.Pipelines {
+		keys := make([]string, 0, len(s.FailureReasons))
+		for k := range s.FailureReasons {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool { return s.FailureReasons[keys[i]] > s.FailureReasons[keys[j]] })
+		for _, k := range keys {
+			if !reasons {
+				fmt.Fprintf(w, "\nFAILURE REASONS\n%sPIPELINE\tREASON\tCOUNT\n", nsHeader)
+				reasons = true
+			}
+			fmt.Fprintf(w, "%s%s\t%s\t%d\n", ns(s), s.Pipeline, k, s.FailureReasons[k])
+		}
+	}
+
+	if len(stats.Tasks) > 0 {
+		fmt.Fprintf(w, "\nSLOWEST TASKS\n%sPIPELINE\tTASK\tRUNS\tSUCCESS RATE\tMEAN\tP95\tFLAKINESS\tRETRIED\n", nsHeader)
+		for i, s := range stats.Tasks {
+			if opts.Top > 0 && i >= opts.Top {
+				break
+			}
+			fmt.Fprintf(w, "%s%s\t%s\t%d\t%s\t%s\t%s\t%s\t%d\n", ns(s), s.Pipeline, s.Task, s.Runs,
+				formatRate(s, s.SuccessRate), formatSeconds(s, s.MeanSeconds), formatSeconds(s, s.P95Seconds), formatRate(s, s.Flakiness), s.Retried)
+		}
+	}
+
+	return w.Flush()
+}
+
+func formatRate(s *RunsStats, rate float64) string {
+	if !s.completed() {
+		return "---"
+	}
+	return fmt.Sprintf("%.1f%%", rate*100)
+}
+
+func formatSeconds(s *RunsStats, seconds float64) string {
+	if !s.completed() {
+		return "---"
+	}
+	return (time.Duration(seconds * float64(time.Second))).Round(time.Second).String()
+}
+
+func printStatsCSV(out io.Writer, stats *Stats) error {
+	w := csv.NewWriter(out)
+	if err := w.Write([]string{"kind", "namespace", "pipeline", "task", "runs", "succeeded", "failed", "cancelled", "running", "retried",
+		"success_rate", "mean_seconds", "p95_seconds", "flakiness", "failure_reasons"}); err != nil {
+		return err
+	}
+
+	row := func(kind string, s *RunsStats) []string {
+		reasons := make([]string, 0, len(s.FailureReasons))
+		for k, v := range s.FailureReasons {
+			reasons = append(reasons, fmt.Sprintf("%s:%d", k, v))
+		}
+		sort.Strings(reasons)
+		f := func(v float64) string { return strconv.FormatFloat(v, 'f', 3, 64) }
+		return []string{kind, s.Namespace, s.Pipeline, s.Task, strconv.Itoa(s.Runs), strconv.Itoa(s.Succeeded), strconv.Itoa(s.Failed),
+			strconv.Itoa(s.Cancelled), strconv.Itoa(s.Running), strconv.Itoa(s.Retried),
+			f(s.SuccessRate), f(s.MeanSeconds), f(s.P95Seconds), f(s.Flakiness), strings.Join(reasons, ";")}
+	}
+	for _, s := range stats.Pipelines {
+		if err := w.Write(row("pipeline", s)); err != nil {
+			return err
+		}
+	}
+	for _, s := range stats.Tasks {
+		if err := w.Write(row("task", s)); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
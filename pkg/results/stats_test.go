@@ -0,0 +1,108 @@
+package results
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/cobra"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	pb "github.com/tektoncd/results/proto/v1alpha2/results_go_proto"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+)
+
+func statsRecord(t *testing.T, kind, name string, labels map[string]string, start time.Time, duration time.Duration, status corev1.ConditionStatus, reason string) *pb.Record {
+	run := map[string]any{
+		"apiVersion": "tekton.dev/v1",
+		"kind":       kind,
+		"metadata": metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "ns",
+			UID:       types.UID(name),
+			Labels:    labels,
+		},
+		"status": v1.PipelineRunStatus{
+			Status: duckv1.Status{Conditions: duckv1.Conditions{{Type: apis.ConditionSucceeded, Status: status, Reason: reason}}},
+			PipelineRunStatusFields: v1.PipelineRunStatusFields{
+				StartTime:      &metav1.Time{Time: start},
+				CompletionTime: &metav1.Time{Time: start.Add(duration)},
+			},
+		},
+	}
+	return runRecord(t, "tekton.dev/v1."+kind, name, run, start)
+}
+
+func TestCollectStats(t *testing.T) {
+	now := time.Date(2024, 5, 3, 12, 0, 0, 0, time.UTC)
+	build := map[string]string{pipelineLabel: "build"}
+	compile := map[string]string{pipelineLabel: "build", pipelineTaskLabel: "compile"}
+	fake, p := newFakeResults(t, 2,
+		statsRecord(t, "PipelineRun", "build-1", build, now.Add(-48*time.Hour), 10*time.Minute, corev1.ConditionTrue, "Succeeded"),
+		statsRecord(t, "PipelineRun", "build-2", build, now.Add(-47*time.Hour), 20*time.Minute, corev1.ConditionFalse, "Failed"),
+		statsRecord(t, "PipelineRun", "build-3", build, now.Add(-time.Hour), 30*time.Minute, corev1.ConditionTrue, "Succeeded"),
+		statsRecord(t, "PipelineRun", "build-4", build, now.Add(-time.Hour), 5*time.Minute, corev1.ConditionFalse, "Cancelled"),
+		statsRecord(t, "TaskRun", "build-1-compile", compile, now.Add(-48*time.Hour), 5*time.Minute, corev1.ConditionTrue, "Succeeded"),
+	)
+	cmd := &cobra.Command{}
+	cmd.SetContext(context.Background())
+
+	stats, err := collectStats(cmd, p, &statsOptions{Since: "2d", Limit: 100}, "build", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, q := range fake.queries {
+		if !strings.Contains(q.Get("filter"), `create_time>=timestamp("2024-05-01T12:00:00Z")`) || !strings.Contains(q.Get("filter"), `data.metadata.labels["tekton.dev/pipeline"]=="build"`) {
+			t.Errorf("filter %s is not bound to the time range and the pipeline", q.Get("filter"))
+		}
+	}
+
+	if len(stats.Pipelines) != 1 || len(stats.Tasks) != 1 {
+		t.Fatalf("got %d pipelines and %d tasks, want 1 and 1", len(stats.Pipelines), len(stats.Tasks))
+	}
+	got := stats.Pipelines[0]
+	want := &RunsStats{
+		Namespace:      "ns",
+		Pipeline:       "build",
+		Runs:           4,
+		Succeeded:      2,
+		Failed:         1,
+		Cancelled:      1,
+		SuccessRate:    2.0 / 3,
+		MeanSeconds:    20 * 60,
+		P95Seconds:     30 * 60,
+		Flakiness:      1,
+		FailureReasons: map[string]int{"Failed": 1},
+		Daily: []*DailyStats{
+			{Date: "2024-05-01", Runs: 2, Succeeded: 1, Failed: 1},
+			{Date: "2024-05-02"},
+			{Date: "2024-05-03", Runs: 2, Succeeded: 1},
+		},
+	}
+	if d := cmp.Diff(want, got, cmp.AllowUnexported(RunsStats{}), cmp.FilterPath(func(p cmp.Path) bool { return p.Last().String() == ".samples" }, cmp.Ignore())); d != "" {
+		t.Errorf("pipeline stats mismatch (-want +got):\n%s", d)
+	}
+	if task := stats.Tasks[0]; task.Task != "compile" || task.Runs != 1 || task.SuccessRate != 1 {
+		t.Errorf("task stats = %+v, want one succeeded run of compile", task)
+	}
+}
+
+func TestStatsRequiresSince(t *testing.T) {
+	_, p := newFakeResults(t, 2)
+	c := StatsCommand(p)
+	c.SetOut(&bytes.Buffer{})
+	c.SetErr(&bytes.Buffer{})
+	c.SetArgs([]string{"--since", ""})
+	err := c.Execute()
+	want := "the start of the time range must be given with --since (i.e: --since 90d)"
+	if err == nil || err.Error() != want {
+		t.Fatalf("Execute() error = %v, want %s", err, want)
+	}
+}
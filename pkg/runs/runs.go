@@ -0,0 +1,77 @@
+// Package runs holds the helpers of the commands creating new PipelineRuns
+// and TaskRuns from the runs of the cluster or from their records in Results.
+package runs
+
+import (
+	"strings"
+
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// The prefixes of the labels and annotations which are not copied to the new
+// runs: the ones set by the Tekton controller, which would tie a TaskRun to
+// its PipelineRun, and the ones tying the run to its record in Results, its
+// signature or its Pipelines as Code event.
+var (
+	labelPrefixes      = []string{"tekton.dev/", "pipelinesascode.tekton.dev/"}
+	annotationPrefixes = []string{"results.tekton.dev/", "chains.tekton.dev/", "pipelinesascode.tekton.dev/", "kubectl.kubernetes.io/"}
+)
+
+// RerunObjectMeta returns the metadata of the new run, generated from the
+// name of the run without the labels and annotations of the old run.
+func RerunObjectMeta(meta metav1.ObjectMeta) metav1.ObjectMeta {
+	rerun := metav1.ObjectMeta{
+		GenerateName: meta.GenerateName,
+		Labels:       withoutPrefixes(meta.Labels, labelPrefixes),
+		Annotations:  withoutPrefixes(meta.Annotations, annotationPrefixes),
+	}
+	if rerun.GenerateName == "" {
+		rerun.GenerateName = meta.Name + "-"
+	}
+	// the runs of Pipelines as Code are managed by its watcher, which needs
+	// the annotations of the event
+	if rerun.Labels["app.kubernetes.io/managed-by"] == "pipelinesascode.tekton.dev" {
+		delete(rerun.Labels, "app.kubernetes.io/managed-by")
+	}
+	return rerun
+}
+
+func withoutPrefixes(m map[string]string, prefixes []string) map[string]string {
+	out := map[string]string{}
+	for k, v := range m {
+		copied := true
+		for _, prefix := range prefixes {
+			copied = copied && !strings.HasPrefix(k, prefix)
+		}
+		if copied {
+			out[k] = v
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// ParamSpecs returns the v1beta1 specs of the params to override, from the
+// values of the params when the spec is unknown.
+func ParamSpecs(specs v1.ParamSpecs, values v1.Params) []v1beta1.ParamSpec {
+	typed := []v1beta1.ParamSpec{}
+	add := func(name string, t v1.ParamType) {
+		if t == "" {
+			t = v1.ParamTypeString
+		}
+		typed = append(typed, v1beta1.ParamSpec{Name: name, Type: v1beta1.ParamType(t)})
+	}
+	for _, s := range specs {
+		add(s.Name, s.Type)
+	}
+	if len(specs) == 0 {
+		for _, v := range values {
+			add(v.Name, v.Value.Type)
+		}
+	}
+	return typed
+}
@@ -0,0 +1,88 @@
+package runs
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRerunObjectMeta(t *testing.T) {
+	tests := []struct {
+		name string
+		meta metav1.ObjectMeta
+		want metav1.ObjectMeta
+	}{{
+		name: "labels and annotations of the old run",
+		meta: metav1.ObjectMeta{
+			Name: "build-abcde",
+			Labels: map[string]string{
+				"app":                                   "web",
+				"tekton.dev/pipeline":                   "build",
+				"pipelinesascode.tekton.dev/event-type": "push",
+				"app.kubernetes.io/managed-by":          "pipelinesascode.tekton.dev",
+			},
+			Annotations: map[string]string{
+				"owner":                                            "team",
+				"results.tekton.dev/record":                        "ns/results/uid/records/uid",
+				"chains.tekton.dev/signed":                         "true",
+				"pipelinesascode.tekton.dev/sha":                   "abc",
+				"kubectl.kubernetes.io/last-applied-configuration": "{}",
+			},
+		},
+		want: metav1.ObjectMeta{
+			GenerateName: "build-abcde-",
+			Labels:       map[string]string{"app": "web"},
+			Annotations:  map[string]string{"owner": "team"},
+		},
+	}, {
+		name: "generate name",
+		meta: metav1.ObjectMeta{
+			Name:         "build-abcde",
+			GenerateName: "build-",
+			Labels:       map[string]string{"tekton.dev/pipeline": "build"},
+		},
+		want: metav1.ObjectMeta{GenerateName: "build-"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if d := cmp.Diff(tt.want, RerunObjectMeta(tt.meta)); d != "" {
+				t.Errorf("RerunObjectMeta() (-want +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestParamSpecs(t *testing.T) {
+	values := v1.Params{
+		{Name: "revision", Value: *v1.NewStructuredValues("main")},
+		{Name: "flags", Value: *v1.NewStructuredValues("-v", "-x")},
+	}
+	tests := []struct {
+		name  string
+		specs v1.ParamSpecs
+		want  []v1beta1.ParamSpec
+	}{{
+		name:  "specs",
+		specs: v1.ParamSpecs{{Name: "revision"}, {Name: "labels", Type: v1.ParamTypeObject}},
+		want: []v1beta1.ParamSpec{
+			{Name: "revision", Type: v1beta1.ParamTypeString},
+			{Name: "labels", Type: v1beta1.ParamTypeObject},
+		},
+	}, {
+		name: "values without specs",
+		want: []v1beta1.ParamSpec{
+			{Name: "revision", Type: v1beta1.ParamTypeString},
+			{Name: "flags", Type: v1beta1.ParamTypeArray},
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if d := cmp.Diff(tt.want, ParamSpecs(tt.specs, values)); d != "" {
+				t.Errorf("ParamSpecs() (-want +got):\n%s", d)
+			}
+		})
+	}
+}
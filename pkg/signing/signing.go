@@ -0,0 +1,74 @@
+// Package signing loads the keys signing and verifying the bundles and the
+// Tekton resources of files.
+package signing
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/sigstore/sigstore/pkg/signature/kms"
+	"golang.org/x/term"
+)
+
+// LoadSigner returns the signer of the private key file, or of the KMS key
+// when given. The password of an encrypted key is read with pf.
+func LoadSigner(ctx context.Context, keyFile, kmsKey string, pf cryptoutils.PassFunc) (signature.Signer, error) {
+	if kmsKey != "" {
+		signer, err := kms.Get(ctx, kmsKey, crypto.SHA256)
+		if err != nil {
+			return nil, fmt.Errorf("error getting kms signer: %v", err)
+		}
+		return signer, nil
+	}
+	signer, err := signature.LoadSignerFromPEMFile(keyFile, crypto.SHA256, pf)
+	if err != nil {
+		return nil, fmt.Errorf("error getting signer from key file: %v", err)
+	}
+	return signer, nil
+}
+
+// LoadVerifier returns the verifier of the public key file, or of the KMS
+// key when given.
+func LoadVerifier(ctx context.Context, keyFile, kmsKey string) (signature.Verifier, error) {
+	if kmsKey != "" {
+		verifier, err := kms.Get(ctx, kmsKey, crypto.SHA256)
+		if err != nil {
+			return nil, fmt.Errorf("error getting kms verifier: %v", err)
+		}
+		return verifier, nil
+	}
+	verifier, err := signature.LoadVerifierFromPEMFile(keyFile, crypto.SHA256)
+	if err != nil {
+		return nil, fmt.Errorf("error getting verifier from key file: %v", err)
+	}
+	return verifier, nil
+}
+
+// KeyPassword returns the function reading the password of an encrypted
+// private key from the environment variables used by cosign and by the sign
+// commands of tkn, or from in when it is a terminal.
+func KeyPassword(in io.Reader, errOut io.Writer) cryptoutils.PassFunc {
+	return func(_ bool) ([]byte, error) {
+		for _, env := range []string{"COSIGN_PASSWORD", "PRIVATE_PASSWORD"} {
+			if pw, ok := os.LookupEnv(env); ok {
+				return []byte(pw), nil
+			}
+		}
+		f, ok := in.(*os.File)
+		// #nosec G115 -- the file descriptor of stdin fits in an int
+		if !ok || !term.IsTerminal(int(f.Fd())) {
+			return nil, errors.New("the password of the private key must be given with COSIGN_PASSWORD")
+		}
+		fmt.Fprint(errOut, "Enter password for private key: ")
+		// #nosec G115 -- the file descriptor of stdin fits in an int
+		pw, err := term.ReadPassword(int(f.Fd()))
+		fmt.Fprintln(errOut)
+		return pw, err
+	}
+}
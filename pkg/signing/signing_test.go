@@ -0,0 +1,81 @@
+package signing
+
+import (
+	"bytes"
+	"context"
+	"crypto/elliptic"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+)
+
+func TestLoadSignerAndVerifier(t *testing.T) {
+	priv, pub, err := cryptoutils.GeneratePEMEncodedECDSAKeyPair(elliptic.P256(), cryptoutils.StaticPasswordFunc([]byte("secret")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	privPath, pubPath := filepath.Join(dir, "cosign.key"), filepath.Join(dir, "cosign.pub")
+	if err := os.WriteFile(privPath, priv, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(pubPath, pub, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	signer, err := LoadSigner(ctx, privPath, "", cryptoutils.StaticPasswordFunc([]byte("secret")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := signer.SignMessage(strings.NewReader("payload"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier, err := LoadVerifier(ctx, pubPath, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verifier.VerifySignature(bytes.NewReader(sig), strings.NewReader("payload")); err != nil {
+		t.Errorf("VerifySignature() = %v", err)
+	}
+	if err := verifier.VerifySignature(bytes.NewReader(sig), strings.NewReader("other")); err == nil {
+		t.Error("VerifySignature() of another payload succeeded")
+	}
+
+	if _, err := LoadSigner(ctx, privPath, "", cryptoutils.StaticPasswordFunc([]byte("wrong"))); err == nil {
+		t.Error("LoadSigner() with a wrong password succeeded")
+	}
+	if _, err := LoadVerifier(ctx, filepath.Join(dir, "missing.pub"), ""); err == nil {
+		t.Error("LoadVerifier() of a missing file succeeded")
+	}
+}
+
+func TestKeyPassword(t *testing.T) {
+	t.Setenv("COSIGN_PASSWORD", "secret")
+	pw, err := KeyPassword(strings.NewReader(""), &bytes.Buffer{})(false)
+	if err != nil || string(pw) != "secret" {
+		t.Errorf("KeyPassword() = %q, %v, want secret", pw, err)
+	}
+
+	os.Unsetenv("COSIGN_PASSWORD")
+	t.Setenv("PRIVATE_PASSWORD", "private")
+	pw, err = KeyPassword(strings.NewReader(""), &bytes.Buffer{})(false)
+	if err != nil || string(pw) != "private" {
+		t.Errorf("KeyPassword() = %q, %v, want private", pw, err)
+	}
+
+	os.Unsetenv("PRIVATE_PASSWORD")
+	errOut := &bytes.Buffer{}
+	_, err = KeyPassword(strings.NewReader("secret\n"), errOut)(false)
+	want := "the password of the private key must be given with COSIGN_PASSWORD"
+	if err == nil || err.Error() != want {
+		t.Errorf("KeyPassword() error = %v, want %s", err, want)
+	}
+	if errOut.Len() > 0 {
+		t.Errorf("prompt written without a terminal: %q", errOut.String())
+	}
+}
@@ -0,0 +1,109 @@
+package stepaction
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/tektoncd/cli/pkg/actions"
+	"github.com/tektoncd/cli/pkg/cli"
+	"github.com/tektoncd/cli/pkg/deleter"
+	"github.com/tektoncd/cli/pkg/formatted"
+	"github.com/tektoncd/cli/pkg/options"
+	"go.uber.org/multierr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	cliopts "k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+// stepActionExists validates that the arguments are valid StepAction names
+func stepActionExists(args []string, p cli.Params) ([]string, error) {
+	availableNames := make([]string, 0)
+	c, err := p.Clients()
+	if err != nil {
+		return availableNames, err
+	}
+	var errorList error
+	for _, name := range args {
+		if _, err := getStepAction(c, name, p.Namespace()); err != nil {
+			errorList = multierr.Append(errorList, err)
+			continue
+		}
+		availableNames = append(availableNames, name)
+	}
+	return availableNames, errorList
+}
+
+func deleteCommand(p cli.Params) *cobra.Command {
+	opts := &options.DeleteOptions{Resource: "StepAction", ForceDelete: false}
+	f := cliopts.NewPrintFlags("delete")
+	eg := `Delete StepActions with names 'foo' and 'bar' in namespace 'quux':
+
+    opc stepaction delete foo bar -n quux
+
+or
+
+    opc sa rm foo bar -n quux
+`
+
+	c := &cobra.Command{
+		Use:     "delete",
+		Aliases: []string{"rm"},
+		Short:   "Delete StepActions in a namespace",
+		Example: eg,
+		Args:    cobra.MinimumNArgs(0),
+
+		SilenceUsage: true,
+		Annotations: map[string]string{
+			"commandType": "main",
+		},
+		ValidArgsFunction: formatted.ParentCompletion,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s := &cli.Stream{
+				In:  cmd.InOrStdin(),
+				Out: cmd.OutOrStdout(),
+				Err: cmd.OutOrStderr(),
+			}
+
+			availableNames, errs := stepActionExists(args, p)
+			if len(availableNames) == 0 && errs != nil {
+				return errs
+			}
+
+			if err := opts.CheckOptions(s, availableNames, p.Namespace()); err != nil {
+				return err
+			}
+
+			if err := deleteStepActions(opts, s, p, availableNames); err != nil {
+				return err
+			}
+			return errs
+		},
+	}
+	f.AddFlags(c)
+	c.Flags().BoolVarP(&opts.ForceDelete, "force", "f", false, "Whether to force deletion (default: false)")
+	c.Flags().BoolVarP(&opts.DeleteAllNs, "all", "", false, "Delete all StepActions in a namespace (default: false)")
+
+	return c
+}
+
+func deleteStepActions(opts *options.DeleteOptions, s *cli.Stream, p cli.Params, names []string) error {
+	cs, err := p.Clients()
+	if err != nil {
+		return fmt.Errorf("failed to create tekton client")
+	}
+	d := deleter.New("StepAction", func(name string) error {
+		return actions.Delete(stepActionGroupResource, cs.Dynamic, cs.Tekton.Discovery(), name, p.Namespace(), metav1.DeleteOptions{})
+	})
+	if opts.DeleteAllNs {
+		names, err = stepActionNames(cs, p.Namespace())
+		if err != nil {
+			return err
+		}
+	}
+	d.Delete(names)
+	if !opts.DeleteAllNs {
+		d.PrintSuccesses(s)
+	} else if d.Errors() == nil {
+		fmt.Fprintf(s.Out, "All StepActions deleted in namespace %q\n", p.Namespace())
+	}
+	return d.Errors()
+}
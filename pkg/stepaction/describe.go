@@ -0,0 +1,219 @@
+package stepaction
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"github.com/spf13/cobra"
+	"github.com/tektoncd/cli/pkg/actions"
+	"github.com/tektoncd/cli/pkg/cli"
+	"github.com/tektoncd/cli/pkg/formatted"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	cliopts "k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+const describeTemplate = `{{decorate "bold" "Name"}}:	{{ .StepAction.Name }}
+{{decorate "bold" "Namespace"}}:	{{ .StepAction.Namespace }}
+{{- if ne .StepAction.Spec.Description "" }}
+{{decorate "bold" "Description"}}:	{{ .StepAction.Spec.Description }}
+{{- end }}
+{{- $v := findVersion .StepAction.Labels }} {{- if ne $v ""}}
+{{decorate "bold" "Version"}}:    	{{ $v }}
+{{- end }}
+{{decorate "bold" "Image"}}:	{{ .StepAction.Spec.Image }}
+{{- if ne (len .StepAction.Spec.Command) 0 }}
+{{decorate "bold" "Command"}}:	{{ join .StepAction.Spec.Command }}
+{{- end }}
+{{- if ne (len .StepAction.Spec.Args) 0 }}
+{{decorate "bold" "Args"}}:	{{ join .StepAction.Spec.Args }}
+{{- end }}
+{{- if ne .StepAction.Spec.Script "" }}
+{{decorate "bold" "Script"}}:	{{ scriptLines .StepAction.Spec.Script }} lines
+{{- end }}
+{{- $annotations := removeLastAppliedConfig .StepAction.Annotations -}}
+{{- if $annotations }}
+{{decorate "bold" "Annotations"}}:
+{{- range $k, $v := $annotations }}
+ {{ $k }}={{ $v }}
+{{- end }}
+{{- end }}
+
+{{- if ne (len .StepAction.Spec.Params) 0 }}
+
+{{decorate "params" ""}}{{decorate "underline bold" "Params\n"}}
+ NAME	TYPE	DESCRIPTION	DEFAULT VALUE
+{{- range $p := .StepAction.Spec.Params }}
+{{- if not $p.Default }}
+ {{decorate "bullet" $p.Name }}	{{ $p.Type }}	{{ formatDesc $p.Description }}	{{ "---" }}
+{{- else }}
+{{- if eq $p.Type "string" }}
+ {{decorate "bullet" $p.Name }}	{{ $p.Type }}	{{ formatDesc $p.Description }}	{{ $p.Default.StringVal }}
+{{- else if eq $p.Type "array" }}
+ {{decorate "bullet" $p.Name }}	{{ $p.Type }}	{{ formatDesc $p.Description }}	{{ $p.Default.ArrayVal }}
+{{- else }}
+ {{decorate "bullet" $p.Name }}	{{ $p.Type }}	{{ formatDesc $p.Description }}	{{ $p.Default.ObjectVal }}
+{{- end }}
+{{- end }}
+{{- end }}
+{{- end }}
+
+{{- if ne (len .StepAction.Spec.Results) 0 }}
+
+{{decorate "results" ""}}{{decorate "underline bold" "Results\n"}}
+ NAME	TYPE	DESCRIPTION
+{{- range $result := .StepAction.Spec.Results }}
+ {{ decorate "bullet" $result.Name }}	{{ $result.Type }}	{{ formatDesc $result.Description }}
+{{- end }}
+{{- end }}
+
+{{decorate "tasks" ""}}{{decorate "underline bold" "Tasks\n"}}
+{{- if eq (len .References) 0 }}
+ No Tasks referencing the StepAction
+{{- else }}
+ NAME	STEPS
+{{- range $r := .References }}
+ {{ decorate "bullet" $r.Task }}	{{ join $r.Steps }}
+{{- end }}
+{{- end }}
+`
+
+type describeOptions struct {
+	Fzf bool
+}
+
+// taskReference is a Task with the names of its steps referencing a
+// StepAction.
+type taskReference struct {
+	Task  string
+	Steps []string
+}
+
+func describeCommand(p cli.Params) *cobra.Command {
+	f := cliopts.NewPrintFlags("describe")
+	opts := &describeOptions{}
+	eg := `Describe a StepAction of name 'foo' in namespace 'bar':
+
+    opc stepaction describe foo -n bar
+
+or
+
+    opc sa desc foo -n bar
+`
+
+	c := &cobra.Command{
+		Use:               "describe",
+		Aliases:           []string{"desc"},
+		ValidArgsFunction: formatted.ParentCompletion,
+		Short:             "Describe a StepAction in a namespace",
+		Long: `Describe a StepAction in a namespace with the Tasks of the namespace which have steps referencing it
+with ref.`,
+		Example: eg,
+		Annotations: map[string]string{
+			"commandType": "main",
+		},
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s := &cli.Stream{
+				Out: cmd.OutOrStdout(),
+				Err: cmd.OutOrStderr(),
+			}
+
+			output, err := cmd.LocalFlags().GetString("output")
+			if err != nil {
+				return fmt.Errorf("output option not set properly: %v", err)
+			}
+
+			cs, err := p.Clients()
+			if err != nil {
+				return err
+			}
+
+			var name string
+			if len(args) == 0 {
+				if name, err = askStepActionName(cs, p.Namespace(), opts.Fzf); err != nil {
+					return err
+				}
+			} else {
+				name = args[0]
+			}
+
+			if output != "" {
+				printer, err := f.ToPrinter()
+				if err != nil {
+					return err
+				}
+				return actions.PrintObjectV1(stepActionGroupResource, name, cmd.OutOrStdout(), cs, printer, p.Namespace())
+			}
+
+			return printStepActionDescription(s, p, cs, name)
+		},
+	}
+
+	f.AddFlags(c)
+	c.Flags().BoolVarP(&opts.Fzf, "fzf", "F", false, "use fzf to select a StepAction to describe")
+	return c
+}
+
+func printStepActionDescription(s *cli.Stream, p cli.Params, cs *cli.Clients, name string) error {
+	sa, err := getStepAction(cs, name, p.Namespace())
+	if err != nil {
+		return fmt.Errorf("failed to get StepAction %s: %v", name, err)
+	}
+
+	var tasks *v1.TaskList
+	if err := actions.ListV1(taskGroupResource, cs, metav1.ListOptions{}, p.Namespace(), &tasks); err != nil {
+		return fmt.Errorf("failed to list Tasks from namespace %s: %v", p.Namespace(), err)
+	}
+
+	var data = struct {
+		StepAction *v1beta1.StepAction
+		References []taskReference
+	}{
+		StepAction: sa,
+		References: taskReferences(tasks.Items, sa.Name),
+	}
+
+	funcMap := template.FuncMap{
+		"decorate":                formatted.DecorateAttr,
+		"formatDesc":              formatted.FormatDesc,
+		"findVersion":             formatted.FindVersion,
+		"removeLastAppliedConfig": formatted.RemoveLastAppliedConfig,
+		"join":                    func(s []string) string { return strings.Join(s, " ") },
+		"scriptLines":             func(s string) int { return len(strings.Split(strings.TrimRight(s, "\n"), "\n")) },
+	}
+
+	w := tabwriter.NewWriter(s.Out, 0, 5, 3, ' ', tabwriter.TabIndent)
+	t := template.Must(template.New("Describe StepAction").Funcs(funcMap).Parse(describeTemplate))
+	if err := t.Execute(w, data); err != nil {
+		return fmt.Errorf("failed to execute template: %v", err)
+	}
+
+	return w.Flush()
+}
+
+// taskReferences returns the Tasks with steps referencing the StepAction by
+// name, the steps referencing StepActions with a resolver are ignored.
+func taskReferences(tasks []v1.Task, name string) []taskReference {
+	refs := []taskReference{}
+	for _, t := range tasks {
+		ref := taskReference{Task: t.Name}
+		for i, step := range t.Spec.Steps {
+			if step.Ref == nil || step.Ref.Resolver != "" || step.Ref.Name != name {
+				continue
+			}
+			stepName := step.Name
+			if stepName == "" {
+				stepName = fmt.Sprintf("unnamed-%d", i)
+			}
+			ref.Steps = append(ref.Steps, stepName)
+		}
+		if len(ref.Steps) > 0 {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
@@ -0,0 +1,74 @@
+package stepaction
+
+import (
+	"io"
+
+	"github.com/spf13/cobra"
+	"github.com/tektoncd/cli/pkg/actions"
+	"github.com/tektoncd/cli/pkg/cli"
+	"github.com/tektoncd/cli/pkg/export"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+type exportOptions struct {
+	Fzf bool
+}
+
+func exportCommand(p cli.Params) *cobra.Command {
+	opts := &exportOptions{}
+	eg := `Export a StepAction named 'foo' in namespace 'bar' and recreate it in the namespace 'baz':
+
+    opc stepaction export foo -n bar | kubectl create -f- -n baz
+`
+
+	c := &cobra.Command{
+		Use:     "export",
+		Short:   "Export StepAction",
+		Long:    `Export a StepAction definition as yaml to be easily reimported or modified.`,
+		Example: eg,
+		Annotations: map[string]string{
+			"commandType": "main",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cs, err := p.Clients()
+			if err != nil {
+				return err
+			}
+
+			var name string
+			if len(args) == 0 {
+				if name, err = askStepActionName(cs, p.Namespace(), opts.Fzf); err != nil {
+					return err
+				}
+			} else {
+				name = args[0]
+			}
+
+			return exportStepAction(cmd.OutOrStdout(), cs, p.Namespace(), name)
+		},
+	}
+	c.Flags().BoolVarP(&opts.Fzf, "fzf", "F", false, "use fzf to select a StepAction to export")
+	return c
+}
+
+func exportStepAction(out io.Writer, c *cli.Clients, ns string, name string) error {
+	obj, err := actions.GetUnstructured(stepActionGroupResource, c, name, ns, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	if err := export.RemoveFieldForExport(obj); err != nil {
+		return err
+	}
+
+	obj.SetKind("StepAction")
+
+	data, err := yaml.Marshal(obj)
+	if err != nil {
+		return err
+	}
+
+	_, err = out.Write(data)
+	return err
+}
@@ -0,0 +1,136 @@
+package stepaction
+
+import (
+	"fmt"
+	"text/tabwriter"
+	"text/template"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/spf13/cobra"
+	"github.com/tektoncd/cli/pkg/actions"
+	"github.com/tektoncd/cli/pkg/cli"
+	"github.com/tektoncd/cli/pkg/formatted"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	cliopts "k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+const listTemplate = `{{- $sl := len .StepActions.Items }}{{ if eq $sl 0 -}}
+No StepActions found
+{{ else -}}
+{{- if not $.NoHeaders -}}
+{{- if $.AllNamespaces -}}
+NAMESPACE	NAME	IMAGE	DESCRIPTION	AGE
+{{ else -}}
+NAME	IMAGE	DESCRIPTION	AGE
+{{ end -}}
+{{- end -}}
+{{- range $_, $s := .StepActions.Items }}
+{{- if $.AllNamespaces -}}
+{{ $s.Namespace }}	{{ $s.Name }}	{{ $s.Spec.Image }}	{{ formatDesc $s.Spec.Description }}	{{ formatAge $s.CreationTimestamp $.Time }}
+{{ else -}}
+{{ $s.Name }}	{{ $s.Spec.Image }}	{{ formatDesc $s.Spec.Description }}	{{ formatAge $s.CreationTimestamp $.Time }}
+{{ end }}{{- end }}
+{{- end -}}
+`
+
+type listOptions struct {
+	AllNamespaces bool
+	NoHeaders     bool
+	LabelSelector string
+}
+
+func listCommand(p cli.Params) *cobra.Command {
+	opts := &listOptions{}
+	f := cliopts.NewPrintFlags("list")
+	eg := `List all StepActions in namespace 'bar':
+
+    opc stepaction list -n bar
+
+List the StepActions of all namespaces with the label app=build as yaml:
+
+    opc stepaction list -A --label app=build -o yaml
+`
+
+	c := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "Lists StepActions in a namespace",
+		Example: eg,
+		Annotations: map[string]string{
+			"commandType": "main",
+		},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cs, err := p.Clients()
+			if err != nil {
+				return err
+			}
+
+			output, err := cmd.LocalFlags().GetString("output")
+			if err != nil {
+				return fmt.Errorf("error: output option not set properly: %v", err)
+			}
+
+			ns := p.Namespace()
+			if opts.AllNamespaces {
+				ns = ""
+			}
+			listOpts := metav1.ListOptions{LabelSelector: opts.LabelSelector}
+
+			if output != "" {
+				printer, err := f.ToPrinter()
+				if err != nil {
+					return err
+				}
+				sas, err := actions.List(stepActionGroupResource, cs.Dynamic, cs.Tekton.Discovery(), ns, listOpts)
+				if err != nil {
+					return fmt.Errorf("failed to list StepActions from namespace %s: %v", ns, err)
+				}
+				return printer.PrintObj(sas, cmd.OutOrStdout())
+			}
+
+			sas, err := listStepActions(cs, listOpts, ns)
+			if err != nil {
+				return fmt.Errorf("failed to list StepActions from namespace %s: %v", ns, err)
+			}
+			stream := &cli.Stream{
+				Out: cmd.OutOrStdout(),
+				Err: cmd.OutOrStderr(),
+			}
+			return printStepActions(stream, p, sas, opts)
+		},
+	}
+	f.AddFlags(c)
+	c.Flags().BoolVarP(&opts.AllNamespaces, "all-namespaces", "A", opts.AllNamespaces, "list StepActions from all namespaces")
+	c.Flags().BoolVarP(&opts.NoHeaders, "no-headers", "", opts.NoHeaders, "do not print column headers with output (default print column headers with output)")
+	c.Flags().StringVarP(&opts.LabelSelector, "label", "", opts.LabelSelector, "A selector (label query) to filter on, supports '=', '==', and '!='")
+
+	return c
+}
+
+func printStepActions(s *cli.Stream, p cli.Params, sas *v1beta1.StepActionList, opts *listOptions) error {
+	var data = struct {
+		StepActions   *v1beta1.StepActionList
+		Time          clockwork.Clock
+		AllNamespaces bool
+		NoHeaders     bool
+	}{
+		StepActions:   sas,
+		Time:          p.Time(),
+		AllNamespaces: opts.AllNamespaces,
+		NoHeaders:     opts.NoHeaders,
+	}
+
+	funcMap := template.FuncMap{
+		"formatAge":  formatted.Age,
+		"formatDesc": formatted.FormatDesc,
+	}
+
+	w := tabwriter.NewWriter(s.Out, 0, 5, 3, ' ', tabwriter.TabIndent)
+	t := template.Must(template.New("List StepActions").Funcs(funcMap).Parse(listTemplate))
+	if err := t.Execute(w, data); err != nil {
+		return err
+	}
+
+	return w.Flush()
+}
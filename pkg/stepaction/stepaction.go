@@ -0,0 +1,126 @@
+// Package stepaction adds the commands managing the StepActions, the
+// reusable steps referenced by the steps of Tasks.
+package stepaction
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/fatih/color"
+	"github.com/ktr0731/go-fuzzyfinder"
+	"github.com/spf13/cobra"
+	"github.com/tektoncd/cli/pkg/actions"
+	"github.com/tektoncd/cli/pkg/cli"
+	"github.com/tektoncd/cli/pkg/cli/prerun"
+	"github.com/tektoncd/cli/pkg/flags"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var stepActionGroupResource = schema.GroupVersionResource{Group: "tekton.dev", Resource: "stepactions"}
+var taskGroupResource = schema.GroupVersionResource{Group: "tekton.dev", Resource: "tasks"}
+
+// Command returns the stepaction command, with the same subcommands and
+// flags as the task command.
+func Command(p cli.Params) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "stepaction",
+		Aliases: []string{"sa", "stepactions"},
+		Short:   "Manage StepActions",
+		Annotations: map[string]string{
+			"commandType": "main",
+		},
+		PersistentPreRunE: prerun.PersistentPreRunE(p),
+	}
+
+	flags.AddTektonOptions(cmd)
+	cmd.AddCommand(
+		deleteCommand(p),
+		describeCommand(p),
+		exportCommand(p),
+		listCommand(p),
+	)
+	return cmd
+}
+
+func getStepAction(c *cli.Clients, name, ns string) (*v1beta1.StepAction, error) {
+	// v1alpha1 and v1beta1 StepActions have the same spec
+	var sa v1beta1.StepAction
+	if err := actions.GetV1(stepActionGroupResource, c, name, ns, metav1.GetOptions{}, &sa); err != nil {
+		return nil, err
+	}
+	return &sa, nil
+}
+
+func listStepActions(c *cli.Clients, opts metav1.ListOptions, ns string) (*v1beta1.StepActionList, error) {
+	var sas *v1beta1.StepActionList
+	if err := actions.ListV1(stepActionGroupResource, c, opts, ns, &sas); err != nil {
+		return nil, err
+	}
+	sort.Slice(sas.Items, func(i, j int) bool {
+		if sas.Items[i].Namespace != sas.Items[j].Namespace {
+			return sas.Items[i].Namespace < sas.Items[j].Namespace
+		}
+		return sas.Items[i].Name < sas.Items[j].Name
+	})
+	return sas, nil
+}
+
+func stepActionNames(c *cli.Clients, ns string) ([]string, error) {
+	sas, err := listStepActions(c, metav1.ListOptions{}, ns)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list StepActions from namespace %s: %v", ns, err)
+	}
+	names := []string{}
+	for _, sa := range sas.Items {
+		names = append(names, sa.Name)
+	}
+	return names, nil
+}
+
+// askStepActionName returns the StepAction to use when none is given, asking
+// to select one with a prompt or with fzf when there are several.
+func askStepActionName(c *cli.Clients, ns string, fzf bool) (string, error) {
+	names, err := stepActionNames(c, ns)
+	if err != nil {
+		return "", err
+	}
+	switch len(names) {
+	case 0:
+		return "", fmt.Errorf("no StepActions found in namespace %s", ns)
+	case 1:
+		return names[0], nil
+	}
+
+	if fzf {
+		chosenColouring := color.NoColor
+		defer func() {
+			color.NoColor = chosenColouring
+		}()
+		// Remove colors as fuzzyfinder doesn't support it
+		color.NoColor = true
+		idx, err := fuzzyfinder.Find(names, func(i int) string { return names[i] })
+		if err != nil {
+			return "", err
+		}
+		return names[idx], nil
+	}
+
+	var ans string
+	qs := []*survey.Question{{
+		Name: "stepaction",
+		Prompt: &survey.Select{
+			Message: "Select stepaction:",
+			Options: names,
+		},
+	}}
+	err = survey.Ask(qs, &ans, func(opt *survey.AskOptions) error {
+		opt.Stdio = terminal.Stdio{In: os.Stdin, Out: os.Stdout, Err: os.Stderr}
+		return nil
+	})
+	return ans, err
+}
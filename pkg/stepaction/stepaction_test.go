@@ -0,0 +1,79 @@
+package stepaction
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tektoncd/cli/pkg/cli"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestTaskReferences(t *testing.T) {
+	task := func(name string, steps ...v1.Step) v1.Task {
+		return v1.Task{ObjectMeta: metav1.ObjectMeta{Name: name}, Spec: v1.TaskSpec{Steps: steps}}
+	}
+	tasks := []v1.Task{
+		task("build",
+			v1.Step{Name: "clone", Ref: &v1.Ref{Name: "git-clone"}},
+			v1.Step{Name: "compile", Image: "golang"},
+			v1.Step{Ref: &v1.Ref{Name: "git-clone"}},
+		),
+		task("remote", v1.Step{Name: "clone", Ref: &v1.Ref{Name: "git-clone", ResolverRef: v1.ResolverRef{Resolver: "hub"}}}),
+		task("test", v1.Step{Name: "test", Image: "golang"}),
+		task("release", v1.Step{Name: "fetch", Ref: &v1.Ref{Name: "git-clone"}}),
+	}
+	want := []taskReference{
+		{Task: "build", Steps: []string{"clone", "unnamed-2"}},
+		{Task: "release", Steps: []string{"fetch"}},
+	}
+	if d := cmp.Diff(want, taskReferences(tasks, "git-clone")); d != "" {
+		t.Errorf("taskReferences() (-want +got):\n%s", d)
+	}
+}
+
+func TestPrintStepActions(t *testing.T) {
+	created := metav1.NewTime(time.Now().Add(-2 * time.Hour))
+	sas := &v1beta1.StepActionList{Items: []v1beta1.StepAction{{
+		ObjectMeta: metav1.ObjectMeta{Name: "git-clone", Namespace: "bar", CreationTimestamp: created},
+		Spec:       v1beta1.StepActionSpec{Image: "alpine/git", Description: "Clone a repository"},
+	}}}
+	tests := []struct {
+		name string
+		sas  *v1beta1.StepActionList
+		opts *listOptions
+		want string
+	}{{
+		name: "namespace",
+		sas:  sas,
+		opts: &listOptions{},
+		want: `NAME        IMAGE        DESCRIPTION          AGE
+git-clone   alpine/git   Clone a repository   2 hours ago
+`,
+	}, {
+		name: "all namespaces without headers",
+		sas:  sas,
+		opts: &listOptions{AllNamespaces: true, NoHeaders: true},
+		want: `bar   git-clone   alpine/git   Clone a repository   2 hours ago
+`,
+	}, {
+		name: "none",
+		sas:  &v1beta1.StepActionList{},
+		opts: &listOptions{},
+		want: "No StepActions found\n",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			if err := printStepActions(&cli.Stream{Out: out, Err: out}, &cli.TektonParams{}, tt.sas, tt.opts); err != nil {
+				t.Fatal(err)
+			}
+			if d := cmp.Diff(tt.want, out.String()); d != "" {
+				t.Errorf("printStepActions() (-want +got):\n%s", d)
+			}
+		})
+	}
+}
@@ -0,0 +1,244 @@
+// Package task extends the task commands of the Tekton CLI with opc specific
+// commands.
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tektoncd/cli/pkg/cli"
+	"github.com/tektoncd/cli/pkg/file"
+	"github.com/tektoncd/cli/pkg/params"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	"knative.dev/pkg/apis"
+	"sigs.k8s.io/yaml"
+)
+
+type runLocalOptions struct {
+	Filename   string
+	Params     []string
+	Workspaces []string
+	Runtime    string
+	Timeout    time.Duration
+}
+
+// RunLocalCommand returns the command running a Task with a local container
+// runtime.
+func RunLocalCommand(p cli.Params) *cobra.Command {
+	opts := &runLocalOptions{Timeout: time.Hour}
+	eg := `Run the Task of task.yaml with the param image and the workspace source bound to the current directory:
+
+    opc task run-local -f task.yaml -p image=quay.io/myorg/app -w source=.
+
+Run it with docker, failing when it runs for more than 10 minutes:
+
+    opc task run-local -f task.yaml -p image=quay.io/myorg/app -w source=. --runtime docker --timeout 10m
+`
+
+	c := &cobra.Command{
+		Use:   "run-local",
+		Short: "Run a Task with a local container runtime",
+		Long: `Run a Task of a local or remote file without a cluster, with podman or docker.
+
+The params, workspaces, results and context variables of the steps are replaced the same way as the Tekton
+controller does. The steps run one after the other in containers sharing the /workspace and /tekton/results
+directories, the workspaces are bound to local directories mounted at their path. The logs of the steps are
+streamed like "tkn taskrun logs" and the TaskRun fails the same way: when a step fails, unless its onError is
+continue, the following steps are skipped. The namespace of the TaskRun is the one given with --namespace, default
+otherwise.
+
+The steps referencing StepActions, the volumes other than emptyDir and the values of the environment variables
+read from the cluster are not supported, the sidecars are not run.`,
+		Example: eg,
+		Args:    cobra.NoArgs,
+		Annotations: map[string]string{
+			"commandType": "main",
+			"kubernetes":  "false",
+		},
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if opts.Filename == "" {
+				return errors.New("a file containing the Task must be given with --filename")
+			}
+			task, err := parseTask(opts.Filename)
+			if err != nil {
+				return err
+			}
+			ctx := cmd.Context()
+			task.SetDefaults(ctx)
+			if err := task.Validate(apis.WithinCreate(ctx)); err != nil {
+				return fmt.Errorf("invalid Task %s: %v", task.Name, err)
+			}
+			values, err := paramValues(task, opts.Params)
+			if err != nil {
+				return err
+			}
+			workspaces, err := workspaceBindings(task, opts.Workspaces)
+			if err != nil {
+				return err
+			}
+			runtime, err := containerRuntime(opts.Runtime)
+			if err != nil {
+				return err
+			}
+
+			// the namespace is the one of the flag as the kubeconfig is
+			// not read without a cluster
+			ns := p.Namespace()
+			if ns == "" {
+				ns = "default"
+			}
+			s := &cli.Stream{Out: cmd.OutOrStdout(), Err: cmd.OutOrStderr()}
+			run, err := newLocalRun(task, runtime, ns, values, workspaces)
+			if err != nil {
+				return err
+			}
+			defer os.RemoveAll(run.dir)
+			if len(task.Spec.Sidecars) > 0 {
+				fmt.Fprintf(s.Err, "Warning: the sidecars of Task %s are not run\n", task.Name)
+			}
+
+			ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+			defer cancel()
+			return run.run(ctx, s, opts.Timeout)
+		},
+	}
+	c.Flags().StringVarP(&opts.Filename, "filename", "f", "", "local or remote file containing the Task")
+	c.Flags().StringArrayVarP(&opts.Params, "param", "p", nil, "pass the param as key=value for string type, or key=value1,value2,... for array type, or key=\"key1:value1, key2:value2\" for object type")
+	c.Flags().StringArrayVarP(&opts.Workspaces, "workspace", "w", nil, "bind the workspace to a local directory, as name=path")
+	c.Flags().StringVar(&opts.Runtime, "runtime", "", "container runtime running the steps, podman or docker, podman when installed by default")
+	c.Flags().DurationVar(&opts.Timeout, "timeout", opts.Timeout, "timeout of the TaskRun")
+
+	return c
+}
+
+func parseTask(location string) (*v1.Task, error) {
+	httpClient := http.Client{Timeout: 10 * time.Second}
+	b, err := file.LoadFileContent(httpClient, location, file.IsYamlFile(), fmt.Errorf("invalid file format for %s: .yaml or .yml file extension and format required", location))
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]interface{}{}
+	if err := yaml.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	if m["kind"] != "Task" {
+		return nil, fmt.Errorf("%s does not contain a Task", location)
+	}
+
+	task := &v1.Task{}
+	switch m["apiVersion"] {
+	case "tekton.dev/v1":
+		if err := yaml.UnmarshalStrict(b, task); err != nil {
+			return nil, err
+		}
+	case "tekton.dev/v1beta1":
+		taskV1beta1 := &v1beta1.Task{}
+		if err := yaml.UnmarshalStrict(b, taskV1beta1); err != nil {
+			return nil, err
+		}
+		if err := taskV1beta1.ConvertTo(context.Background(), task); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported Task version %v", m["apiVersion"])
+	}
+	return task, nil
+}
+
+// paramValues returns the values of the params of the task, from the flags
+// or from their defaults. The errors are the ones of the TaskRuns.
+func paramValues(task *v1.Task, flags []string) (map[string]v1.ParamValue, error) {
+	parsed, err := params.ParseParams(flags)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]v1.ParamValue{}
+	for name, value := range parsed {
+		var spec *v1.ParamSpec
+		for i := range task.Spec.Params {
+			if task.Spec.Params[i].Name == name {
+				spec = &task.Spec.Params[i]
+			}
+		}
+		if spec == nil {
+			return nil, fmt.Errorf("param '%s' not present in spec", name)
+		}
+		v := v1.ParamValue{Type: spec.Type}
+		switch spec.Type {
+		case v1.ParamTypeArray:
+			v.ArrayVal = []string{}
+			if value != "" {
+				v.ArrayVal = strings.Split(value, ",")
+			}
+		case v1.ParamTypeObject:
+			v.ObjectVal = map[string]string{}
+			for _, field := range strings.Split(value, ",") {
+				key, val, ok := strings.Cut(field, ":")
+				if !ok {
+					return nil, fmt.Errorf("invalid input format for param parameter: %s=%s", name, value)
+				}
+				v.ObjectVal[strings.TrimSpace(key)] = strings.TrimSpace(val)
+			}
+		default:
+			v.StringVal = value
+		}
+		values[name] = v
+	}
+
+	missing := []string{}
+	for _, spec := range task.Spec.Params {
+		if _, ok := values[spec.Name]; ok {
+			continue
+		}
+		if spec.Default == nil {
+			missing = append(missing, spec.Name)
+			continue
+		}
+		values[spec.Name] = *spec.Default
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("invalid input params for task %s: missing parameters: %v", task.Name, missing)
+	}
+	return values, nil
+}
+
+// workspaceBindings returns the local directories of the workspaces of the
+// task by name, they must exist.
+func workspaceBindings(task *v1.Task, flags []string) (map[string]string, error) {
+	bindings := map[string]string{}
+	for _, f := range flags {
+		name, path, ok := strings.Cut(f, "=")
+		if !ok || name == "" || path == "" {
+			return nil, fmt.Errorf("invalid input format for workspace: %s, it must be name=path", f)
+		}
+		declared := false
+		for _, ws := range task.Spec.Workspaces {
+			declared = declared || ws.Name == name
+		}
+		if !declared {
+			return nil, fmt.Errorf("workspace binding %q does not match any declared workspace", name)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid workspace %s: %v", name, err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("invalid workspace %s: %s is not a directory", name, path)
+		}
+		bindings[name] = path
+	}
+	for _, ws := range task.Spec.Workspaces {
+		if _, ok := bindings[ws.Name]; !ok && !ws.Optional {
+			return nil, fmt.Errorf("declared workspace %q is required but has not been bound", ws.Name)
+		}
+	}
+	return bindings, nil
+}
@@ -0,0 +1,180 @@
+package task
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tektoncd/cli/pkg/cli"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+)
+
+// fakeRuntime writes a container runtime with the name printing the
+// arguments of each container to the log and writing them to args.
+func fakeRuntime(t *testing.T, name string) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	args := filepath.Join(dir, "args")
+	script := "#!/bin/sh\necho \"$@\" >> " + args + "\necho \"ran $#\"\n"
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil { // #nosec G306
+		t.Fatal(err)
+	}
+	return path, args
+}
+
+func TestRunLocal(t *testing.T) {
+	dir := t.TempDir()
+	task := `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: hello
+spec:
+  params:
+  - name: greeting
+    default: hello
+  workspaces:
+  - name: source
+    readOnly: true
+  steps:
+  - name: greet
+    image: alpine
+    command: ["echo"]
+    args: ["$(params.greeting)", "$(context.taskRun.namespace)"]
+`
+	taskFile := filepath.Join(dir, "task.yaml")
+	if err := os.WriteFile(taskFile, []byte(task), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		runtime   string
+		namespace string
+		wantArgs  []string
+	}{{
+		name:      "podman",
+		runtime:   "podman",
+		namespace: "foo",
+		wantArgs:  []string{":/workspace:z", ":/tekton/scripts:ro,z", dir + ":/workspace/source:ro,z", "alpine hi foo"},
+	}, {
+		name:     "docker",
+		runtime:  "docker",
+		wantArgs: []string{":/workspace ", ":/tekton/scripts:ro ", dir + ":/workspace/source:ro ", "alpine hi default"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runtime, argsFile := fakeRuntime(t, tt.runtime)
+			p := &cli.TektonParams{}
+			p.SetNamespace(tt.namespace)
+			c := RunLocalCommand(p)
+			out := &bytes.Buffer{}
+			c.SetOut(out)
+			c.SetErr(out)
+			c.SetArgs([]string{"-f", taskFile, "-p", "greeting=hi", "-w", "source=" + dir, "--runtime", runtime})
+			if err := c.Execute(); err != nil {
+				t.Fatalf("run-local: %v: %s", err, out)
+			}
+			if !strings.Contains(out.String(), "[greet] ran ") || !strings.Contains(out.String(), "succeeded") {
+				t.Errorf("unexpected output: %s", out)
+			}
+			b, err := os.ReadFile(argsFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.wantArgs {
+				if !strings.Contains(string(b), want) {
+					t.Errorf("the arguments of the runtime do not contain %q: %s", want, b)
+				}
+			}
+		})
+	}
+}
+
+func TestParamValues(t *testing.T) {
+	task := &v1.Task{Spec: v1.TaskSpec{Params: v1.ParamSpecs{
+		{Name: "image", Type: v1.ParamTypeString},
+		{Name: "flags", Type: v1.ParamTypeArray},
+		{Name: "labels", Type: v1.ParamTypeObject},
+		{Name: "tag", Type: v1.ParamTypeString, Default: v1.NewStructuredValues("latest")},
+	}}}
+	task.Name = "build"
+
+	got, err := paramValues(task, []string{"image=quay.io/app", "flags=-v,--fast", "labels=app: web, tier: front"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]v1.ParamValue{
+		"image":  *v1.NewStructuredValues("quay.io/app"),
+		"flags":  *v1.NewStructuredValues("-v", "--fast"),
+		"labels": *v1.NewObject(map[string]string{"app": "web", "tier": "front"}),
+		"tag":    *v1.NewStructuredValues("latest"),
+	}
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("paramValues() (-want +got):\n%s", d)
+	}
+
+	tests := []struct {
+		flags []string
+		want  string
+	}{{
+		flags: []string{"image=quay.io/app", "other=1"},
+		want:  "param 'other' not present in spec",
+	}, {
+		flags: []string{"flags=-v"},
+		want:  "invalid input params for task build: missing parameters: [image labels]",
+	}, {
+		flags: []string{"image=quay.io/app", "labels=app"},
+		want:  "invalid input format for param parameter: labels=app",
+	}}
+	for _, tt := range tests {
+		if _, err := paramValues(task, tt.flags); err == nil || err.Error() != tt.want {
+			t.Errorf("paramValues(%v) error = %v, want %s", tt.flags, err, tt.want)
+		}
+	}
+}
+
+func TestWorkspaceBindings(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	task := &v1.Task{Spec: v1.TaskSpec{Workspaces: []v1.WorkspaceDeclaration{
+		{Name: "source"},
+		{Name: "cache", Optional: true},
+	}}}
+
+	got, err := workspaceBindings(task, []string{"source=" + dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := cmp.Diff(map[string]string{"source": dir}, got); d != "" {
+		t.Errorf("workspaceBindings() (-want +got):\n%s", d)
+	}
+
+	tests := []struct {
+		flags []string
+		want  string
+	}{{
+		flags: []string{"source"},
+		want:  "invalid input format for workspace: source, it must be name=path",
+	}, {
+		flags: []string{"source=" + dir, "other=" + dir},
+		want:  `workspace binding "other" does not match any declared workspace`,
+	}, {
+		flags: []string{"source=" + file},
+		want:  "invalid workspace source: " + file + " is not a directory",
+	}, {
+		flags: []string{"cache=" + dir},
+		want:  `declared workspace "source" is required but has not been bound`,
+	}}
+	for _, tt := range tests {
+		if _, err := workspaceBindings(task, tt.flags); err == nil || err.Error() != tt.want {
+			t.Errorf("workspaceBindings(%v) error = %v, want %s", tt.flags, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,361 @@
+package task
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/tektoncd/cli/pkg/cli"
+	"github.com/tektoncd/cli/pkg/log"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"k8s.io/apimachinery/pkg/util/uuid"
+)
+
+// The statuses of the steps, the same as the reasons of the terminated
+// containers of the TaskRuns.
+const (
+	statusCompleted = "Completed"
+	statusError     = "Error"
+	statusTimeout   = "TimeoutExceeded"
+	statusSkipped   = "Skipped"
+)
+
+// containerRuntime returns the path of the container runtime, podman or
+// docker when it is not given.
+func containerRuntime(name string) (string, error) {
+	if name != "" {
+		path, err := exec.LookPath(name)
+		if err != nil {
+			return "", fmt.Errorf("container runtime %s not found: %v", name, err)
+		}
+		return path, nil
+	}
+	for _, name := range []string{"podman", "docker"} {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", errors.New("podman or docker is required to run the steps, none was found")
+}
+
+// localRun is a TaskRun of a task with a local container runtime. The
+// directories shared by the steps are created in a temporary directory.
+type localRun struct {
+	task       *v1.Task
+	name       string
+	runtime    string
+	dir        string
+	workspaces map[string]string
+	steps      []v1.Step
+	variables  *variables
+	statuses   []stepStatus
+}
+
+type stepStatus struct {
+	name     string
+	status   string
+	exitCode int
+}
+
+func newLocalRun(task *v1.Task, runtime, namespace string, values map[string]v1.ParamValue, workspaces map[string]string) (*localRun, error) {
+	for i := range task.Spec.Steps {
+		if task.Spec.Steps[i].Name == "" {
+			task.Spec.Steps[i].Name = fmt.Sprintf("unnamed-%d", i)
+		}
+	}
+	steps, err := v1.MergeStepsWithStepTemplate(task.Spec.StepTemplate, task.Spec.Steps)
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge the steps of Task %s with its step template: %v", task.Name, err)
+	}
+	if err := supported(task, steps); err != nil {
+		return nil, err
+	}
+
+	uid := string(uuid.NewUUID())
+	r := &localRun{
+		task:       task,
+		name:       fmt.Sprintf("%s-run-%s", task.Name, uid[:5]),
+		runtime:    runtime,
+		workspaces: map[string]string{},
+		steps:      steps,
+	}
+	for name, path := range workspaces {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		r.workspaces[name] = abs
+	}
+	r.variables = taskVariables(task, r.name, namespace, uid, values, workspaces)
+
+	r.dir, err = os.MkdirTemp("", "opc-"+r.name)
+	if err != nil {
+		return nil, err
+	}
+	dirs := []string{"workspace", "results", "scripts", "creds"}
+	for _, s := range steps {
+		dirs = append(dirs, filepath.Join("steps", stepContainer(s.Name), "results"))
+	}
+	for _, v := range task.Spec.Volumes {
+		dirs = append(dirs, filepath.Join("volumes", v.Name))
+	}
+	for _, d := range dirs {
+		// the steps may not run as root
+		if err := os.MkdirAll(filepath.Join(r.dir, d), 0o777); err != nil { // #nosec G301
+			os.RemoveAll(r.dir)
+			return nil, err
+		}
+		if err := os.Chmod(filepath.Join(r.dir, d), 0o777); err != nil { // #nosec G302
+			os.RemoveAll(r.dir)
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// supported returns an error when the task uses features which need a
+// cluster.
+func supported(task *v1.Task, steps []v1.Step) error {
+	for _, v := range task.Spec.Volumes {
+		if v.EmptyDir == nil {
+			return fmt.Errorf("volume %s of Task %s is not supported, only emptyDir volumes are", v.Name, task.Name)
+		}
+	}
+	for _, s := range steps {
+		if s.Ref != nil {
+			return fmt.Errorf("step %s of Task %s references a StepAction, which is not supported", s.Name, task.Name)
+		}
+		if len(s.EnvFrom) > 0 {
+			return fmt.Errorf("envFrom of step %s of Task %s is not supported", s.Name, task.Name)
+		}
+		for _, e := range s.Env {
+			if e.ValueFrom != nil {
+				return fmt.Errorf("env %s of step %s of Task %s is read from the cluster, which is not supported", e.Name, s.Name, task.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// run runs the steps one after the other, streaming their logs, and prints
+// the statuses of the steps and the results of the TaskRun.
+func (r *localRun) run(ctx context.Context, s *cli.Stream, timeout time.Duration) error {
+	fmt.Fprintf(s.Out, "TaskRun %s started\n\n", r.name)
+
+	logC := make(chan log.Log)
+	errC := make(chan error)
+	var runErr error
+	go func() {
+		defer close(errC)
+		defer close(logC)
+		runErr = r.runSteps(ctx, timeout, logC)
+	}()
+	log.NewWriter(log.LogTypeTask, true).Write(s, logC, errC)
+
+	if err := r.printStatus(s.Out); err != nil {
+		return err
+	}
+	if runErr != nil {
+		return fmt.Errorf("TaskRun %s failed: %v", r.name, runErr)
+	}
+	fmt.Fprintf(s.Out, "\nTaskRun %s succeeded\n", r.name)
+	return nil
+}
+
+func (r *localRun) runSteps(ctx context.Context, timeout time.Duration, logC chan<- log.Log) error {
+	var failure error
+	stepResults := map[string]map[string]string{}
+	for i, step := range r.steps {
+		status := stepStatus{name: step.Name, status: statusSkipped}
+		if failure != nil {
+			r.statuses = append(r.statuses, status)
+			continue
+		}
+
+		step = replaceStep(step, r.variables.with(stepVariables(step, stepResults)))
+		if !step.When.AllowsExecution(map[string]bool{}) {
+			r.statuses = append(r.statuses, status)
+			continue
+		}
+
+		exitCode, err := r.runStep(ctx, i, step, logC)
+		logC <- log.Log{Task: r.task.Name, Step: step.Name, Log: "EOFLOG"}
+		switch {
+		case ctx.Err() != nil:
+			status.status = statusTimeout
+			failure = fmt.Errorf("TaskRun %q failed to finish within %q", r.name, timeout)
+		case errors.Is(err, context.DeadlineExceeded):
+			status.status = statusTimeout
+			if step.OnError != v1.Continue {
+				failure = fmt.Errorf("%q exited because the step exceeded the specified timeout limit", stepContainer(step.Name))
+			}
+		case err != nil:
+			return err
+		case exitCode != 0:
+			status.status, status.exitCode = statusError, exitCode
+			if step.OnError != v1.Continue {
+				failure = fmt.Errorf("%q exited with code %d", stepContainer(step.Name), exitCode)
+			}
+		default:
+			status.status = statusCompleted
+		}
+		r.statuses = append(r.statuses, status)
+
+		if err := os.WriteFile(filepath.Join(r.dir, "steps", stepContainer(step.Name), "exitCode"), []byte(strconv.Itoa(status.exitCode)), 0o644); err != nil { // #nosec G306
+			return err
+		}
+		results := map[string]string{}
+		for _, res := range step.Results {
+			if b, err := os.ReadFile(filepath.Join(r.dir, "steps", stepContainer(step.Name), "results", res.Name)); err == nil {
+				results[res.Name] = string(b)
+			}
+		}
+		stepResults[step.Name] = results
+	}
+	return failure
+}
+
+// mount returns the bind mount of the local directory at the path, it is
+// relabeled with podman so that the containers can use it with SELinux.
+func (r *localRun) mount(dir, path string, readOnly bool) string {
+	options := []string{}
+	if readOnly {
+		options = append(options, "ro")
+	}
+	if strings.Contains(filepath.Base(r.runtime), "podman") {
+		options = append(options, "z")
+	}
+	mount := dir + ":" + path
+	if len(options) > 0 {
+		mount += ":" + strings.Join(options, ",")
+	}
+	return mount
+}
+
+// runStep runs the step in a container and returns its exit code, the error
+// is context.DeadlineExceeded when it times out.
+func (r *localRun) runStep(ctx context.Context, index int, step v1.Step, logC chan<- log.Log) (int, error) {
+	container := fmt.Sprintf("%s-%s", r.name, stepContainer(step.Name))
+	args := []string{"run", "--rm", "--name", container,
+		"-v", r.mount(filepath.Join(r.dir, "workspace"), pipeline.WorkspaceDir, false),
+		"-v", r.mount(filepath.Join(r.dir, "results"), pipeline.DefaultResultPath, false),
+		"-v", r.mount(filepath.Join(r.dir, "steps"), pipeline.StepsDir, false),
+		"-v", r.mount(filepath.Join(r.dir, "scripts"), pipeline.ScriptDir, true),
+		"-v", r.mount(filepath.Join(r.dir, "creds"), pipeline.CredsDir, false),
+	}
+	for _, w := range r.task.Spec.Workspaces {
+		path, ok := r.workspaces[w.Name]
+		if !ok {
+			continue
+		}
+		args = append(args, "-v", r.mount(path, w.GetMountPath(), w.ReadOnly))
+	}
+	for _, m := range step.VolumeMounts {
+		args = append(args, "-v", r.mount(filepath.Join(r.dir, "volumes", m.Name, m.SubPath), m.MountPath, m.ReadOnly))
+	}
+	workingDir := step.WorkingDir
+	if workingDir == "" {
+		workingDir = pipeline.WorkspaceDir
+	}
+	args = append(args, "-w", workingDir)
+	for _, e := range step.Env {
+		args = append(args, "-e", e.Name+"="+e.Value)
+	}
+
+	command := step.Command
+	if step.Script != "" {
+		script := fmt.Sprintf("script-%d-%s", index, step.Name)
+		content := step.Script
+		if !strings.HasPrefix(content, "#!") {
+			content = "#!/bin/sh\nset -e\n" + content
+		}
+		if err := os.WriteFile(filepath.Join(r.dir, "scripts", script), []byte(content), 0o755); err != nil { // #nosec G306
+			return 0, err
+		}
+		command = []string{filepath.Join(pipeline.ScriptDir, script)}
+	}
+	if len(command) > 0 {
+		args = append(args, "--entrypoint", command[0], step.Image)
+		args = append(args, command[1:]...)
+	} else {
+		args = append(args, step.Image)
+	}
+	args = append(args, step.Args...)
+
+	if step.Timeout != nil && step.Timeout.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, step.Timeout.Duration)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, r.runtime, args...) // #nosec G204
+	cmd.Cancel = func() error {
+		// killing the client does not stop the container
+		_ = exec.Command(r.runtime, "kill", container).Run() // #nosec G204
+		return cmd.Process.Kill()
+	}
+	cmd.WaitDelay = 10 * time.Second
+	pr, pw := io.Pipe()
+	cmd.Stdout, cmd.Stderr = pw, pw
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		scanner := bufio.NewScanner(pr)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			logC <- log.Log{Task: r.task.Name, Step: step.Name, Log: scanner.Text()}
+		}
+		_, _ = io.Copy(io.Discard, pr)
+	}()
+	err := cmd.Run()
+	pw.Close()
+	<-done
+
+	if ctx.Err() != nil {
+		return 0, ctx.Err()
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to run step %s with %s: %v", step.Name, r.runtime, err)
+	}
+	return 0, nil
+}
+
+func (r *localRun) printStatus(out io.Writer) error {
+	w := tabwriter.NewWriter(out, 0, 5, 3, ' ', tabwriter.TabIndent)
+	fmt.Fprintf(w, "Steps\n")
+	fmt.Fprintf(w, " NAME\tSTATUS\tEXIT CODE\n")
+	for _, s := range r.statuses {
+		exitCode := "---"
+		if s.status == statusCompleted || s.status == statusError {
+			exitCode = strconv.Itoa(s.exitCode)
+		}
+		fmt.Fprintf(w, " %s\t%s\t%s\n", s.name, s.status, exitCode)
+	}
+
+	if len(r.task.Spec.Results) > 0 {
+		fmt.Fprintf(w, "\nResults\n")
+		fmt.Fprintf(w, " NAME\tVALUE\n")
+		for _, res := range r.task.Spec.Results {
+			value := "<none>"
+			if b, err := os.ReadFile(filepath.Join(r.dir, "results", res.Name)); err == nil {
+				value = string(b)
+			}
+			fmt.Fprintf(w, " %s\t%s\n", res.Name, value)
+		}
+	}
+	return w.Flush()
+}
@@ -0,0 +1,157 @@
+package task
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"github.com/tektoncd/pipeline/pkg/substitution"
+)
+
+// The patterns of the references to the params, the same as the Tekton
+// controller.
+var paramPatterns = []string{"params.%s", "params[%q]", "params['%s']"}
+
+// variables are the replacements of the variables of the steps. They are
+// applied in stages like the Tekton controller, the params first, so that
+// the values of the params are replaced too.
+type variables struct {
+	stages []map[string]string
+	arrays map[string][]string
+}
+
+func (v *variables) apply(in string) string {
+	for _, stage := range v.stages {
+		in = substitution.ApplyReplacements(in, stage)
+	}
+	return in
+}
+
+// applyArray replaces the variables of the elements of the array, the
+// elements which are references to array params are replaced by their
+// values.
+func (v *variables) applyArray(in []string) []string {
+	if in == nil {
+		return nil
+	}
+	out := []string{}
+	for _, s := range in {
+		for _, e := range substitution.ApplyArrayReplacements(s, map[string]string{}, v.arrays) {
+			out = append(out, v.apply(e))
+		}
+	}
+	return out
+}
+
+// with returns the variables with an additional stage.
+func (v *variables) with(stage map[string]string) *variables {
+	stages := append(append([]map[string]string{}, v.stages...), stage)
+	return &variables{stages: stages, arrays: v.arrays}
+}
+
+// taskVariables returns the variables of the TaskRun of the task in the
+// namespace, with the values of the params and the local directories of the
+// workspaces.
+func taskVariables(task *v1.Task, name, namespace, uid string, values map[string]v1.ParamValue, workspaces map[string]string) *variables {
+	params := map[string]string{}
+	arrays := map[string][]string{}
+	for n, value := range values {
+		for _, pattern := range paramPatterns {
+			key := fmt.Sprintf(pattern, n)
+			switch value.Type {
+			case v1.ParamTypeArray:
+				arrays[key] = value.ArrayVal
+				for i, e := range value.ArrayVal {
+					params[fmt.Sprintf("%s[%d]", key, i)] = e
+				}
+			case v1.ParamTypeObject:
+				for k, e := range value.ObjectVal {
+					params[fmt.Sprintf("%s.%s", key, k)] = e
+				}
+			default:
+				params[key] = value.StringVal
+			}
+		}
+	}
+
+	context := map[string]string{
+		"context.taskRun.name":      name,
+		"context.taskRun.namespace": namespace,
+		"context.taskRun.uid":       uid,
+		"context.task.name":         task.Name,
+		"context.task.retry-count":  "0",
+	}
+
+	ws := map[string]string{}
+	for _, w := range task.Spec.Workspaces {
+		_, bound := workspaces[w.Name]
+		path := w.GetMountPath()
+		if !bound {
+			// the path of the optional workspaces which are not bound is
+			// empty
+			path = ""
+		}
+		prefix := "workspaces." + w.Name
+		ws[prefix+".path"] = path
+		ws[prefix+".bound"] = strconv.FormatBool(bound)
+		ws[prefix+".claim"] = ""
+		ws[prefix+".volume"] = w.Name
+	}
+
+	results := map[string]string{"credentials.path": pipeline.CredsDir}
+	for _, r := range task.Spec.Results {
+		for _, pattern := range []string{"results.%s.path", "results[%q].path", "results['%s'].path"} {
+			results[fmt.Sprintf(pattern, r.Name)] = filepath.Join(pipeline.DefaultResultPath, r.Name)
+		}
+	}
+	for _, s := range task.Spec.Steps {
+		results[fmt.Sprintf("steps.%s.exitCode.path", stepContainer(s.Name))] = filepath.Join(pipeline.StepsDir, stepContainer(s.Name), "exitCode")
+	}
+
+	return &variables{stages: []map[string]string{params, context, ws, results}, arrays: arrays}
+}
+
+// stepVariables returns the variables specific to the step: the paths of
+// its results and the values of the results of the previous steps.
+func stepVariables(step v1.Step, previous map[string]map[string]string) map[string]string {
+	vars := map[string]string{}
+	for _, r := range step.Results {
+		vars[fmt.Sprintf("step.results.%s.path", r.Name)] = filepath.Join(pipeline.StepsDir, stepContainer(step.Name), "results", r.Name)
+	}
+	for s, results := range previous {
+		for r, value := range results {
+			vars[fmt.Sprintf("steps.%s.results.%s", s, r)] = value
+		}
+	}
+	return vars
+}
+
+// replaceStep returns the step with its variables replaced.
+func replaceStep(step v1.Step, v *variables) v1.Step {
+	s := *step.DeepCopy()
+	s.Image = v.apply(s.Image)
+	s.Command = v.applyArray(s.Command)
+	s.Args = v.applyArray(s.Args)
+	s.Script = v.apply(s.Script)
+	s.WorkingDir = v.apply(s.WorkingDir)
+	s.OnError = v1.OnErrorType(v.apply(string(s.OnError)))
+	for i := range s.Env {
+		s.Env[i].Value = v.apply(s.Env[i].Value)
+	}
+	for i := range s.VolumeMounts {
+		s.VolumeMounts[i].MountPath = v.apply(s.VolumeMounts[i].MountPath)
+		s.VolumeMounts[i].SubPath = v.apply(s.VolumeMounts[i].SubPath)
+	}
+	for _, stage := range v.stages {
+		s.When = s.When.ReplaceVariables(stage, v.arrays)
+	}
+	return s
+}
+
+// stepContainer returns the name of the container of the step, the same as
+// the Tekton controller.
+func stepContainer(name string) string {
+	return "step-" + name
+}
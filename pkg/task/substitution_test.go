@@ -0,0 +1,57 @@
+package task
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestReplaceStep(t *testing.T) {
+	task := &v1.Task{Spec: v1.TaskSpec{
+		Params: v1.ParamSpecs{{Name: "image"}, {Name: "flags", Type: v1.ParamTypeArray}},
+		Workspaces: []v1.WorkspaceDeclaration{
+			{Name: "source"},
+			{Name: "cache", Optional: true},
+		},
+		Results: []v1.TaskResult{{Name: "digest"}},
+	}}
+	task.Name = "build"
+	values := map[string]v1.ParamValue{
+		"image": *v1.NewStructuredValues("quay.io/myorg/$(context.task.name)"),
+		"flags": *v1.NewStructuredValues("-v", "--fast"),
+	}
+	vars := taskVariables(task, "build-run-abcde", "foo", "uid", values, map[string]string{"source": "/tmp/source"})
+
+	step := v1.Step{
+		Name:       "build",
+		Image:      "$(params.image)",
+		Command:    []string{"build"},
+		Args:       []string{"$(params.flags[*])", "$(params['image'])", "$(steps.clone.results.commit)"},
+		WorkingDir: "$(workspaces.source.path)",
+		Env: []corev1.EnvVar{
+			{Name: "NAMESPACE", Value: "$(context.taskRun.namespace)"},
+			{Name: "RUN", Value: "$(context.taskRun.name)"},
+			{Name: "CACHE", Value: "$(workspaces.cache.bound):$(workspaces.cache.path)"},
+			{Name: "DIGEST", Value: "$(results.digest.path)"},
+		},
+	}
+	got := replaceStep(step, vars.with(stepVariables(step, map[string]map[string]string{"clone": {"commit": "abc"}})))
+	want := v1.Step{
+		Name:       "build",
+		Image:      "quay.io/myorg/build",
+		Command:    []string{"build"},
+		Args:       []string{"-v", "--fast", "quay.io/myorg/build", "abc"},
+		WorkingDir: "/workspace/source",
+		Env: []corev1.EnvVar{
+			{Name: "NAMESPACE", Value: "foo"},
+			{Name: "RUN", Value: "build-run-abcde"},
+			{Name: "CACHE", Value: "false:"},
+			{Name: "DIGEST", Value: "/tekton/results/digest"},
+		},
+	}
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("replaceStep() (-want +got):\n%s", d)
+	}
+}
@@ -0,0 +1,156 @@
+package trustedresources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tektoncd/cli/pkg/cli"
+	"github.com/tektoncd/pipeline/pkg/apis/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// pipelinesNamespaces are the namespaces searched for the feature flags of
+// Tekton Pipelines, the one of OpenShift Pipelines first.
+var pipelinesNamespaces = []string{"openshift-pipelines", "tekton-pipelines"}
+
+type clusterOptions struct {
+	UseClusterPolicies bool
+	Source             string
+	NoMatchPolicy      string
+}
+
+// AddClusterPolicies adds the --use-cluster-policies flag to the verify
+// command of the kind, Task or Pipeline, to verify the resource with the
+// VerificationPolicies of the namespace instead of a key.
+func AddClusterPolicies(verify *cobra.Command, p cli.Params, kind string) {
+	opts := &clusterOptions{}
+	verify.Long += fmt.Sprintf(`
+
+With --use-cluster-policies, the %[1]s is verified with the VerificationPolicies of the namespace the same way as
+the Tekton controller verifies the %[1]ss fetched by the resolvers: the policies with a resource pattern matching
+the source given with --source are evaluated, like the URL of the git repository of the %[1]s, and their keys are
+read from the policies, from KMS or from the Secrets of the cluster. A failing enforced policy fails the
+verification and a failing policy in warn mode gives a warning. Without matching policy, the verification is
+skipped, fails or gives a warning according to the trusted-resources-verification-no-match-policy feature flag,
+or to --no-match-policy when the feature flags cannot be read.`, kind)
+	verify.Example += fmt.Sprintf(`
+
+Verify a %[1]s fetched from a git repository with the VerificationPolicies of the namespace foo:
+	opc %[2]s verify signed.yaml --use-cluster-policies --source https://github.com/myorg/tekton.git -n foo`, kind, strings.ToLower(kind))
+	verify.Flags().BoolVar(&opts.UseClusterPolicies, "use-cluster-policies", false, "verify with the VerificationPolicies of the namespace instead of a key")
+	verify.Flags().StringVar(&opts.Source, "source", "", "source of the "+kind+" matched against the resource patterns of the policies, with --use-cluster-policies")
+	verify.Flags().StringVar(&opts.NoMatchPolicy, "no-match-policy", "", "outcome of the "+kind+" without matching policy instead of the feature flag of the cluster: fail, warn or ignore")
+
+	runE := verify.RunE
+	verify.RunE = func(cmd *cobra.Command, args []string) error {
+		if !opts.UseClusterPolicies {
+			return runE(cmd, args)
+		}
+		keyFile, _ := cmd.Flags().GetString("key-file")
+		kmsKey, _ := cmd.Flags().GetString("kms-key")
+		if keyFile != "" || kmsKey != "" {
+			return errors.New("--use-cluster-policies cannot be used with --key-file or --kms-key")
+		}
+		switch opts.NoMatchPolicy {
+		case "", config.FailNoMatchPolicy, config.WarnNoMatchPolicy, config.IgnoreNoMatchPolicy:
+		default:
+			return fmt.Errorf("invalid value %q for --no-match-policy, it must be one of fail, warn and ignore", opts.NoMatchPolicy)
+		}
+		cmd.SilenceUsage = true
+
+		files, err := load(args[:1])
+		if err != nil {
+			return err
+		}
+		resources := []*resource{}
+		for _, f := range files {
+			for _, r := range f.resources {
+				if r.kind == kind {
+					resources = append(resources, r)
+				}
+			}
+		}
+		if len(resources) == 0 {
+			return fmt.Errorf("no %s found in %s", kind, args[0])
+		}
+
+		ctx := cmd.Context()
+		cs, err := p.Clients()
+		if err != nil {
+			return err
+		}
+		ns := p.Namespace()
+		policies, err := clusterPolicies(ctx, cs, ns)
+		if err != nil {
+			return err
+		}
+		noMatchPolicy := opts.NoMatchPolicy
+		if noMatchPolicy == "" {
+			if noMatchPolicy, err = clusterNoMatchPolicy(ctx, cs.Kube); err != nil {
+				return err
+			}
+		}
+
+		out := cmd.OutOrStdout()
+		fmt.Fprintf(out, "Verifying with the %d VerificationPolicies of namespace %s, the no match policy is %s\n\n", len(policies), ns, noMatchPolicy)
+		v := &policyVerifier{
+			policies:      policies,
+			noMatchPolicy: noMatchPolicy,
+			kubeClient:    func() (kubernetes.Interface, error) { return cs.Kube, nil },
+		}
+		results := []*result{}
+		for _, r := range resources {
+			results = append(results, v.verify(ctx, r, opts.Source))
+		}
+		if failed := printResults(out, results); failed > 0 {
+			return fmt.Errorf("%d resources failed verification", failed)
+		}
+		return nil
+	}
+}
+
+// clusterPolicies returns the VerificationPolicies of the namespace, by name.
+func clusterPolicies(ctx context.Context, cs *cli.Clients, ns string) ([]*v1alpha1.VerificationPolicy, error) {
+	list, err := cs.Tekton.TektonV1alpha1().VerificationPolicies(ns).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list VerificationPolicies from namespace %s: %v", ns, err)
+	}
+	policies := []*v1alpha1.VerificationPolicy{}
+	for i := range list.Items {
+		policies = append(policies, &list.Items[i])
+	}
+	sort.Slice(policies, func(i, j int) bool { return policies[i].Name < policies[j].Name })
+	return policies, nil
+}
+
+// clusterNoMatchPolicy returns the trusted-resources-verification-no-match-policy
+// feature flag of Tekton Pipelines, its default when the feature flags are
+// not found. It fails when the feature flags cannot be read, as the default
+// may not be the flag of the cluster.
+func clusterNoMatchPolicy(ctx context.Context, kube kubernetes.Interface) (string, error) {
+	for _, ns := range pipelinesNamespaces {
+		cm, err := kube.CoreV1().ConfigMaps(ns).Get(ctx, config.GetFeatureFlagsConfigName(), metav1.GetOptions{})
+		if k8serrors.IsNotFound(err) {
+			continue
+		}
+		if k8serrors.IsForbidden(err) {
+			return "", fmt.Errorf("not allowed to read the feature flags of Tekton Pipelines in namespace %s, give the no match policy with --no-match-policy: %v", ns, err)
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to get the feature flags of Tekton Pipelines: %v", err)
+		}
+		flags, err := config.NewFeatureFlagsFromConfigMap(cm)
+		if err != nil {
+			return "", fmt.Errorf("invalid feature flags of Tekton Pipelines: %v", err)
+		}
+		return flags.VerificationNoMatchPolicy, nil
+	}
+	return config.DefaultNoMatchPolicyConfig, nil
+}
@@ -0,0 +1,130 @@
+package trustedresources
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/tektoncd/cli/pkg/cli"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// newKubeClient returns a client of an API server answering the requests of
+// the feature flags of the namespaces with the responses, the other
+// namespaces have none.
+func newKubeClient(t *testing.T, responses map[string]func(w http.ResponseWriter)) kubernetes.Interface {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		ns := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/v1/namespaces/"), "/")[0]
+		if respond, ok := responses[ns]; ok {
+			respond(w)
+			return
+		}
+		status := k8serrors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, "feature-flags").ErrStatus
+		status.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "Status"}
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(&status)
+	}))
+	t.Cleanup(server.Close)
+	kube, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return kube
+}
+
+func featureFlags(noMatchPolicy string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		json.NewEncoder(w).Encode(&corev1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{Name: "feature-flags"},
+			Data:       map[string]string{"trusted-resources-verification-no-match-policy": noMatchPolicy},
+		})
+	}
+}
+
+func forbidden(w http.ResponseWriter) {
+	status := k8serrors.NewForbidden(schema.GroupResource{Resource: "configmaps"}, "feature-flags", nil).ErrStatus
+	status.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "Status"}
+	w.WriteHeader(http.StatusForbidden)
+	json.NewEncoder(w).Encode(&status)
+}
+
+func TestClusterNoMatchPolicy(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses map[string]func(w http.ResponseWriter)
+		want      string
+		wantErr   string
+	}{{
+		name:      "openshift pipelines",
+		responses: map[string]func(w http.ResponseWriter){"openshift-pipelines": featureFlags("fail")},
+		want:      "fail",
+	}, {
+		name:      "tekton pipelines",
+		responses: map[string]func(w http.ResponseWriter){"tekton-pipelines": featureFlags("warn")},
+		want:      "warn",
+	}, {
+		name: "default without feature flags",
+		want: "ignore",
+	}, {
+		name:      "forbidden",
+		responses: map[string]func(w http.ResponseWriter){"openshift-pipelines": forbidden, "tekton-pipelines": featureFlags("warn")},
+		wantErr:   `not allowed to read the feature flags of Tekton Pipelines in namespace openshift-pipelines, give the no match policy with --no-match-policy: configmaps "feature-flags" is forbidden`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := clusterNoMatchPolicy(context.Background(), newKubeClient(t, tt.responses))
+			if tt.wantErr != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+					t.Fatalf("clusterNoMatchPolicy() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("clusterNoMatchPolicy() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddClusterPoliciesFlags(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{{
+		name: "key file",
+		args: []string{"task.yaml", "--use-cluster-policies", "-K", "cosign.pub"},
+		want: "--use-cluster-policies cannot be used with --key-file or --kms-key",
+	}, {
+		name: "invalid no match policy",
+		args: []string{"task.yaml", "--use-cluster-policies", "--no-match-policy", "deny"},
+		want: `invalid value "deny" for --no-match-policy, it must be one of fail, warn and ignore`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verify := &cobra.Command{Use: "verify", RunE: func(*cobra.Command, []string) error { return nil }}
+			verify.Flags().StringP("key-file", "K", "", "")
+			verify.Flags().StringP("kms-key", "m", "", "")
+			AddClusterPolicies(verify, &cli.TektonParams{}, "Task")
+			_, err := run(verify, tt.args...)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("error = %v, want %s", err, tt.want)
+			}
+		})
+	}
+}
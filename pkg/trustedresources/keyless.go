@@ -0,0 +1,473 @@
+package trustedresources
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+)
+
+const (
+	defaultFulcioURL = "https://fulcio.sigstore.dev"
+	defaultRekorURL  = "https://rekor.sigstore.dev"
+)
+
+// The extensions of the Fulcio certificates holding the OIDC issuer, the
+// first one is deprecated.
+var (
+	issuerV1OID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}
+	issuerV2OID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 8}
+)
+
+// keylessSigner signs with an ephemeral key certified by a Fulcio compatible
+// certificate authority for the identity of an OIDC token, the signatures are
+// recorded in a Rekor compatible transparency log.
+type keylessSigner struct {
+	signer      signature.Signer
+	certificate []byte
+	chain       []byte
+	rekorURL    string
+}
+
+// logEntry is the entry of a signature in the transparency log.
+type logEntry struct {
+	UUID           string `json:"uuid"`
+	LogIndex       int64  `json:"logIndex"`
+	IntegratedTime int64  `json:"integratedTime"`
+}
+
+// The requests and responses of the Fulcio and Rekor APIs, only with the
+// fields used.
+type (
+	fulcioRequest struct {
+		Credentials struct {
+			OIDCIdentityToken string `json:"oidcIdentityToken"`
+		} `json:"credentials"`
+		PublicKeyRequest struct {
+			PublicKey struct {
+				Algorithm string `json:"algorithm"`
+				Content   string `json:"content"`
+			} `json:"publicKey"`
+			ProofOfPossession []byte `json:"proofOfPossession"`
+		} `json:"publicKeyRequest"`
+	}
+	certificateChain struct {
+		Certificates []string `json:"certificates"`
+	}
+	fulcioResponse struct {
+		SignedCertificateEmbeddedSct *struct {
+			Chain certificateChain `json:"chain"`
+		} `json:"signedCertificateEmbeddedSct"`
+		SignedCertificateDetachedSct *struct {
+			Chain certificateChain `json:"chain"`
+		} `json:"signedCertificateDetachedSct"`
+	}
+	trustBundle struct {
+		Chains []certificateChain `json:"chains"`
+	}
+	hashedRekord struct {
+		APIVersion string `json:"apiVersion"`
+		Kind       string `json:"kind"`
+		Spec       struct {
+			Data struct {
+				Hash struct {
+					Algorithm string `json:"algorithm"`
+					Value     string `json:"value"`
+				} `json:"hash"`
+			} `json:"data"`
+			Signature struct {
+				Content   []byte `json:"content"`
+				PublicKey struct {
+					Content []byte `json:"content"`
+				} `json:"publicKey"`
+			} `json:"signature"`
+		} `json:"spec"`
+	}
+	rekorEntry struct {
+		Body           string `json:"body"`
+		IntegratedTime int64  `json:"integratedTime"`
+		LogID          string `json:"logID"`
+		LogIndex       int64  `json:"logIndex"`
+		Verification   struct {
+			SignedEntryTimestamp []byte          `json:"signedEntryTimestamp"`
+			InclusionProof       *inclusionProof `json:"inclusionProof"`
+		} `json:"verification"`
+	}
+	inclusionProof struct {
+		Checkpoint string   `json:"checkpoint"`
+		Hashes     []string `json:"hashes"`
+		LogIndex   int64    `json:"logIndex"`
+		RootHash   string   `json:"rootHash"`
+		TreeSize   int64    `json:"treeSize"`
+	}
+)
+
+// newKeylessSigner returns a signer with a certificate for the identity of
+// the token, read from SIGSTORE_ID_TOKEN when empty like cosign.
+func newKeylessSigner(ctx context.Context, fulcioURL, rekorURL, token string) (*keylessSigner, error) {
+	if token == "" {
+		token = os.Getenv("SIGSTORE_ID_TOKEN")
+	}
+	if token == "" {
+		return nil, errors.New("an OIDC identity token must be given with --identity-token or SIGSTORE_ID_TOKEN for keyless signing")
+	}
+	subject, err := tokenSubject(token)
+	if err != nil {
+		return nil, err
+	}
+
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := signature.LoadECDSASignerVerifier(priv, crypto.SHA256)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := cryptoutils.MarshalPublicKeyToPEM(priv.Public())
+	if err != nil {
+		return nil, err
+	}
+	// the proof of possession of the key is the signature of the subject of
+	// the token
+	proof, err := signer.SignMessage(strings.NewReader(subject))
+	if err != nil {
+		return nil, err
+	}
+
+	req := &fulcioRequest{}
+	req.Credentials.OIDCIdentityToken = token
+	req.PublicKeyRequest.PublicKey.Algorithm = "ECDSA"
+	req.PublicKeyRequest.PublicKey.Content = string(pub)
+	req.PublicKeyRequest.ProofOfPossession = proof
+	resp := &fulcioResponse{}
+	if err := doJSON(ctx, http.MethodPost, strings.TrimSuffix(fulcioURL, "/")+"/api/v2/signingCert", token, req, resp); err != nil {
+		return nil, fmt.Errorf("failed to get a signing certificate from %s: %v", fulcioURL, err)
+	}
+	var chain certificateChain
+	switch {
+	case resp.SignedCertificateEmbeddedSct != nil:
+		chain = resp.SignedCertificateEmbeddedSct.Chain
+	case resp.SignedCertificateDetachedSct != nil:
+		chain = resp.SignedCertificateDetachedSct.Chain
+	}
+	if len(chain.Certificates) == 0 {
+		return nil, fmt.Errorf("no signing certificate returned by %s", fulcioURL)
+	}
+	return &keylessSigner{
+		signer:      signer,
+		certificate: []byte(chain.Certificates[0]),
+		chain:       []byte(strings.Join(chain.Certificates, "")),
+		rekorURL:    rekorURL,
+	}, nil
+}
+
+// record adds the signature of the message to the transparency log.
+func (s *keylessSigner) record(ctx context.Context, message, sig []byte) (*logEntry, error) {
+	h := sha256.Sum256(message)
+	rekord := &hashedRekord{APIVersion: "0.0.1", Kind: "hashedrekord"}
+	rekord.Spec.Data.Hash.Algorithm = "sha256"
+	rekord.Spec.Data.Hash.Value = hex.EncodeToString(h[:])
+	rekord.Spec.Signature.Content = sig
+	rekord.Spec.Signature.PublicKey.Content = s.certificate
+
+	entries := map[string]rekorEntry{}
+	if err := doJSON(ctx, http.MethodPost, strings.TrimSuffix(s.rekorURL, "/")+"/api/v1/log/entries", "", rekord, &entries); err != nil {
+		return nil, fmt.Errorf("failed to add the signature to the transparency log %s: %v", s.rekorURL, err)
+	}
+	for uuid, e := range entries {
+		return &logEntry{UUID: uuid, LogIndex: e.LogIndex, IntegratedTime: e.IntegratedTime}, nil
+	}
+	return nil, fmt.Errorf("no entry returned by the transparency log %s", s.rekorURL)
+}
+
+// keylessVerifier verifies the keyless signatures: the certificate must be
+// issued by the certificate authority for the identity and the issuer, and
+// the signature must be in the transparency log when the certificate was
+// valid.
+type keylessVerifier struct {
+	fulcioURL      string
+	rekorURL       string
+	caRoots        string
+	rekorPublicKey string
+	identity       string
+	issuer         string
+
+	roots         *x509.CertPool
+	intermediates *x509.CertPool
+	rekorKey      *rekorKey
+}
+
+// verify checks the signature of the resource with its certificate.
+func (v *keylessVerifier) verify(ctx context.Context, r *resource) error {
+	sig, err := signatureOf(r.object)
+	if err != nil {
+		return err
+	}
+	message, err := digest(r.object)
+	if err != nil {
+		return err
+	}
+	annotations := r.object.GetAnnotations()
+	encoded, ok := annotations[certificateAnnotation]
+	if !ok {
+		return errors.New("resource is not signed keyless, it has no certificate")
+	}
+	chain, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("invalid certificate: %v", err)
+	}
+	certs, err := cryptoutils.UnmarshalCertificatesFromPEM(chain)
+	if err != nil || len(certs) == 0 {
+		return fmt.Errorf("invalid certificate: %v", err)
+	}
+	cert := certs[0]
+	var entry logEntry
+	if err := json.Unmarshal([]byte(annotations[logEntryAnnotation]), &entry); err != nil || entry.UUID == "" {
+		return errors.New("resource has no transparency log entry")
+	}
+
+	integratedTime, err := v.checkLogEntry(ctx, entry, message, sig, cert)
+	if err != nil {
+		return err
+	}
+	if err := v.checkCertificate(ctx, cert, certs[1:], integratedTime); err != nil {
+		return err
+	}
+	verifier, err := signature.LoadVerifier(cert.PublicKey, crypto.SHA256)
+	if err != nil {
+		return err
+	}
+	return verifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader(message))
+}
+
+// checkLogEntry checks that the entry of the transparency log is the
+// signature of the message with the certificate and that it is signed and
+// included in the log, it returns the time it was added to the log.
+func (v *keylessVerifier) checkLogEntry(ctx context.Context, entry logEntry, message, sig []byte, cert *x509.Certificate) (time.Time, error) {
+	entries := map[string]rekorEntry{}
+	if err := doJSON(ctx, http.MethodGet, strings.TrimSuffix(v.rekorURL, "/")+"/api/v1/log/entries/"+entry.UUID, "", nil, &entries); err != nil {
+		return time.Time{}, fmt.Errorf("failed to get the transparency log entry %s: %v", entry.UUID, err)
+	}
+	var e *rekorEntry
+	for _, got := range entries {
+		e = &got
+	}
+	if e == nil {
+		return time.Time{}, fmt.Errorf("transparency log entry %s not found", entry.UUID)
+	}
+	if e.LogIndex != entry.LogIndex {
+		return time.Time{}, fmt.Errorf("the transparency log entry %s has the index %d, not %d", entry.UUID, e.LogIndex, entry.LogIndex)
+	}
+	if err := v.loadRekorKey(ctx); err != nil {
+		return time.Time{}, err
+	}
+	if err := v.rekorKey.verifyEntry(e); err != nil {
+		return time.Time{}, fmt.Errorf("invalid transparency log entry %s: %v", entry.UUID, err)
+	}
+	body, err := base64.StdEncoding.DecodeString(e.Body)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid transparency log entry %s: %v", entry.UUID, err)
+	}
+	var rekord hashedRekord
+	if err := json.Unmarshal(body, &rekord); err != nil || rekord.Kind != "hashedrekord" {
+		return time.Time{}, fmt.Errorf("invalid transparency log entry %s", entry.UUID)
+	}
+	h := sha256.Sum256(message)
+	if rekord.Spec.Data.Hash.Value != hex.EncodeToString(h[:]) || !bytes.Equal(rekord.Spec.Signature.Content, sig) {
+		return time.Time{}, fmt.Errorf("the transparency log entry %s is not the signature of the resource", entry.UUID)
+	}
+	logged, err := cryptoutils.UnmarshalCertificatesFromPEM(rekord.Spec.Signature.PublicKey.Content)
+	if err != nil || len(logged) == 0 || !logged[0].Equal(cert) {
+		return time.Time{}, fmt.Errorf("the transparency log entry %s is not signed with the certificate of the resource", entry.UUID)
+	}
+	return time.Unix(e.IntegratedTime, 0), nil
+}
+
+// checkCertificate checks that the certificate was valid at the time and
+// was issued by the certificate authority for the identity and the issuer.
+func (v *keylessVerifier) checkCertificate(ctx context.Context, cert *x509.Certificate, chain []*x509.Certificate, at time.Time) error {
+	if err := v.loadRoots(ctx); err != nil {
+		return err
+	}
+	intermediates := v.intermediates.Clone()
+	for _, c := range chain {
+		intermediates.AddCert(c)
+	}
+	_, err := cert.Verify(x509.VerifyOptions{
+		Roots:         v.roots,
+		Intermediates: intermediates,
+		CurrentTime:   at,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	})
+	if err != nil {
+		return fmt.Errorf("invalid certificate: %v", err)
+	}
+
+	identities := append([]string{}, cert.EmailAddresses...)
+	for _, u := range cert.URIs {
+		identities = append(identities, u.String())
+	}
+	if !contains(identities, v.identity) {
+		return fmt.Errorf("the certificate was issued for %s, not %s", strings.Join(identities, ", "), v.identity)
+	}
+	if issuer := certificateIssuer(cert); issuer != v.issuer {
+		return fmt.Errorf("the certificate was issued by %q, not %q", issuer, v.issuer)
+	}
+	return nil
+}
+
+// loadRoots loads the certificates of the certificate authority, from the
+// file or from the trust bundle of Fulcio.
+func (v *keylessVerifier) loadRoots(ctx context.Context) error {
+	if v.roots != nil {
+		return nil
+	}
+	var chains []certificateChain
+	if v.caRoots != "" {
+		b, err := os.ReadFile(v.caRoots)
+		if err != nil {
+			return err
+		}
+		chains = []certificateChain{{Certificates: []string{string(b)}}}
+	} else {
+		bundle := &trustBundle{}
+		if err := doJSON(ctx, http.MethodGet, strings.TrimSuffix(v.fulcioURL, "/")+"/api/v2/trustBundle", "", nil, bundle); err != nil {
+			return fmt.Errorf("failed to get the trust bundle of %s: %v", v.fulcioURL, err)
+		}
+		chains = bundle.Chains
+	}
+
+	v.roots, v.intermediates = x509.NewCertPool(), x509.NewCertPool()
+	for _, chain := range chains {
+		certs, err := cryptoutils.UnmarshalCertificatesFromPEM([]byte(strings.Join(chain.Certificates, "")))
+		if err != nil {
+			return fmt.Errorf("invalid certificate authority: %v", err)
+		}
+		for _, c := range certs {
+			if bytes.Equal(c.RawIssuer, c.RawSubject) {
+				v.roots.AddCert(c)
+			} else {
+				v.intermediates.AddCert(c)
+			}
+		}
+	}
+	return nil
+}
+
+// certificateIssuer returns the OIDC issuer of a Fulcio certificate.
+func certificateIssuer(cert *x509.Certificate) string {
+	for _, ext := range cert.Extensions {
+		switch {
+		case ext.Id.Equal(issuerV2OID):
+			var issuer string
+			if _, err := asn1.Unmarshal(ext.Value, &issuer); err == nil {
+				return issuer
+			}
+		case ext.Id.Equal(issuerV1OID):
+			return string(ext.Value)
+		}
+	}
+	return ""
+}
+
+// tokenSubject returns the subject of the token proven to Fulcio, its email
+// when it has one.
+func tokenSubject(token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", errors.New("invalid OIDC identity token")
+	}
+	b, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("invalid OIDC identity token: %v", err)
+	}
+	var claims struct {
+		Subject string `json:"sub"`
+		Email   string `json:"email"`
+	}
+	if err := json.Unmarshal(b, &claims); err != nil {
+		return "", fmt.Errorf("invalid OIDC identity token: %v", err)
+	}
+	if claims.Email != "" {
+		return claims.Email, nil
+	}
+	if claims.Subject == "" {
+		return "", errors.New("the OIDC identity token has no subject")
+	}
+	return claims.Subject, nil
+}
+
+// doJSON sends the request with the body in JSON and decodes the JSON
+// response.
+func doJSON(ctx context.Context, method, url, token string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	b, err := do(req)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, out)
+}
+
+// do sends the request and returns the body of the response.
+func do(req *http.Request) ([]byte, error) {
+	// #nosec G704 -- the URL is the endpoint given by the user
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(b)))
+	}
+	return b, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
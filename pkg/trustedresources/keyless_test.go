@@ -0,0 +1,388 @@
+package trustedresources
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/tektoncd/cli/pkg/cli"
+)
+
+const (
+	testIdentity = "user@example.com"
+	testIssuer   = "https://issuer.example.com"
+)
+
+// fakeSigstore is a Fulcio certificate authority issuing the certificates for
+// the email of the tokens and a Rekor transparency log, each one with its
+// own server.
+type fakeSigstore struct {
+	fulcio *httptest.Server
+	rekor  *httptest.Server
+
+	ca     *x509.Certificate
+	caKey  *ecdsa.PrivateKey
+	caPEM  []byte
+	logKey signature.SignerVerifier
+	logPEM []byte
+	logID  string
+
+	mu     sync.Mutex
+	bodies [][]byte
+	times  []int64
+	// tamper changes the entries returned by the log
+	tamper func(*rekorEntry)
+}
+
+func newFakeSigstore(t *testing.T) *fakeSigstore {
+	t.Helper()
+	s := &fakeSigstore{}
+	var err error
+	if s.caKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "fake fulcio"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, s.caKey.Public(), s.caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.ca, err = x509.ParseCertificate(der); err != nil {
+		t.Fatal(err)
+	}
+	if s.caPEM, err = cryptoutils.MarshalCertificateToPEM(s.ca); err != nil {
+		t.Fatal(err)
+	}
+
+	logKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.logKey, err = signature.LoadECDSASignerVerifier(logKey, crypto.SHA256); err != nil {
+		t.Fatal(err)
+	}
+	if s.logPEM, err = cryptoutils.MarshalPublicKeyToPEM(logKey.Public()); err != nil {
+		t.Fatal(err)
+	}
+	pub, err := x509.MarshalPKIXPublicKey(logKey.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := sha256.Sum256(pub)
+	s.logID = hex.EncodeToString(id[:])
+
+	fulcio := http.NewServeMux()
+	fulcio.HandleFunc("/api/v2/signingCert", s.signingCert)
+	fulcio.HandleFunc("/api/v2/trustBundle", func(w http.ResponseWriter, _ *http.Request) {
+		json.NewEncoder(w).Encode(&trustBundle{Chains: []certificateChain{{Certificates: []string{string(s.caPEM)}}}})
+	})
+	s.fulcio = httptest.NewServer(fulcio)
+	t.Cleanup(s.fulcio.Close)
+
+	rekor := http.NewServeMux()
+	rekor.HandleFunc("/api/v1/log/entries", s.addEntry)
+	rekor.HandleFunc("/api/v1/log/entries/", s.getEntry)
+	rekor.HandleFunc("/api/v1/log/publicKey", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write(s.logPEM)
+	})
+	s.rekor = httptest.NewServer(rekor)
+	t.Cleanup(s.rekor.Close)
+	return s
+}
+
+func (s *fakeSigstore) signingCert(w http.ResponseWriter, r *http.Request) {
+	req := &fulcioRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	email, err := tokenSubject(req.Credentials.OIDCIdentityToken)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	pub, err := cryptoutils.UnmarshalPEMToPublicKey([]byte(req.PublicKeyRequest.PublicKey.Content))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	issuer, _ := asn1.Marshal(testIssuer)
+	template := &x509.Certificate{
+		SerialNumber:    big.NewInt(time.Now().UnixNano()),
+		NotBefore:       time.Now().Add(-time.Minute),
+		NotAfter:        time.Now().Add(10 * time.Minute),
+		KeyUsage:        x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		EmailAddresses:  []string{email},
+		ExtraExtensions: []pkix.Extension{{Id: issuerV2OID, Value: issuer}},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, s.ca, pub, s.caKey)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	cert, _ := x509.ParseCertificate(der)
+	leaf, _ := cryptoutils.MarshalCertificateToPEM(cert)
+	resp := map[string]interface{}{
+		"signedCertificateEmbeddedSct": map[string]interface{}{
+			"chain": certificateChain{Certificates: []string{string(leaf), string(s.caPEM)}},
+		},
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (s *fakeSigstore) addEntry(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bodies = append(s.bodies, body)
+	s.times = append(s.times, time.Now().Unix())
+	index := len(s.bodies) - 1
+	json.NewEncoder(w).Encode(map[string]rekorEntry{
+		hex.EncodeToString(hashLeaf(body)): {LogIndex: int64(index), IntegratedTime: s.times[index]},
+	})
+}
+
+// getEntry returns the entry with its signed entry timestamp and its
+// inclusion proof in the tree of all the entries.
+func (s *fakeSigstore) getEntry(w http.ResponseWriter, r *http.Request) {
+	uuid := strings.TrimPrefix(r.URL.Path, "/api/v1/log/entries/")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, body := range s.bodies {
+		if hex.EncodeToString(hashLeaf(body)) != uuid {
+			continue
+		}
+		e := rekorEntry{
+			Body:           base64.StdEncoding.EncodeToString(body),
+			IntegratedTime: s.times[i],
+			LogID:          s.logID,
+			LogIndex:       int64(i),
+		}
+		payload, _ := json.Marshal(&setPayload{Body: e.Body, IntegratedTime: e.IntegratedTime, LogID: e.LogID, LogIndex: e.LogIndex})
+		e.Verification.SignedEntryTimestamp, _ = s.logKey.SignMessage(bytes.NewReader(payload))
+
+		root := merkleRoot(s.bodies)
+		proof := &inclusionProof{LogIndex: int64(i), TreeSize: int64(len(s.bodies)), RootHash: hex.EncodeToString(root)}
+		for _, h := range merklePath(i, s.bodies) {
+			proof.Hashes = append(proof.Hashes, hex.EncodeToString(h))
+		}
+		text := fmt.Sprintf("fake rekor - 1\n%d\n%s\n", len(s.bodies), base64.StdEncoding.EncodeToString(root))
+		sig, _ := s.logKey.SignMessage(strings.NewReader(text))
+		proof.Checkpoint = text + "\n— fake-rekor " + base64.StdEncoding.EncodeToString(append([]byte{0, 0, 0, 0}, sig...)) + "\n"
+		e.Verification.InclusionProof = proof
+
+		if s.tamper != nil {
+			s.tamper(&e)
+		}
+		json.NewEncoder(w).Encode(map[string]rekorEntry{uuid: e})
+		return
+	}
+	http.NotFound(w, r)
+}
+
+// merkleRoot returns the RFC 6962 root hash of the leaves.
+func merkleRoot(leaves [][]byte) []byte {
+	if len(leaves) == 1 {
+		return hashLeaf(leaves[0])
+	}
+	k := split(len(leaves))
+	return hashChildren(merkleRoot(leaves[:k]), merkleRoot(leaves[k:]))
+}
+
+// merklePath returns the RFC 6962 inclusion proof of the leaf m.
+func merklePath(m int, leaves [][]byte) [][]byte {
+	if len(leaves) == 1 {
+		return nil
+	}
+	k := split(len(leaves))
+	if m < k {
+		return append(merklePath(m, leaves[:k]), merkleRoot(leaves[k:]))
+	}
+	return append(merklePath(m-k, leaves[k:]), merkleRoot(leaves[:k]))
+}
+
+// split returns the largest power of two smaller than n.
+func split(n int) int {
+	k := 1
+	for k*2 < n {
+		k *= 2
+	}
+	return k
+}
+
+// testToken returns an unsigned OIDC token for the email.
+func testToken(email string) string {
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString([]byte(`{"alg":"none"}`)) + "." + enc.EncodeToString([]byte(`{"email":"`+email+`"}`)) + ".sig"
+}
+
+// writeResources writes a Task, a Pipeline and a StepAction in a directory of
+// the test.
+func writeResources(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	yaml := `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: hello
+spec:
+  steps:
+  - name: hello
+    image: alpine
+    script: echo hello
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: unsigned
+---
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: hello
+spec:
+  tasks:
+  - name: hello
+    taskRef:
+      name: hello
+---
+apiVersion: tekton.dev/v1beta1
+kind: StepAction
+metadata:
+  name: hello
+spec:
+  image: alpine
+  command: ["echo", "hello"]
+`
+	if err := os.WriteFile(filepath.Join(dir, "resources.yaml"), []byte(yaml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestKeylessSignAndVerify(t *testing.T) {
+	s := newFakeSigstore(t)
+	dir := writeResources(t)
+	out, err := run(SignCommand(nil), "-f", dir, "--keyless", "--identity-token", testToken(testIdentity),
+		"--fulcio-url", s.fulcio.URL, "--rekor-url", s.rekor.URL)
+	if err != nil {
+		t.Fatalf("sign: %v: %s", err, out)
+	}
+	if len(s.bodies) != 3 {
+		t.Fatalf("got %d transparency log entries, want 3", len(s.bodies))
+	}
+
+	caRoots := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caRoots, s.caPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPEM, err := cryptoutils.MarshalPublicKeyToPEM(otherKey.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherRekorKey := filepath.Join(t.TempDir(), "rekor.pub")
+	if err := os.WriteFile(otherRekorKey, otherPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		args   []string
+		tamper func(*rekorEntry)
+		want   string
+	}{{
+		name: "trust bundle and key of the servers",
+	}, {
+		name: "certificate authority file",
+		args: []string{"--ca-roots", caRoots},
+	}, {
+		name: "other identity",
+		args: []string{"--certificate-identity", "other@example.com"},
+		want: "the certificate was issued for user@example.com, not other@example.com",
+	}, {
+		name: "other log key",
+		args: []string{"--rekor-public-key", otherRekorKey},
+		want: "is in the log " + s.logID,
+	}, {
+		name:   "invalid signed entry timestamp",
+		tamper: func(e *rekorEntry) { e.IntegratedTime++ },
+		want:   "invalid signed entry timestamp",
+	}, {
+		name:   "invalid inclusion proof",
+		tamper: func(e *rekorEntry) { e.Verification.InclusionProof.Hashes = nil },
+		want:   "invalid inclusion proof: 0 hashes, want 2",
+	}, {
+		name: "unsigned checkpoint",
+		tamper: func(e *rekorEntry) {
+			text, _, _ := strings.Cut(e.Verification.InclusionProof.Checkpoint, "\n\n")
+			e.Verification.InclusionProof.Checkpoint = text + "\n\n— fake-rekor AAAAAAAA\n"
+		},
+		want: "invalid checkpoint: it is not signed by the transparency log",
+	}, {
+		name:   "no inclusion proof",
+		tamper: func(e *rekorEntry) { e.Verification.InclusionProof = nil },
+		want:   "it has no inclusion proof",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s.tamper = tt.tamper
+			defer func() { s.tamper = nil }()
+			args := append([]string{"-f", dir, "--certificate-identity", testIdentity, "--certificate-oidc-issuer", testIssuer,
+				"--fulcio-url", s.fulcio.URL, "--rekor-url", s.rekor.URL, "--kubeconfig", kubeconfig(t)}, tt.args...)
+			out, err := run(VerifyCommand(&cli.TektonParams{}), args...)
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("verify: %v: %s", err, out)
+				}
+				if !strings.Contains(out, "3 resources verified: 3 passed") {
+					t.Errorf("unexpected output: %s", out)
+				}
+				return
+			}
+			if err == nil || err.Error() != "3 resources failed verification" {
+				t.Fatalf("verify error = %v, want 3 resources failed verification", err)
+			}
+			if !strings.Contains(out, tt.want) {
+				t.Errorf("output does not contain %q: %s", tt.want, out)
+			}
+		})
+	}
+}
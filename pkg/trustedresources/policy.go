@@ -0,0 +1,313 @@
+package trustedresources
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/sigstore/sigstore/pkg/signature/kms"
+	"github.com/tektoncd/pipeline/pkg/apis/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	"go.yaml.in/yaml/v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"knative.dev/pkg/apis"
+)
+
+// The outcomes of the verification of a resource, the same as the Tekton
+// controller: a failure fails the runs, a warning is only logged.
+const (
+	outcomePassed  = "passed"
+	outcomeFailed  = "failed"
+	outcomeWarning = "warning"
+	outcomeSkipped = "skipped"
+)
+
+// policyResult is the verification of a resource with a policy, the error is
+// nil when one of its keys verifies the signature.
+type policyResult struct {
+	policy string
+	mode   v1alpha1.ModeType
+	err    error
+}
+
+// result is the verification of a resource, the error is the one which is
+// not related to a policy like a missing signature.
+type result struct {
+	resource *resource
+	outcome  string
+	policies []policyResult
+	err      error
+}
+
+// policyVerifier verifies the resources with the VerificationPolicies the
+// same way as the Tekton controller.
+type policyVerifier struct {
+	policies      []*v1alpha1.VerificationPolicy
+	noMatchPolicy string
+	// kubeClient returns the client to read the keys of the Secrets, it is
+	// only created for the policies which need it
+	kubeClient func() (kubernetes.Interface, error)
+
+	verifiers map[string][]signature.Verifier
+	errs      map[string]error
+}
+
+// verify verifies the resource from the source with the policies matching
+// the source.
+func (v *policyVerifier) verify(ctx context.Context, r *resource, source string) *result {
+	res := &result{resource: r, outcome: outcomePassed}
+	matched, err := matchPolicies(v.policies, source)
+	if err != nil {
+		res.outcome, res.err = outcomeFailed, err
+		return res
+	}
+	if len(matched) == 0 {
+		err := fmt.Errorf("no matching policies are found for resource: %s against source: %s", r.object.GetName(), source)
+		switch v.noMatchPolicy {
+		case config.FailNoMatchPolicy:
+			res.outcome, res.err = outcomeFailed, err
+		case config.WarnNoMatchPolicy:
+			res.outcome, res.err = outcomeWarning, err
+		default:
+			res.outcome = outcomeSkipped
+		}
+		return res
+	}
+
+	sig, err := signatureOf(r.object)
+	if err != nil {
+		res.outcome, res.err = outcomeFailed, err
+		return res
+	}
+	message, err := digest(r.object)
+	if err != nil {
+		res.outcome, res.err = outcomeFailed, err
+		return res
+	}
+	for _, p := range matched {
+		pr := policyResult{policy: p.Name, mode: p.Spec.Mode}
+		if pr.mode == "" {
+			pr.mode = v1alpha1.ModeEnforce
+		}
+		verifiers, err := v.policyVerifiers(ctx, p)
+		if err != nil {
+			pr.err = fmt.Errorf("failed to get verifiers from policy: %v", err)
+		} else {
+			pr.err = verifyAny(verifiers, message, sig)
+		}
+		res.policies = append(res.policies, pr)
+
+		if pr.err == nil {
+			continue
+		}
+		// a failing enforced policy fails the verification whatever the
+		// other policies, a failing warn policy only gives a warning
+		if pr.mode == v1alpha1.ModeWarn {
+			if res.outcome == outcomePassed {
+				res.outcome = outcomeWarning
+			}
+		} else {
+			res.outcome = outcomeFailed
+		}
+	}
+	return res
+}
+
+// matchPolicies returns the policies with a resource pattern matching the
+// source.
+func matchPolicies(policies []*v1alpha1.VerificationPolicy, source string) ([]*v1alpha1.VerificationPolicy, error) {
+	matched := []*v1alpha1.VerificationPolicy{}
+	for _, p := range policies {
+		for _, r := range p.Spec.Resources {
+			ok, err := regexp.MatchString(r.Pattern, source)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern %q of policy %s: %v", r.Pattern, p.Name, err)
+			}
+			if ok {
+				matched = append(matched, p)
+				break
+			}
+		}
+	}
+	return matched, nil
+}
+
+// policyVerifiers returns the verifiers of the keys of the authorities of
+// the policy.
+func (v *policyVerifier) policyVerifiers(ctx context.Context, p *v1alpha1.VerificationPolicy) ([]signature.Verifier, error) {
+	if v.verifiers == nil {
+		v.verifiers, v.errs = map[string][]signature.Verifier{}, map[string]error{}
+	}
+	key := p.Namespace + "/" + p.Name
+	if verifiers, ok := v.verifiers[key]; ok {
+		return verifiers, v.errs[key]
+	}
+
+	verifiers := []signature.Verifier{}
+	var err error
+	for _, a := range p.Spec.Authorities {
+		if a.Key == nil {
+			continue
+		}
+		var vs []signature.Verifier
+		vs, err = v.keyVerifiers(ctx, a.Key)
+		if err != nil {
+			err = fmt.Errorf("authority %s: %v", a.Name, err)
+			break
+		}
+		verifiers = append(verifiers, vs...)
+	}
+	if err == nil && len(verifiers) == 0 {
+		err = errors.New("no public keys are found in the authorities")
+	}
+	v.verifiers[key], v.errs[key] = verifiers, err
+	return verifiers, err
+}
+
+// keyVerifiers returns the verifiers of a key of a policy, a Secret can hold
+// several keys.
+func (v *policyVerifier) keyVerifiers(ctx context.Context, key *v1alpha1.KeyRef) ([]signature.Verifier, error) {
+	algorithm, ok := v1alpha1.SupportedSignatureAlgorithms[key.HashAlgorithm]
+	if !ok {
+		return nil, fmt.Errorf("unsupported hash algorithm %q", key.HashAlgorithm)
+	}
+	switch {
+	case key.Data != "":
+		verifier, err := pemVerifier([]byte(key.Data), algorithm)
+		if err != nil {
+			return nil, err
+		}
+		return []signature.Verifier{verifier}, nil
+	case key.KMS != "":
+		verifier, err := kms.Get(ctx, key.KMS, algorithm)
+		if err != nil {
+			return nil, fmt.Errorf("error getting kms verifier: %v", err)
+		}
+		return []signature.Verifier{verifier}, nil
+	case key.SecretRef != nil:
+		kube, err := v.kubeClient()
+		if err != nil {
+			return nil, err
+		}
+		secret, err := kube.CoreV1().Secrets(key.SecretRef.Namespace).Get(ctx, key.SecretRef.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get secret %s/%s: %v", key.SecretRef.Namespace, key.SecretRef.Name, err)
+		}
+		if len(secret.Data) == 0 {
+			return nil, fmt.Errorf("secret %s/%s has no keys", key.SecretRef.Namespace, key.SecretRef.Name)
+		}
+		names := make([]string, 0, len(secret.Data))
+		for name := range secret.Data {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		verifiers := []signature.Verifier{}
+		for _, name := range names {
+			verifier, err := pemVerifier(secret.Data[name], algorithm)
+			if err != nil {
+				return nil, fmt.Errorf("key %s of secret %s/%s: %v", name, key.SecretRef.Namespace, key.SecretRef.Name, err)
+			}
+			verifiers = append(verifiers, verifier)
+		}
+		return verifiers, nil
+	}
+	return nil, errors.New("the key has no data, kms or secretRef")
+}
+
+func pemVerifier(data []byte, algorithm crypto.Hash) (signature.Verifier, error) {
+	pub, err := cryptoutils.UnmarshalPEMToPublicKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %v", err)
+	}
+	return signature.LoadVerifier(pub, algorithm)
+}
+
+// verifyAny returns nil when one of the verifiers verifies the signature of
+// the message.
+func verifyAny(verifiers []signature.Verifier, message, sig []byte) error {
+	var err error
+	for _, verifier := range verifiers {
+		if err = verifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader(message)); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("resource verification failed: %v", err)
+}
+
+// loadPolicies returns the VerificationPolicies of the files, which must be
+// valid.
+func loadPolicies(ctx context.Context, files []string) ([]*v1alpha1.VerificationPolicy, error) {
+	policies := []*v1alpha1.VerificationPolicy{}
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		dec := yaml.NewDecoder(bytes.NewReader(b))
+		for {
+			var content map[string]interface{}
+			err := dec.Decode(&content)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %v", f, err)
+			}
+			if content == nil {
+				continue
+			}
+			if content["kind"] != "VerificationPolicy" {
+				return nil, fmt.Errorf("%s: %v is not a VerificationPolicy", f, content["kind"])
+			}
+			p := &v1alpha1.VerificationPolicy{}
+			if err := decode(content, p); err != nil {
+				return nil, fmt.Errorf("failed to decode VerificationPolicy of %s: %v", f, err)
+			}
+			p.SetDefaults(ctx)
+			if err := p.Validate(apis.WithinCreate(ctx)); err != nil {
+				return nil, fmt.Errorf("invalid VerificationPolicy %s of %s: %v", p.Name, f, err)
+			}
+			policies = append(policies, p)
+		}
+	}
+	return policies, nil
+}
+
+// printResults prints the verification of the resources with a summary, it
+// returns the number of resources which failed.
+func printResults(out io.Writer, results []*result) int {
+	counts := map[string]int{}
+	for _, res := range results {
+		counts[res.outcome]++
+		fmt.Fprintf(out, "%s: %s\n", res.resource, res.outcome)
+		if res.err != nil {
+			fmt.Fprintf(out, "  %v\n", res.err)
+		}
+		for _, p := range res.policies {
+			status := "passed"
+			if p.err != nil {
+				status = fmt.Sprintf("failed: %v", p.err)
+			}
+			fmt.Fprintf(out, "  policy %s (%s): %s\n", p.policy, p.mode, status)
+		}
+	}
+
+	summary := []string{}
+	for _, outcome := range []string{outcomePassed, outcomeFailed, outcomeWarning, outcomeSkipped} {
+		if counts[outcome] > 0 {
+			summary = append(summary, fmt.Sprintf("%d %s", counts[outcome], outcome))
+		}
+	}
+	fmt.Fprintf(out, "\n%d resources verified: %s\n", len(results), strings.Join(summary, ", "))
+	return counts[outcomeFailed]
+}
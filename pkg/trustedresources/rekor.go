@@ -0,0 +1,224 @@
+package trustedresources
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/bits"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+)
+
+// rekorKey is the public key of a Rekor transparency log, its ID is the
+// SHA-256 of the key.
+type rekorKey struct {
+	verifier signature.Verifier
+	logID    string
+}
+
+// setPayload is the payload of the signed entry timestamp of an entry, the
+// fields are in the order of the canonical JSON signed by Rekor.
+type setPayload struct {
+	Body           string `json:"body"`
+	IntegratedTime int64  `json:"integratedTime"`
+	LogID          string `json:"logID"`
+	LogIndex       int64  `json:"logIndex"`
+}
+
+// loadRekorKey loads the public key of the transparency log, from the file
+// or from Rekor.
+func (v *keylessVerifier) loadRekorKey(ctx context.Context) error {
+	if v.rekorKey != nil {
+		return nil
+	}
+	var pem []byte
+	if v.rekorPublicKey != "" {
+		b, err := os.ReadFile(v.rekorPublicKey)
+		if err != nil {
+			return err
+		}
+		pem = b
+	} else {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(v.rekorURL, "/")+"/api/v1/log/publicKey", nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Accept", "application/x-pem-file")
+		if pem, err = do(req); err != nil {
+			return fmt.Errorf("failed to get the public key of %s: %v", v.rekorURL, err)
+		}
+	}
+	key, err := newRekorKey(pem)
+	if err != nil {
+		return fmt.Errorf("invalid public key of the transparency log: %v", err)
+	}
+	v.rekorKey = key
+	return nil
+}
+
+// newRekorKey returns the key of the public key in PEM format.
+func newRekorKey(pem []byte) (*rekorKey, error) {
+	pub, err := cryptoutils.UnmarshalPEMToPublicKey(pem)
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := signature.LoadVerifier(pub, crypto.SHA256)
+	if err != nil {
+		return nil, err
+	}
+	id := sha256.Sum256(der)
+	return &rekorKey{verifier: verifier, logID: hex.EncodeToString(id[:])}, nil
+}
+
+// verifyEntry checks the signed entry timestamp of the entry and its
+// inclusion proof in the signed checkpoint of the log.
+func (k *rekorKey) verifyEntry(e *rekorEntry) error {
+	if e.LogID != k.logID {
+		return fmt.Errorf("it is in the log %s, not %s", e.LogID, k.logID)
+	}
+	if len(e.Verification.SignedEntryTimestamp) == 0 {
+		return errors.New("it has no signed entry timestamp")
+	}
+	// the body and the log ID are base64 and hex strings, json.Marshal
+	// writes them as the canonical JSON
+	payload, err := json.Marshal(&setPayload{
+		Body:           e.Body,
+		IntegratedTime: e.IntegratedTime,
+		LogID:          e.LogID,
+		LogIndex:       e.LogIndex,
+	})
+	if err != nil {
+		return err
+	}
+	if err := k.verifier.VerifySignature(bytes.NewReader(e.Verification.SignedEntryTimestamp), bytes.NewReader(payload)); err != nil {
+		return fmt.Errorf("invalid signed entry timestamp: %v", err)
+	}
+
+	proof := e.Verification.InclusionProof
+	if proof == nil {
+		return errors.New("it has no inclusion proof")
+	}
+	body, err := base64.StdEncoding.DecodeString(e.Body)
+	if err != nil {
+		return err
+	}
+	if err := verifyInclusion(proof, body); err != nil {
+		return fmt.Errorf("invalid inclusion proof: %v", err)
+	}
+	if err := k.verifyCheckpoint(proof); err != nil {
+		return fmt.Errorf("invalid checkpoint: %v", err)
+	}
+	return nil
+}
+
+// verifyCheckpoint checks that the checkpoint of the proof is signed by the
+// log for the size and the root hash of the tree of the proof.
+func (k *rekorKey) verifyCheckpoint(proof *inclusionProof) error {
+	text, signatures, ok := strings.Cut(proof.Checkpoint, "\n\n")
+	if !ok {
+		return errors.New("it is not a signed note")
+	}
+	text += "\n"
+	verified := false
+	for _, line := range strings.Split(strings.TrimSpace(signatures), "\n") {
+		// the signature lines are "— <name> <base64 of the key hint and
+		// the signature>"
+		fields := strings.Fields(strings.TrimPrefix(line, "— "))
+		if len(fields) != 2 {
+			continue
+		}
+		sig, err := base64.StdEncoding.DecodeString(fields[1])
+		if err != nil || len(sig) <= 4 {
+			continue
+		}
+		if k.verifier.VerifySignature(bytes.NewReader(sig[4:]), strings.NewReader(text)) == nil {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return errors.New("it is not signed by the transparency log")
+	}
+
+	lines := strings.Split(text, "\n")
+	if len(lines) < 3 {
+		return errors.New("it has no tree size and root hash")
+	}
+	size, err := strconv.ParseInt(lines[1], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid tree size: %v", err)
+	}
+	root, err := base64.StdEncoding.DecodeString(lines[2])
+	if err != nil {
+		return fmt.Errorf("invalid root hash: %v", err)
+	}
+	if size != proof.TreeSize || hex.EncodeToString(root) != proof.RootHash {
+		return errors.New("it is not the tree of the inclusion proof")
+	}
+	return nil
+}
+
+// verifyInclusion checks the RFC 6962 inclusion proof of the leaf in the
+// tree of the proof.
+func verifyInclusion(proof *inclusionProof, leaf []byte) error {
+	if proof.LogIndex < 0 || proof.LogIndex >= proof.TreeSize {
+		return fmt.Errorf("index %d out of the tree of size %d", proof.LogIndex, proof.TreeSize)
+	}
+	index, size := uint64(proof.LogIndex), uint64(proof.TreeSize)
+	hashes := make([][]byte, 0, len(proof.Hashes))
+	for _, h := range proof.Hashes {
+		b, err := hex.DecodeString(h)
+		if err != nil {
+			return err
+		}
+		hashes = append(hashes, b)
+	}
+	inner := bits.Len64(index ^ (size - 1))
+	border := bits.OnesCount64(index >> inner)
+	if len(hashes) != inner+border {
+		return fmt.Errorf("%d hashes, want %d", len(hashes), inner+border)
+	}
+
+	root := hashLeaf(leaf)
+	for i, h := range hashes[:inner] {
+		if (index>>i)&1 == 0 {
+			root = hashChildren(root, h)
+		} else {
+			root = hashChildren(h, root)
+		}
+	}
+	for _, h := range hashes[inner:] {
+		root = hashChildren(h, root)
+	}
+	if hex.EncodeToString(root) != proof.RootHash {
+		return fmt.Errorf("the root hash is %x, not %s", root, proof.RootHash)
+	}
+	return nil
+}
+
+func hashLeaf(leaf []byte) []byte {
+	h := sha256.Sum256(append([]byte{0}, leaf...))
+	return h[:]
+}
+
+func hashChildren(l, r []byte) []byte {
+	b := append([]byte{1}, l...)
+	h := sha256.Sum256(append(b, r...))
+	return h[:]
+}
@@ -0,0 +1,59 @@
+package trustedresources
+
+import (
+	"encoding/hex"
+	"fmt"
+	"testing"
+)
+
+func TestVerifyInclusion(t *testing.T) {
+	for size := 1; size <= 9; size++ {
+		leaves := [][]byte{}
+		for i := 0; i < size; i++ {
+			leaves = append(leaves, []byte(fmt.Sprintf("leaf %d", i)))
+		}
+		root := hex.EncodeToString(merkleRoot(leaves))
+		for index := range leaves {
+			proof := &inclusionProof{LogIndex: int64(index), TreeSize: int64(size), RootHash: root}
+			for _, h := range merklePath(index, leaves) {
+				proof.Hashes = append(proof.Hashes, hex.EncodeToString(h))
+			}
+			if err := verifyInclusion(proof, leaves[index]); err != nil {
+				t.Errorf("leaf %d of %d: %v", index, size, err)
+			}
+			if err := verifyInclusion(proof, []byte("other")); err == nil {
+				t.Errorf("leaf %d of %d: another leaf is included", index, size)
+			}
+		}
+	}
+}
+
+func TestVerifyInclusionInvalid(t *testing.T) {
+	leaves := [][]byte{[]byte("a"), []byte("b"), []byte("c")}
+	root := hex.EncodeToString(merkleRoot(leaves))
+	tests := []struct {
+		name  string
+		proof *inclusionProof
+		want  string
+	}{{
+		name:  "index out of the tree",
+		proof: &inclusionProof{LogIndex: 3, TreeSize: 3, RootHash: root},
+		want:  "index 3 out of the tree of size 3",
+	}, {
+		name:  "missing hashes",
+		proof: &inclusionProof{LogIndex: 0, TreeSize: 3, RootHash: root},
+		want:  "0 hashes, want 2",
+	}, {
+		name:  "invalid hash",
+		proof: &inclusionProof{LogIndex: 2, TreeSize: 3, RootHash: root, Hashes: []string{"zz"}},
+		want:  "encoding/hex: invalid byte: U+007A 'z'",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyInclusion(tt.proof, []byte("a"))
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("verifyInclusion() error = %v, want %s", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package trustedresources
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/openshift-pipelines/opc/pkg/signing"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/spf13/cobra"
+	"github.com/tektoncd/cli/pkg/cli"
+	"github.com/tektoncd/cli/pkg/trustedresources"
+)
+
+type signOptions struct {
+	Filenames     []string
+	KeyFile       string
+	KMSKey        string
+	Keyless       bool
+	FulcioURL     string
+	RekorURL      string
+	IdentityToken string
+}
+
+// SignCommand returns the command signing the Tekton resources of files in
+// place.
+func SignCommand(_ cli.Params) *cobra.Command {
+	opts := &signOptions{FulcioURL: defaultFulcioURL, RekorURL: defaultRekorURL}
+	eg := `Sign the Tasks, Pipelines and StepActions of the directory tekton with the key cosign.key:
+
+    opc sign -f tekton/ -K cosign.key
+
+Sign them keyless with the identity of an OIDC token, using a private Fulcio and Rekor:
+
+    opc sign -f tekton/ --keyless --identity-token "$(cat token)" --fulcio-url https://fulcio.example.com --rekor-url https://rekor.example.com
+`
+
+	c := &cobra.Command{
+		Use:   "sign",
+		Short: "Sign the Tekton resources of files in place",
+		Long: `Sign the Tasks, Pipelines and StepActions of the files in place, the directories are searched recursively for
+YAML files. The signature is added to the tekton.dev/signature annotation of each resource the same way as "tkn task
+sign", so that the resources can be verified by the Tekton controller with VerificationPolicies and by "opc verify".
+The other documents of the files are left as is.
+
+The resources are signed with a private key file, its password is read from the COSIGN_PASSWORD environment variable
+or from the terminal, or with a KMS key.
+
+With --keyless, the resources are signed with an ephemeral key certified by a Fulcio compatible certificate authority
+for the identity of an OIDC token, given with --identity-token or the SIGSTORE_ID_TOKEN environment variable. The
+certificate is added to the tekton.dev/signature-certificate annotation and the signature is recorded in a Rekor
+compatible transparency log, its entry is added to the tekton.dev/signature-log-entry annotation. The keyless
+signatures are only verified by "opc verify", not by the Tekton controller.`,
+		Example: eg,
+		Args:    cobra.NoArgs,
+		Annotations: map[string]string{
+			"commandType": "main",
+			"kubernetes":  "false",
+		},
+		SilenceUsage: true,
+		PreRunE: func(_ *cobra.Command, _ []string) error {
+			if len(opts.Filenames) == 0 {
+				return errors.New("at least one file or directory must be given with --filename")
+			}
+			n := 0
+			for _, set := range []bool{opts.KeyFile != "", opts.KMSKey != "", opts.Keyless} {
+				if set {
+					n++
+				}
+			}
+			if n != 1 {
+				return errors.New("one of --key-file, --kms-key and --keyless must be given")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx := cmd.Context()
+			files, err := load(opts.Filenames)
+			if err != nil {
+				return err
+			}
+			count := 0
+			for _, f := range files {
+				count += len(f.resources)
+			}
+			if count == 0 {
+				return errors.New("no Tasks, Pipelines or StepActions found")
+			}
+
+			var signer signature.Signer
+			var keyless *keylessSigner
+			if opts.Keyless {
+				keyless, err = newKeylessSigner(ctx, opts.FulcioURL, opts.RekorURL, opts.IdentityToken)
+				if err != nil {
+					return err
+				}
+				signer = keyless.signer
+			} else {
+				signer, err = signing.LoadSigner(ctx, opts.KeyFile, opts.KMSKey, signing.KeyPassword(cmd.InOrStdin(), cmd.ErrOrStderr()))
+				if err != nil {
+					return err
+				}
+			}
+
+			for _, f := range files {
+				if len(f.resources) == 0 {
+					continue
+				}
+				for _, r := range f.resources {
+					for _, a := range []string{trustedresources.SignatureAnnotation, certificateAnnotation, logEntryAnnotation} {
+						r.setAnnotation(a, "")
+					}
+					if keyless != nil {
+						r.setAnnotation(certificateAnnotation, base64.StdEncoding.EncodeToString(keyless.chain))
+					}
+					message, err := digest(r.object)
+					if err != nil {
+						return err
+					}
+					sig, err := signer.SignMessage(bytes.NewReader(message))
+					if err != nil {
+						return fmt.Errorf("failed to sign %s: %v", r, err)
+					}
+					r.setAnnotation(trustedresources.SignatureAnnotation, base64.StdEncoding.EncodeToString(sig))
+					if keyless != nil {
+						entry, err := keyless.record(ctx, message, sig)
+						if err != nil {
+							return err
+						}
+						b, err := json.Marshal(entry)
+						if err != nil {
+							return err
+						}
+						r.setAnnotation(logEntryAnnotation, string(b))
+					}
+				}
+				if err := f.write(); err != nil {
+					return fmt.Errorf("failed to write %s: %v", f.path, err)
+				}
+				for _, r := range f.resources {
+					fmt.Fprintf(cmd.OutOrStdout(), "Signed %s\n", r)
+				}
+			}
+			return nil
+		},
+	}
+	c.Flags().StringSliceVarP(&opts.Filenames, "filename", "f", nil, "files or directories of the resources to sign")
+	c.Flags().StringVarP(&opts.KeyFile, "key-file", "K", "", "path of the private key to sign the resources with, in PEM format as generated by cosign")
+	c.Flags().StringVarP(&opts.KMSKey, "kms-key", "m", "", "KMS key to sign the resources with")
+	c.Flags().BoolVar(&opts.Keyless, "keyless", false, "sign with an ephemeral key certified for the identity of an OIDC token")
+	c.Flags().StringVar(&opts.FulcioURL, "fulcio-url", opts.FulcioURL, "URL of the Fulcio compatible certificate authority for keyless signing")
+	c.Flags().StringVar(&opts.RekorURL, "rekor-url", opts.RekorURL, "URL of the Rekor compatible transparency log for keyless signing")
+	c.Flags().StringVar(&opts.IdentityToken, "identity-token", "", "OIDC identity token for keyless signing, read from SIGSTORE_ID_TOKEN when empty")
+
+	return c
+}
@@ -0,0 +1,147 @@
+package trustedresources
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/spf13/cobra"
+	"github.com/tektoncd/cli/pkg/cli"
+)
+
+// run executes the command with the arguments and returns its output.
+func run(c *cobra.Command, args ...string) (string, error) {
+	out := &bytes.Buffer{}
+	c.SetOut(out)
+	c.SetErr(out)
+	c.SetArgs(args)
+	err := c.Execute()
+	return out.String(), err
+}
+
+// kubeconfig writes a kubeconfig of a cluster which is never contacted.
+func kubeconfig(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "kubeconfig")
+	config := `apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: https://127.0.0.1:1
+contexts:
+- name: test
+  context:
+    cluster: test
+    namespace: default
+current-context: test
+`
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// writePolicy writes a VerificationPolicy with the public key for all the
+// sources.
+func writePolicy(t *testing.T, name string, pub []byte) string {
+	t.Helper()
+	indented := "        " + strings.ReplaceAll(strings.TrimSpace(string(pub)), "\n", "\n        ")
+	policy := `apiVersion: tekton.dev/v1alpha1
+kind: VerificationPolicy
+metadata:
+  name: ` + name + `
+spec:
+  resources:
+  - pattern: ".*"
+  authorities:
+  - name: key
+    key:
+      data: |
+` + indented + "\n"
+	path := filepath.Join(t.TempDir(), name+".yaml")
+	if err := os.WriteFile(path, []byte(policy), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSignAndVerifyWithKey(t *testing.T) {
+	priv, pub, err := cryptoutils.GeneratePEMEncodedECDSAKeyPair(elliptic.P256(), cryptoutils.StaticPasswordFunc([]byte("secret")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "cosign.key")
+	if err := os.WriteFile(keyFile, priv, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("COSIGN_PASSWORD", "secret")
+
+	dir := writeResources(t)
+	out, err := run(SignCommand(nil), "-f", dir, "-K", keyFile)
+	if err != nil {
+		t.Fatalf("sign: %v: %s", err, out)
+	}
+	if got := strings.Count(out, "Signed "); got != 3 {
+		t.Errorf("got %d resources signed, want 3: %s", got, out)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "resources.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "name: unsigned") {
+		t.Errorf("the ConfigMap was not kept: %s", b)
+	}
+
+	_, otherPub, err := cryptoutils.GeneratePEMEncodedECDSAKeyPair(elliptic.P256(), cryptoutils.StaticPasswordFunc(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err = run(VerifyCommand(&cli.TektonParams{}), "-f", dir, "--policy", writePolicy(t, "signer", pub), "--kubeconfig", kubeconfig(t))
+	if err != nil {
+		t.Fatalf("verify: %v: %s", err, out)
+	}
+	if !strings.Contains(out, "3 resources verified: 3 passed") {
+		t.Errorf("unexpected output: %s", out)
+	}
+
+	out, err = run(VerifyCommand(&cli.TektonParams{}), "-f", dir, "--policy", writePolicy(t, "other", otherPub), "--kubeconfig", kubeconfig(t))
+	if err == nil || err.Error() != "3 resources failed verification" {
+		t.Fatalf("verify with another key: error = %v, want 3 resources failed verification: %s", err, out)
+	}
+	if !strings.Contains(out, "policy other (enforce): failed: resource verification failed") {
+		t.Errorf("unexpected output: %s", out)
+	}
+}
+
+func TestSignFlags(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{{
+		name: "no files",
+		args: []string{"-K", "cosign.key"},
+		want: "at least one file or directory must be given with --filename",
+	}, {
+		name: "no key",
+		args: []string{"-f", "tekton"},
+		want: "one of --key-file, --kms-key and --keyless must be given",
+	}, {
+		name: "several keys",
+		args: []string{"-f", "tekton", "-K", "cosign.key", "--keyless"},
+		want: "one of --key-file, --kms-key and --keyless must be given",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := run(SignCommand(nil), tt.args...)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("error = %v, want %s", err, tt.want)
+			}
+		})
+	}
+}
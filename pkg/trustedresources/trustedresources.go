@@ -0,0 +1,340 @@
+// Package trustedresources adds the commands signing the Tekton resources of
+// files in place and verifying them the same way as the Tekton controller
+// does with the VerificationPolicies.
+package trustedresources
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tektoncd/cli/pkg/trustedresources"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	"go.yaml.in/yaml/v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// certificateAnnotation holds the certificate chain of the keyless
+	// signatures, base64 encoded. It is signed with the resource.
+	certificateAnnotation = "tekton.dev/signature-certificate"
+	// logEntryAnnotation holds the transparency log entry of the keyless
+	// signatures. It is added once the resource is signed so it is not
+	// signed.
+	logEntryAnnotation = "tekton.dev/signature-log-entry"
+)
+
+// unsignedAnnotations are the annotations left out of the signed resources,
+// the same as the Tekton controller plus the transparency log entry.
+var unsignedAnnotations = []string{
+	trustedresources.SignatureAnnotation,
+	logEntryAnnotation,
+	"kubectl-client-side-apply",
+	"kubectl.kubernetes.io/last-applied-configuration",
+}
+
+// kinds are the resources signed, by apiVersion and kind.
+var kinds = map[string]func() metav1.Object{
+	"tekton.dev/v1/Task":             func() metav1.Object { return &v1.Task{} },
+	"tekton.dev/v1beta1/Task":        func() metav1.Object { return &v1beta1.Task{} },
+	"tekton.dev/v1/Pipeline":         func() metav1.Object { return &v1.Pipeline{} },
+	"tekton.dev/v1beta1/Pipeline":    func() metav1.Object { return &v1beta1.Pipeline{} },
+	"tekton.dev/v1beta1/StepAction":  func() metav1.Object { return &v1beta1.StepAction{} },
+	"tekton.dev/v1alpha1/StepAction": func() metav1.Object { return &v1alpha1.StepAction{} },
+}
+
+// resource is a Tekton resource of a file, the node is the mapping of its
+// YAML document updated with the annotations of the signature.
+type resource struct {
+	file   string
+	node   *yaml.Node
+	kind   string
+	object metav1.Object
+}
+
+func (r *resource) String() string {
+	return fmt.Sprintf("%s %s in %s", r.kind, r.object.GetName(), r.file)
+}
+
+// file is a YAML file with its documents, written back once its resources
+// are signed.
+type file struct {
+	path      string
+	docs      []*yaml.Node
+	resources []*resource
+}
+
+// yamlFiles returns the YAML files of the paths, the directories are
+// searched recursively skipping the hidden ones.
+func yamlFiles(paths []string) ([]string, error) {
+	files := []string{}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if p != path && strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if ext := filepath.Ext(p); ext == ".yaml" || ext == ".yml" {
+				files = append(files, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// load returns the YAML files of the paths with their Tekton resources, the
+// documents of other kinds are kept as is.
+func load(paths []string) ([]*file, error) {
+	names, err := yamlFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+	files := []*file{}
+	for _, name := range names {
+		b, err := os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		f := &file{path: name}
+		dec := yaml.NewDecoder(bytes.NewReader(b))
+		for {
+			doc := &yaml.Node{}
+			err := dec.Decode(doc)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %v", name, err)
+			}
+			f.docs = append(f.docs, doc)
+			if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+				continue
+			}
+			r, err := newResource(name, doc.Content[0])
+			if err != nil {
+				return nil, err
+			}
+			if r != nil {
+				f.resources = append(f.resources, r)
+			}
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// newResource decodes the document, nil is returned when it is not one of
+// the kinds signed.
+func newResource(file string, node *yaml.Node) (*resource, error) {
+	var typeMeta struct {
+		APIVersion string `yaml:"apiVersion"`
+		Kind       string `yaml:"kind"`
+	}
+	if err := node.Decode(&typeMeta); err != nil {
+		return nil, nil
+	}
+	newObject, ok := kinds[typeMeta.APIVersion+"/"+typeMeta.Kind]
+	if !ok {
+		return nil, nil
+	}
+
+	var content interface{}
+	if err := node.Decode(&content); err != nil {
+		return nil, fmt.Errorf("failed to decode %s %s: %v", typeMeta.Kind, file, err)
+	}
+	obj := newObject()
+	if err := decode(content, obj); err != nil {
+		return nil, fmt.Errorf("failed to decode %s %s: %v", typeMeta.Kind, file, err)
+	}
+	return &resource{file: file, node: node, kind: typeMeta.Kind, object: obj}, nil
+}
+
+// decode decodes the content of a YAML document to the Kubernetes type
+// through JSON, as the types only have JSON tags.
+func decode(content, obj interface{}) error {
+	b, err := json.Marshal(content)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, obj)
+}
+
+// write writes the documents back to the file.
+func (f *file) write() error {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	for _, doc := range f.docs {
+		if err := enc.Encode(doc); err != nil {
+			return err
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(f.path, buf.Bytes(), info.Mode().Perm())
+}
+
+// digest returns the sha256 of the JSON of the signed object of the
+// resource, the message signed.
+func digest(o metav1.Object) ([]byte, error) {
+	b, err := json.Marshal(signedObject(o))
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.Sum256(b)
+	return h[:], nil
+}
+
+// signedObject returns the object signed for the resource, the same as the
+// Tekton controller verifies: its type, its metadata without the fields
+// set by the cluster and its spec.
+func signedObject(o metav1.Object) interface{} {
+	meta := metav1.ObjectMeta{
+		Name:         o.GetName(),
+		GenerateName: o.GetGenerateName(),
+		Namespace:    o.GetNamespace(),
+		Annotations:  map[string]string{},
+	}
+	if o.GetLabels() != nil {
+		meta.Labels = map[string]string{}
+		for k, v := range o.GetLabels() {
+			meta.Labels[k] = v
+		}
+	}
+	for k, v := range o.GetAnnotations() {
+		meta.Annotations[k] = v
+	}
+	for _, a := range unsignedAnnotations {
+		delete(meta.Annotations, a)
+	}
+
+	switch o := o.(type) {
+	case *v1.Task:
+		return &v1.Task{TypeMeta: o.TypeMeta, ObjectMeta: meta, Spec: o.Spec}
+	case *v1beta1.Task:
+		return &v1beta1.Task{TypeMeta: o.TypeMeta, ObjectMeta: meta, Spec: o.Spec}
+	case *v1.Pipeline:
+		return &v1.Pipeline{TypeMeta: o.TypeMeta, ObjectMeta: meta, Spec: o.Spec}
+	case *v1beta1.Pipeline:
+		return &v1beta1.Pipeline{TypeMeta: o.TypeMeta, ObjectMeta: meta, Spec: o.Spec}
+	case *v1beta1.StepAction:
+		return &v1beta1.StepAction{TypeMeta: o.TypeMeta, ObjectMeta: meta, Spec: o.Spec}
+	case *v1alpha1.StepAction:
+		return &v1alpha1.StepAction{TypeMeta: o.TypeMeta, ObjectMeta: meta, Spec: o.Spec}
+	}
+	return o
+}
+
+// signatureOf returns the signature of the resource, an error is returned
+// when it is not signed.
+func signatureOf(o metav1.Object) ([]byte, error) {
+	sig, ok := o.GetAnnotations()[trustedresources.SignatureAnnotation]
+	if !ok {
+		return nil, errors.New("resource is not signed")
+	}
+	b, err := base64.StdEncoding.DecodeString(sig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature: %v", err)
+	}
+	return b, nil
+}
+
+// setAnnotation sets the annotation on the object and on its YAML, an empty
+// value removes it.
+func (r *resource) setAnnotation(key, value string) {
+	a := r.object.GetAnnotations()
+	if a == nil {
+		a = map[string]string{}
+	}
+	if value == "" {
+		delete(a, key)
+	} else {
+		a[key] = value
+	}
+	r.object.SetAnnotations(a)
+
+	metadata := mappingValue(r.node, "metadata", value != "")
+	if metadata == nil {
+		return
+	}
+	annotations := mappingValue(metadata, "annotations", value != "")
+	if annotations == nil {
+		return
+	}
+	for i := 0; i+1 < len(annotations.Content); i += 2 {
+		if annotations.Content[i].Value != key {
+			continue
+		}
+		if value == "" {
+			annotations.Content = append(annotations.Content[:i], annotations.Content[i+2:]...)
+		} else {
+			annotations.Content[i+1] = scalar(value)
+		}
+		return
+	}
+	if value != "" {
+		annotations.Content = append(annotations.Content, scalar(key), scalar(value))
+	}
+}
+
+// mappingValue returns the mapping of the key of the node, it is added when
+// missing if create is set.
+func mappingValue(node *yaml.Node, key string, create bool) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value != key {
+			continue
+		}
+		v := node.Content[i+1]
+		if v.Kind == yaml.MappingNode {
+			return v
+		}
+		if !create {
+			return nil
+		}
+		// a null value like "annotations:"
+		*v = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		return v
+	}
+	if !create {
+		return nil
+	}
+	v := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	node.Content = append(node.Content, scalar(key), v)
+	return v
+}
+
+func scalar(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
@@ -0,0 +1,150 @@
+package trustedresources
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/tektoncd/cli/pkg/cli"
+	"github.com/tektoncd/cli/pkg/cli/prerun"
+	"github.com/tektoncd/cli/pkg/flags"
+	"github.com/tektoncd/pipeline/pkg/apis/config"
+)
+
+type verifyOptions struct {
+	Filenames     []string
+	Policies      []string
+	Source        string
+	NoMatchPolicy string
+	Identity      string
+	Issuer        string
+	FulcioURL     string
+	RekorURL      string
+	CARoots       string
+	RekorKey      string
+}
+
+// VerifyCommand returns the command verifying the signatures of the Tekton
+// resources of files.
+func VerifyCommand(p cli.Params) *cobra.Command {
+	opts := &verifyOptions{
+		NoMatchPolicy: config.DefaultNoMatchPolicyConfig,
+		FulcioURL:     defaultFulcioURL,
+		RekorURL:      defaultRekorURL,
+	}
+	eg := `Verify the Tasks, Pipelines and StepActions of the directory tekton with the VerificationPolicies of policy.yaml
+as the Tekton controller does for the resources fetched from a git repository:
+
+    opc verify -f tekton/ --policy policy.yaml --source https://github.com/myorg/tekton.git
+
+Verify their keyless signatures made with a GitHub Actions token:
+
+    opc verify -f tekton/ --certificate-identity https://github.com/myorg/tekton/.github/workflows/sign.yaml@refs/heads/main \
+        --certificate-oidc-issuer https://token.actions.githubusercontent.com
+`
+
+	c := &cobra.Command{
+		Use:   "verify",
+		Short: "Verify the signatures of the Tekton resources of files",
+		Long: `Verify the signatures of the Tasks, Pipelines and StepActions of the files, the directories are searched
+recursively for YAML files.
+
+With --policy, the resources are verified with the VerificationPolicies of the files the same way as the Tekton
+controller: the policies with a resource pattern matching the source of the resources are evaluated, the source is
+the URI the resolvers fetch the resources from like a git URL or a bundle reference. The verification fails when an
+enforced policy fails and gives a warning when a policy in warn mode fails. The resources without matching policy
+are skipped, or fail or give a warning as configured with --no-match-policy like the
+trusted-resources-verification-no-match-policy feature flag. The keys of the policies stored in Secrets are read
+from the cluster.
+
+With --certificate-identity and --certificate-oidc-issuer, the keyless signatures made by "opc sign --keyless" are
+verified: the certificate must be issued by the Fulcio certificate authority for the identity and the issuer, and
+the signature must be recorded in the Rekor transparency log while the certificate was valid. The signed entry
+timestamp and the inclusion proof of the entry are verified with the public key of the log.`,
+		Example: eg,
+		Args:    cobra.NoArgs,
+		Annotations: map[string]string{
+			"commandType": "main",
+		},
+		SilenceUsage:      true,
+		PersistentPreRunE: prerun.PersistentPreRunE(p),
+		PreRunE: func(_ *cobra.Command, _ []string) error {
+			if len(opts.Filenames) == 0 {
+				return errors.New("at least one file or directory must be given with --filename")
+			}
+			keyless := opts.Identity != "" || opts.Issuer != ""
+			if keyless && (opts.Identity == "" || opts.Issuer == "") {
+				return errors.New("both --certificate-identity and --certificate-oidc-issuer must be given")
+			}
+			if keyless == (len(opts.Policies) > 0) {
+				return errors.New("one of --policy and --certificate-identity must be given")
+			}
+			switch opts.NoMatchPolicy {
+			case config.FailNoMatchPolicy, config.WarnNoMatchPolicy, config.IgnoreNoMatchPolicy:
+			default:
+				return fmt.Errorf("invalid value %q for --no-match-policy, it must be one of fail, warn and ignore", opts.NoMatchPolicy)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx := cmd.Context()
+			files, err := load(opts.Filenames)
+			if err != nil {
+				return err
+			}
+			resources := []*resource{}
+			for _, f := range files {
+				resources = append(resources, f.resources...)
+			}
+			if len(resources) == 0 {
+				return errors.New("no Tasks, Pipelines or StepActions found")
+			}
+
+			results := []*result{}
+			if len(opts.Policies) > 0 {
+				policies, err := loadPolicies(ctx, opts.Policies)
+				if err != nil {
+					return err
+				}
+				v := &policyVerifier{policies: policies, noMatchPolicy: opts.NoMatchPolicy, kubeClient: p.KubeClient}
+				for _, r := range resources {
+					results = append(results, v.verify(ctx, r, opts.Source))
+				}
+			} else {
+				v := &keylessVerifier{
+					fulcioURL:      opts.FulcioURL,
+					rekorURL:       opts.RekorURL,
+					caRoots:        opts.CARoots,
+					rekorPublicKey: opts.RekorKey,
+					identity:       opts.Identity,
+					issuer:         opts.Issuer,
+				}
+				for _, r := range resources {
+					res := &result{resource: r, outcome: outcomePassed}
+					if err := v.verify(ctx, r); err != nil {
+						res.outcome, res.err = outcomeFailed, err
+					}
+					results = append(results, res)
+				}
+			}
+
+			if failed := printResults(cmd.OutOrStdout(), results); failed > 0 {
+				return fmt.Errorf("%d resources failed verification", failed)
+			}
+			return nil
+		},
+	}
+	flags.AddTektonOptions(c)
+	c.Flags().StringSliceVarP(&opts.Filenames, "filename", "f", nil, "files or directories of the resources to verify")
+	c.Flags().StringSliceVar(&opts.Policies, "policy", nil, "files of the VerificationPolicies to verify the resources with")
+	c.Flags().StringVar(&opts.Source, "source", "", "source of the resources matched against the resource patterns of the policies")
+	c.Flags().StringVar(&opts.NoMatchPolicy, "no-match-policy", opts.NoMatchPolicy, "outcome of the resources without matching policy: fail, warn or ignore")
+	c.Flags().StringVar(&opts.Identity, "certificate-identity", "", "identity the certificates of the keyless signatures must be issued for, an email or a URI")
+	c.Flags().StringVar(&opts.Issuer, "certificate-oidc-issuer", "", "OIDC issuer of the identity of the certificates of the keyless signatures")
+	c.Flags().StringVar(&opts.FulcioURL, "fulcio-url", opts.FulcioURL, "URL of the Fulcio compatible certificate authority to get the trusted certificates from")
+	c.Flags().StringVar(&opts.CARoots, "ca-roots", "", "file of the trusted certificates of the certificate authority in PEM format, instead of getting them from Fulcio")
+	c.Flags().StringVar(&opts.RekorURL, "rekor-url", opts.RekorURL, "URL of the Rekor compatible transparency log")
+	c.Flags().StringVar(&opts.RekorKey, "rekor-public-key", "", "file of the public key of the transparency log in PEM format, instead of getting it from Rekor")
+
+	return c
+}